@@ -3,6 +3,7 @@ package anilist
 import (
 	"github.com/metafates/gache"
 	"github.com/metafates/mangal/filesystem"
+	"github.com/metafates/mangal/util"
 	"github.com/metafates/mangal/where"
 	"github.com/samber/mo"
 	"path/filepath"
@@ -69,7 +70,7 @@ var relationCacher = &cacher[string, int]{
 			FileSystem: &filesystem.GacheFs{},
 		},
 	),
-	keyWrapper: normalizedName,
+	keyWrapper: util.NormalizeName,
 }
 
 var searchCacher = &cacher[string, []int]{
@@ -80,7 +81,7 @@ var searchCacher = &cacher[string, []int]{
 			FileSystem: &filesystem.GacheFs{},
 		},
 	),
-	keyWrapper: normalizedName,
+	keyWrapper: util.NormalizeName,
 }
 
 var idCacher = &cacher[int, *Manga]{
@@ -102,5 +103,5 @@ var failCacher = &cacher[string, bool]{
 			FileSystem: &filesystem.GacheFs{},
 		},
 	),
-	keyWrapper: normalizedName,
+	keyWrapper: util.NormalizeName,
 }
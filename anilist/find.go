@@ -9,11 +9,6 @@ import (
 	"strings"
 )
 
-// normalizedName returns a normalized name for comparison
-func normalizedName(name string) string {
-	return strings.ToLower(strings.TrimSpace(name))
-}
-
 // SetRelation sets the relation between a manga name and an anilist id
 func SetRelation(name string, to *Manga) error {
 	err := relationCacher.Set(name, to.ID)
@@ -31,7 +26,7 @@ func SetRelation(name string, to *Manga) error {
 // FindClosest returns the closest manga to the given name.
 // It will levenshtein compare the given name with all the manga names in the cache.
 func FindClosest(name string) (*Manga, error) {
-	name = normalizedName(name)
+	name = util.NormalizeName(name)
 	return findClosest(name, name, 0, 3)
 }
 
@@ -100,10 +95,10 @@ func findClosest(name, originalName string, try, limit int) (*Manga, error) {
 	closest := lo.MinBy(mangas, func(a, b *Manga) bool {
 		return levenshtein.Distance(
 			name,
-			normalizedName(a.Name()),
+			util.NormalizeName(a.Name()),
 		) < levenshtein.Distance(
 			name,
-			normalizedName(b.Name()),
+			util.NormalizeName(b.Name()),
 		)
 	})
 
@@ -7,6 +7,7 @@ import (
 	"github.com/metafates/mangal/log"
 	"github.com/metafates/mangal/network"
 	"github.com/metafates/mangal/query"
+	"github.com/metafates/mangal/util"
 	"github.com/samber/lo"
 	"net/http"
 	"strconv"
@@ -87,7 +88,7 @@ func GetByID(id int) (*Manga, error) {
 
 // SearchByName returns a list of mangas that match the given name.
 func SearchByName(name string) ([]*Manga, error) {
-	name = normalizedName(name)
+	name = util.NormalizeName(name)
 	_ = query.Remember(name, 1)
 
 	if _, failed := failCacher.Get(name).Get(); failed {
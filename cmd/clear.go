@@ -23,6 +23,9 @@ var clearTargets = []clearTarget{
 	{"history file", "history", mo.Some("s"), where.History},
 	{"anilist binds", "anilist", mo.Some("a"), where.AnilistBinds},
 	{"queries history", "queries", mo.Some("q"), where.Queries},
+	{"download queue", "queue", mo.None[string](), where.Queue},
+	{"staged pages", "staging", mo.None[string](), where.Staging},
+	{"library index", "library", mo.None[string](), where.Library},
 }
 
 func init() {
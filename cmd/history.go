@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/metafates/mangal/color"
+	"github.com/metafates/mangal/filesystem"
+	"github.com/metafates/mangal/history"
+	"github.com/metafates/mangal/style"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+}
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Browse the reading history",
+	Long: `Browse the reading history.
+Every chapter that is read or downloaded is recorded with the time it happened.
+Saving is controlled by history.save_on_read and history.save_on_download options.`,
+}
+
+// historyFilterFlags adds the flags that filter history entries
+func historyFilterFlags(flags *pflag.FlagSet) {
+	flags.StringP("manga", "m", "", "show only entries of manga which name contains the given string")
+	flags.StringP("source", "s", "", "show only entries of sources which id contains the given string")
+	flags.StringP("action", "a", "", "show only entries with the given action (read or download)")
+	flags.String("since", "", "show only entries after the given date (2006-01-02) or duration ago (24h, 7d)")
+	flags.String("until", "", "show only entries before the given date (2006-01-02) or duration ago (24h, 7d)")
+}
+
+func registerHistoryActionCompletion(cmd *cobra.Command) {
+	lo.Must0(cmd.RegisterFlagCompletionFunc("action", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return lo.Map(history.Actions, func(a history.Action, _ int) string {
+			return string(a)
+		}), cobra.ShellCompDirectiveNoFileComp
+	}))
+}
+
+// historyEntries returns the history entries that match the filter flags
+func historyEntries(cmd *cobra.Command) ([]*history.SavedChapter, error) {
+	filter := &history.Filter{
+		Manga:  lo.Must(cmd.Flags().GetString("manga")),
+		Source: lo.Must(cmd.Flags().GetString("source")),
+		Action: history.Action(lo.Must(cmd.Flags().GetString("action"))),
+	}
+
+	if filter.Action != "" && !lo.Contains(history.Actions, filter.Action) {
+		return nil, fmt.Errorf("unknown action %q, use read or download", filter.Action)
+	}
+
+	var err error
+	if filter.Since, err = parseHistoryTime(lo.Must(cmd.Flags().GetString("since"))); err != nil {
+		return nil, err
+	}
+
+	if filter.Until, err = parseHistoryTime(lo.Must(cmd.Flags().GetString("until"))); err != nil {
+		return nil, err
+	}
+
+	entries, err := history.Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	return filter.Apply(entries), nil
+}
+
+// parseHistoryTime parses a date or a duration ago.
+// Durations are go durations with additional "d" unit for days
+func parseHistoryTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+
+	if strings.HasSuffix(value, "d") {
+		if n, err := strconv.Atoi(strings.TrimSuffix(value, "d")); err == nil && n >= 0 {
+			return time.Now().AddDate(0, 0, -n), nil
+		}
+	}
+
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return time.Now().Add(-d), nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %q, expected date such as 2006-01-02 or duration such as 24h or 7d", value)
+}
+
+func init() {
+	historyCmd.AddCommand(historyListCmd)
+
+	historyFilterFlags(historyListCmd.Flags())
+	registerHistoryActionCompletion(historyListCmd)
+	historyListCmd.Flags().BoolP("json", "j", false, "JSON output")
+	historyListCmd.Flags().IntP("limit", "n", 0, "show only the given number of the most recent entries")
+
+	historyListCmd.SetOut(os.Stdout)
+}
+
+var historyListCmd = &cobra.Command{
+	Use:     "list",
+	Short:   "List read and downloaded chapters",
+	Aliases: []string{"ls"},
+	Run: func(cmd *cobra.Command, args []string) {
+		entries, err := historyEntries(cmd)
+		handleErr(err)
+
+		if limit := lo.Must(cmd.Flags().GetInt("limit")); limit > 0 && len(entries) > limit {
+			entries = entries[len(entries)-limit:]
+		}
+
+		if lo.Must(cmd.Flags().GetBool("json")) {
+			handleErr(json.NewEncoder(cmd.OutOrStdout()).Encode(entries))
+			return
+		}
+
+		if len(entries) == 0 {
+			cmd.Println("History is empty")
+			return
+		}
+
+		for _, entry := range entries {
+			cmd.Printf(
+				"%s %s %s : %s %s\n",
+				style.Faint(entry.Time.Format("2006-01-02 15:04")),
+				historyActionStyle(entry.Action),
+				entry.MangaName,
+				entry.Name,
+				style.Faint(entry.SourceID),
+			)
+		}
+	},
+}
+
+func historyActionStyle(action history.Action) string {
+	var c = color.Blue
+
+	if action == history.ActionDownload {
+		c = color.Green
+	}
+
+	return style.Fg(c)(fmt.Sprintf("[%s]", action))
+}
+
+func init() {
+	historyCmd.AddCommand(historyExportCmd)
+
+	historyFilterFlags(historyExportCmd.Flags())
+	registerHistoryActionCompletion(historyExportCmd)
+	historyExportCmd.Flags().StringP("format", "f", "json", "export format (json or csv)")
+	historyExportCmd.Flags().StringP("output", "o", "", "file to write to, stdout if not set")
+	lo.Must0(historyExportCmd.RegisterFlagCompletionFunc("format", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"json", "csv"}, cobra.ShellCompDirectiveNoFileComp
+	}))
+
+	historyExportCmd.SetOut(os.Stdout)
+}
+
+var historyExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the history as JSON or CSV",
+	Run: func(cmd *cobra.Command, args []string) {
+		entries, err := historyEntries(cmd)
+		handleErr(err)
+
+		var (
+			format = lo.Must(cmd.Flags().GetString("format"))
+			output = lo.Must(cmd.Flags().GetString("output"))
+			w      io.Writer
+		)
+
+		if format != "json" && format != "csv" {
+			handleErr(fmt.Errorf("unknown format %q, use json or csv", format))
+		}
+
+		if output == "" {
+			w = cmd.OutOrStdout()
+		} else {
+			file, err := filesystem.Api().Create(output)
+			handleErr(err)
+			defer file.Close()
+
+			w = file
+		}
+
+		switch format {
+		case "json":
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "  ")
+			handleErr(encoder.Encode(entries))
+		case "csv":
+			handleErr(history.WriteCSV(w, entries))
+		}
+	},
+}
@@ -39,6 +39,7 @@ func init() {
 	lo.Must0(viper.BindPFlag(key.MetadataFetchAnilist, inlineCmd.Flags().Lookup("fetch-metadata")))
 
 	inlineCmd.Flags().StringP("output", "o", "", "output file")
+	inlineCmd.Flags().DurationP("timeout", "t", 0, "timeout for source requests, e.g. 30s. 0 means no timeout")
 
 	lo.Must0(inlineCmd.MarkFlagRequired("query"))
 	inlineCmd.MarkFlagsMutuallyExclusive("download", "json")
@@ -144,6 +145,7 @@ When using the json flag manga selector could be omitted. That way, it will sele
 			MangaPicker:         mangaPicker,
 			ChaptersFilter:      chapterFilter,
 			Out:                 writer,
+			Timeout:             lo.Must(cmd.Flags().GetDuration("timeout")),
 		}
 
 		handleErr(inline.Run(options))
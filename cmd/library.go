@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/metafates/mangal/color"
+	"github.com/metafates/mangal/icon"
+	"github.com/metafates/mangal/library"
+	"github.com/metafates/mangal/style"
+	"github.com/metafates/mangal/util"
+	"github.com/metafates/mangal/where"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(libraryCmd)
+}
+
+var libraryCmd = &cobra.Command{
+	Use:   "library",
+	Short: "Browse downloaded manga",
+	Long: `Browse downloaded manga without accessing any source.
+Downloads directory is indexed by the scan command.
+The index is stored in the config directory.`,
+}
+
+// scanLibrary indexes the downloads directory and saves the index
+func scanLibrary() (*library.Index, error) {
+	erase := util.PrintErasable(fmt.Sprintf("%s Scanning %s", icon.Get(icon.Progress), where.Downloads()))
+	defer erase()
+
+	index, err := library.Scan(where.Downloads())
+	if err != nil {
+		return nil, err
+	}
+
+	return index, library.Save(index)
+}
+
+// loadLibrary loads the library index, scanning the downloads directory if it was not scanned before
+func loadLibrary() (*library.Index, error) {
+	index, err := library.Load()
+	if errors.Is(err, library.ErrNotScanned) {
+		return scanLibrary()
+	}
+
+	return index, err
+}
+
+func init() {
+	libraryCmd.AddCommand(libraryScanCmd)
+
+	libraryScanCmd.Flags().BoolP("json", "j", false, "JSON output")
+	libraryScanCmd.SetOut(os.Stdout)
+}
+
+var libraryScanCmd = &cobra.Command{
+	Use:   "scan",
+	Short: "Index the downloads directory",
+	Run: func(cmd *cobra.Command, args []string) {
+		index, err := scanLibrary()
+		handleErr(err)
+
+		if lo.Must(cmd.Flags().GetBool("json")) {
+			handleErr(json.NewEncoder(cmd.OutOrStdout()).Encode(index))
+			return
+		}
+
+		cmd.Printf(
+			"%s Indexed %s and %s\n",
+			icon.Get(icon.Success),
+			util.Quantify(len(index.Series), "series", "series"),
+			util.Quantify(index.Chapters(), "chapter", "chapters"),
+		)
+	},
+}
+
+func init() {
+	libraryCmd.AddCommand(libraryListCmd)
+
+	libraryListCmd.Flags().BoolP("json", "j", false, "JSON output")
+	libraryListCmd.Flags().StringP("source", "s", "", "show only series from the given source")
+	libraryListCmd.SetOut(os.Stdout)
+}
+
+var libraryListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List downloaded series",
+	Run: func(cmd *cobra.Command, args []string) {
+		index, err := loadLibrary()
+		handleErr(err)
+
+		series := index.Series
+		if src := lo.Must(cmd.Flags().GetString("source")); src != "" {
+			series = lo.Filter(series, func(s *library.Series, _ int) bool {
+				return strings.EqualFold(s.Source, src)
+			})
+		}
+
+		if lo.Must(cmd.Flags().GetBool("json")) {
+			handleErr(json.NewEncoder(cmd.OutOrStdout()).Encode(series))
+			return
+		}
+
+		if len(series) == 0 {
+			cmd.Println("Library is empty")
+			return
+		}
+
+		for _, s := range series {
+			cmd.Printf(
+				"%s %s %s\n",
+				style.Bold(s.Name),
+				style.Fg(color.Purple)(s.Source),
+				style.Faint(fmt.Sprintf("%s, %s", util.Quantify(len(s.Chapters), "chapter", "chapters"), s.SizeHuman())),
+			)
+		}
+	},
+}
+
+func init() {
+	libraryCmd.AddCommand(libraryShowCmd)
+
+	libraryShowCmd.Flags().BoolP("json", "j", false, "JSON output")
+	libraryShowCmd.SetOut(os.Stdout)
+}
+
+var libraryShowCmd = &cobra.Command{
+	Use:     "show [name]",
+	Short:   "Show downloaded chapters of the series",
+	Example: "mangal library show \"One Piece\"",
+	Args:    cobra.MinimumNArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		index, err := library.Load()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return lo.Map(index.Series, func(s *library.Series, _ int) string {
+			return s.Name
+		}), cobra.ShellCompDirectiveNoFileComp
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		index, err := loadLibrary()
+		handleErr(err)
+
+		name := strings.Join(args, " ")
+		series, ok := index.Find(name)
+		if !ok {
+			handleErr(fmt.Errorf("series %q not found in the library", name))
+		}
+
+		if lo.Must(cmd.Flags().GetBool("json")) {
+			handleErr(json.NewEncoder(cmd.OutOrStdout()).Encode(series))
+			return
+		}
+
+		cmd.Println(style.Bold(series.Name))
+		if series.Source != "" {
+			cmd.Printf("%s %s\n", style.Fg(color.Blue)("Source:"), series.Source)
+		}
+		if series.Status != "" {
+			cmd.Printf("%s %s\n", style.Fg(color.Blue)("Status:"), series.Status)
+		}
+		cmd.Printf("%s %s\n", style.Fg(color.Blue)("Path:"), series.Path)
+		cmd.Printf("%s %s\n", style.Fg(color.Blue)("Size:"), series.SizeHuman())
+		cmd.Println()
+
+		for _, chapter := range series.Chapters {
+			name := chapter.Name
+			if chapter.Volume != "" {
+				name = fmt.Sprintf("%s %s", style.Faint(chapter.Volume), name)
+			}
+
+			cmd.Printf("%s %s %s\n", name, style.Fg(color.Yellow)(chapter.Format), style.Faint(chapter.SizeHuman()))
+		}
+	},
+}
@@ -0,0 +1,262 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/metafates/mangal/color"
+	"github.com/metafates/mangal/converter"
+	"github.com/metafates/mangal/downloader"
+	"github.com/metafates/mangal/icon"
+	"github.com/metafates/mangal/key"
+	"github.com/metafates/mangal/log"
+	"github.com/metafates/mangal/queue"
+	"github.com/metafates/mangal/source"
+	"github.com/metafates/mangal/style"
+	"github.com/metafates/mangal/util"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	rootCmd.AddCommand(queueCmd)
+}
+
+var queueCmd = &cobra.Command{
+	Use:   "queue",
+	Short: "Manage the download queue",
+	Long: `Manage the download queue.
+Every chapter that is downloaded is recorded in the queue,
+so that interrupted downloads can be resumed later.`,
+}
+
+func init() {
+	queueCmd.AddCommand(queueListCmd)
+
+	queueListCmd.Flags().BoolP("json", "j", false, "JSON output")
+	queueListCmd.Flags().StringSliceP("status", "s", []string{}, "show only entries with the given statuses")
+	lo.Must0(queueListCmd.RegisterFlagCompletionFunc("status", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return lo.Map(queue.Statuses, func(s queue.Status, _ int) string {
+			return string(s)
+		}), cobra.ShellCompDirectiveNoFileComp
+	}))
+
+	queueListCmd.SetOut(os.Stdout)
+}
+
+var queueListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List queued chapters",
+	Run: func(cmd *cobra.Command, args []string) {
+		entries, err := queue.Get()
+		handleErr(err)
+
+		if statuses := lo.Must(cmd.Flags().GetStringSlice("status")); len(statuses) > 0 {
+			entries = lo.Filter(entries, func(e *queue.Entry, _ int) bool {
+				return lo.Contains(statuses, string(e.Status))
+			})
+		}
+
+		if lo.Must(cmd.Flags().GetBool("json")) {
+			handleErr(json.NewEncoder(cmd.OutOrStdout()).Encode(entries))
+			return
+		}
+
+		if len(entries) == 0 {
+			cmd.Println("Queue is empty")
+			return
+		}
+
+		for _, entry := range entries {
+			cmd.Printf("%s %s %s\n", queueStatusStyle(entry.Status), entry, style.Faint(entry.SourceID))
+			if entry.Error != "" {
+				cmd.Println(style.Fg(color.Red)(entry.Error))
+			}
+		}
+	},
+}
+
+func queueStatusStyle(status queue.Status) string {
+	var c = color.Yellow
+
+	switch status {
+	case queue.StatusFinished:
+		c = color.Green
+	case queue.StatusFailed:
+		c = color.Red
+	case queue.StatusInProgress:
+		c = color.Blue
+	}
+
+	return style.Fg(c)(fmt.Sprintf("[%s]", status))
+}
+
+func init() {
+	queueCmd.AddCommand(queueResumeCmd)
+	queueResumeCmd.SetOut(os.Stdout)
+}
+
+var queueResumeCmd = &cobra.Command{
+	Use:   "resume",
+	Short: "Download pending chapters from the queue",
+	PreRun: func(cmd *cobra.Command, args []string) {
+		if _, err := converter.Get(viper.GetString(key.FormatsUse)); err != nil {
+			handleErr(err)
+		}
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		handleErr(downloadQueue(cmd))
+	},
+}
+
+func init() {
+	queueCmd.AddCommand(queueRetryFailedCmd)
+	queueRetryFailedCmd.SetOut(os.Stdout)
+}
+
+var queueRetryFailedCmd = &cobra.Command{
+	Use:     "retry-failed",
+	Short:   "Enqueue failed chapters again and download them",
+	Aliases: []string{"retry"},
+	PreRun: func(cmd *cobra.Command, args []string) {
+		if _, err := converter.Get(viper.GetString(key.FormatsUse)); err != nil {
+			handleErr(err)
+		}
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		count, err := queue.RetryFailed()
+		handleErr(err)
+
+		cmd.Printf("%s %s enqueued again\n", icon.Get(icon.Success), util.Quantify(count, "chapter", "chapters"))
+		handleErr(downloadQueue(cmd))
+	},
+}
+
+// downloadQueue downloads all pending chapters from the queue
+func downloadQueue(cmd *cobra.Command) error {
+	entries, err := queue.Pending()
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		cmd.Println("Nothing to download")
+		return nil
+	}
+
+	var (
+		resolver = queue.NewResolver()
+		bundle   = viper.GetBool(key.FormatsBundleVolumes)
+		resolved []*source.Chapter
+	)
+
+	for _, entry := range entries {
+		erase := util.PrintErasable(fmt.Sprintf("%s Preparing %s...", icon.Get(icon.Progress), entry))
+		chapter, err := resolver.Resolve(entry)
+		erase()
+
+		if err != nil {
+			// mark the entry as failed so that it can be retried later
+			if err := queue.MarkEntryFailed(entry, err); err != nil {
+				log.Warn(err)
+			}
+
+			cmd.Printf("%s %s: %s\n", icon.Get(icon.Fail), entry, err)
+
+			if viper.GetBool(key.DownloaderStopOnError) {
+				return err
+			}
+
+			continue
+		}
+
+		// volumes are downloaded once all chapters are resolved
+		if bundle {
+			resolved = append(resolved, chapter)
+			continue
+		}
+
+		path, err := downloader.Download(chapter, func(s string) {
+			erase()
+			erase = util.PrintErasable(fmt.Sprintf("%s %s: %s", icon.Get(icon.Progress), entry, s))
+		})
+		erase()
+
+		if err != nil {
+			cmd.Printf("%s %s: %s\n", icon.Get(icon.Fail), entry, err)
+
+			if viper.GetBool(key.DownloaderStopOnError) {
+				return err
+			}
+
+			continue
+		}
+
+		cmd.Printf("%s %s\n", icon.Get(icon.Success), path)
+	}
+
+	if bundle {
+		return downloadVolumes(cmd, resolved)
+	}
+
+	return nil
+}
+
+// downloadVolumes downloads chapters bundled by volumes
+func downloadVolumes(cmd *cobra.Command, chapters []*source.Chapter) error {
+	for _, volume := range downloader.Volumes(chapters) {
+		name := fmt.Sprintf("%s : %s", volume.Manga.Name, volume.Name)
+		erase := util.PrintErasable(fmt.Sprintf("%s Preparing %s...", icon.Get(icon.Progress), name))
+
+		path, err := downloader.DownloadVolume(volume, func(s string) {
+			erase()
+			erase = util.PrintErasable(fmt.Sprintf("%s %s: %s", icon.Get(icon.Progress), name, s))
+		})
+		erase()
+
+		if err != nil {
+			cmd.Printf("%s %s: %s\n", icon.Get(icon.Fail), name, err)
+
+			if viper.GetBool(key.DownloaderStopOnError) {
+				return err
+			}
+
+			continue
+		}
+
+		cmd.Printf("%s %s\n", icon.Get(icon.Success), path)
+	}
+
+	return nil
+}
+
+func init() {
+	queueCmd.AddCommand(queueClearCmd)
+	queueClearCmd.SetOut(os.Stdout)
+
+	queueClearCmd.Flags().BoolP("all", "a", false, "clear the whole queue")
+	queueClearCmd.Flags().BoolP("failed", "f", false, "clear failed entries too")
+	queueClearCmd.MarkFlagsMutuallyExclusive("all", "failed")
+}
+
+var queueClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Clear finished chapters from the queue",
+	Run: func(cmd *cobra.Command, args []string) {
+		var statuses []queue.Status
+
+		switch {
+		case lo.Must(cmd.Flags().GetBool("all")):
+			// no statuses means everything
+		case lo.Must(cmd.Flags().GetBool("failed")):
+			statuses = []queue.Status{queue.StatusFinished, queue.StatusFailed}
+		default:
+			statuses = []queue.Status{queue.StatusFinished}
+		}
+
+		handleErr(queue.Clear(statuses...))
+		cmd.Printf("%s queue cleared\n", icon.Get(icon.Success))
+	},
+}
@@ -3,12 +3,15 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	cc "github.com/ivanpirog/coloredcobra"
 	"github.com/metafates/mangal/color"
 	"github.com/metafates/mangal/constant"
 	"github.com/metafates/mangal/converter"
+	"github.com/metafates/mangal/filesystem"
 	"github.com/metafates/mangal/icon"
 	"github.com/metafates/mangal/key"
 	"github.com/metafates/mangal/log"
@@ -65,12 +68,37 @@ func init() {
 		version.Notify()
 	})
 
-	// Clear temporary files on startup
+	// Clear temporary files on startup.
+	// Staged pages are kept so that interrupted downloads could be resumed,
+	// unless the download was not resumed for stagingMaxAge
 	go func() {
-		_ = util.Delete(where.Temp())
+		files, err := filesystem.Api().ReadDir(where.Temp())
+		if err != nil {
+			return
+		}
+
+		for _, file := range files {
+			if file.Name() != where.StagingDirname {
+				_ = util.Delete(filepath.Join(where.Temp(), file.Name()))
+			}
+		}
+
+		staged, err := filesystem.Api().ReadDir(where.Staging())
+		if err != nil {
+			return
+		}
+
+		for _, chapter := range staged {
+			if time.Since(chapter.ModTime()) > stagingMaxAge {
+				_ = util.Delete(filepath.Join(where.Staging(), chapter.Name()))
+			}
+		}
 	}()
 }
 
+// stagingMaxAge is how long pages of the unfinished chapter are kept
+const stagingMaxAge = 7 * 24 * time.Hour
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   constant.Mangal,
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/metafates/mangal/color"
+	"github.com/metafates/mangal/icon"
+	"github.com/metafates/mangal/key"
+	"github.com/metafates/mangal/server"
+	"github.com/metafates/mangal/style"
+	"github.com/metafates/mangal/where"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+	serveCmd.SetOut(os.Stdout)
+
+	serveCmd.Flags().StringP("address", "a", "", "address to listen on")
+	serveCmd.Flags().Bool("read-only", true, "disable requests that change the library")
+	serveCmd.Flags().String("username", "", "username for the basic authentication")
+	serveCmd.Flags().String("password", "", "password for the basic authentication")
+
+	lo.Must0(viper.BindPFlag(key.ServeAddress, serveCmd.Flags().Lookup("address")))
+	lo.Must0(viper.BindPFlag(key.ServeReadOnly, serveCmd.Flags().Lookup("read-only")))
+	lo.Must0(viper.BindPFlag(key.ServeUsername, serveCmd.Flags().Lookup("username")))
+	lo.Must0(viper.BindPFlag(key.ServePassword, serveCmd.Flags().Lookup("password")))
+}
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve downloaded manga over http",
+	Long: `Start a web reader, a JSON API and an OPDS catalog over the downloads directory.
+Pages are streamed from cbz, zip and plain chapters without extracting them.
+Other formats can be downloaded as files.
+
+The inline mode is available under /api/inline with the same json output.
+Sources are created once, and chapters are downloaded by background jobs
+that can be polled and cancelled. Jobs require the read-only mode to be off.`,
+	Example: "mangal serve --address :8080 --username user --password secret",
+	Run: func(cmd *cobra.Command, args []string) {
+		handler, err := server.New(server.Options{
+			Root:     where.Downloads(),
+			ReadOnly: viper.GetBool(key.ServeReadOnly),
+			Username: viper.GetString(key.ServeUsername),
+			Password: viper.GetString(key.ServePassword),
+		})
+		handleErr(err)
+
+		listener, err := net.Listen("tcp", viper.GetString(key.ServeAddress))
+		handleErr(err)
+
+		cmd.Printf("%s Serving %s on %s\n", icon.Get(icon.Success), where.Downloads(), style.Fg(color.Purple)(serveURL(listener.Addr())))
+
+		httpServer := &http.Server{
+			Handler:           handler,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+
+		handleErr(httpServer.Serve(listener))
+	},
+}
+
+// serveURL returns the url of the listener, unspecified hosts are shown as localhost
+func serveURL(addr net.Addr) string {
+	tcp, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return addr.String()
+	}
+
+	if tcp.IP.IsUnspecified() {
+		return fmt.Sprintf("http://localhost:%d", tcp.Port)
+	}
+
+	return "http://" + tcp.String()
+}
@@ -1,16 +1,21 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/user"
 	"path/filepath"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/metafates/mangal/color"
 	"github.com/metafates/mangal/constant"
 	"github.com/metafates/mangal/key"
+	"github.com/metafates/mangal/provider/custom"
+	"github.com/metafates/mangal/provider/generic"
 	"github.com/metafates/mangal/provider/mangaplus"
 	"github.com/metafates/mangal/tui"
 	"github.com/metafates/mangal/util"
@@ -40,6 +45,7 @@ func init() {
 	sourcesListCmd.Flags().BoolP("raw", "r", false, "do not print headers")
 	sourcesListCmd.Flags().BoolP("custom", "c", false, "show only custom sources")
 	sourcesListCmd.Flags().BoolP("builtin", "b", false, "show only builtin sources")
+	sourcesListCmd.Flags().BoolP("json", "j", false, "JSON output")
 
 	sourcesListCmd.MarkFlagsMutuallyExclusive("custom", "builtin")
 	sourcesListCmd.SetOut(os.Stdout)
@@ -48,7 +54,30 @@ func init() {
 var sourcesListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List an available sources",
+	Long: `List an available sources.
+Custom sources are shown with the version from their manifest.
+Use the json flag to get the full manifests.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		var (
+			showBuiltin = !lo.Must(cmd.Flags().GetBool("custom"))
+			showCustom  = !lo.Must(cmd.Flags().GetBool("builtin"))
+		)
+
+		if lo.Must(cmd.Flags().GetBool("json")) {
+			var providers []*provider.Provider
+			if showBuiltin {
+				providers = append(providers, provider.Builtins()...)
+			}
+			if showCustom {
+				providers = append(providers, provider.Customs()...)
+			}
+
+			handleErr(json.NewEncoder(cmd.OutOrStdout()).Encode(lo.Map(providers, func(p *provider.Provider, _ int) *sourceInfo {
+				return newSourceInfo(p)
+			})))
+			return
+		}
+
 		printHeader := !lo.Must(cmd.Flags().GetBool("raw"))
 		headerStyle := style.New().Foreground(color.HiBlue).Bold(true).Render
 		h := func(s string) {
@@ -67,14 +96,28 @@ var sourcesListCmd = &cobra.Command{
 		printCustom := func() {
 			h("Custom:")
 			for _, p := range provider.Customs() {
-				cmd.Println(p.Name)
+				if !printHeader {
+					cmd.Println(p.Name)
+					continue
+				}
+
+				line := p.Name
+				if p.Manifest.Version != "" {
+					line += " " + style.Faint("v"+strings.TrimPrefix(p.Manifest.Version, "v"))
+				}
+
+				if err := p.Manifest.Compatible(); err != nil {
+					line += " " + style.Fg(color.Red)(err.Error())
+				}
+
+				cmd.Println(line)
 			}
 		}
 
 		switch {
-		case lo.Must(cmd.Flags().GetBool("builtin")):
+		case !showCustom:
 			printBuiltin()
-		case lo.Must(cmd.Flags().GetBool("custom")):
+		case !showBuiltin:
 			printCustom()
 		default:
 			printBuiltin()
@@ -86,6 +129,37 @@ var sourcesListCmd = &cobra.Command{
 	},
 }
 
+// sourceInfo is the json representation of the source
+type sourceInfo struct {
+	ID         string           `json:"id"`
+	Name       string           `json:"name"`
+	Custom     bool             `json:"custom"`
+	Headless   bool             `json:"headless"`
+	Compatible bool             `json:"compatible"`
+	Error      string           `json:"error,omitempty"`
+	Manifest   *custom.Manifest `json:"manifest,omitempty"`
+}
+
+func newSourceInfo(p *provider.Provider) *sourceInfo {
+	info := &sourceInfo{
+		ID:         p.ID,
+		Name:       p.Name,
+		Custom:     p.IsCustom,
+		Headless:   p.UsesHeadless,
+		Compatible: true,
+		Manifest:   p.Manifest,
+	}
+
+	if p.Manifest != nil {
+		if err := p.Manifest.Compatible(); err != nil {
+			info.Compatible = false
+			info.Error = err.Error()
+		}
+	}
+
+	return info
+}
+
 func init() {
 	sourcesCmd.AddCommand(sourcesRemoveCmd)
 
@@ -98,7 +172,7 @@ func init() {
 
 		return lo.FilterMap(sources, func(item os.FileInfo, _ int) (string, bool) {
 			name := item.Name()
-			if !strings.HasSuffix(name, provider.CustomProviderExtension) {
+			if ext := filepath.Ext(name); ext != provider.CustomProviderExtension && !lo.Contains(generic.DefinitionExtensions, ext) {
 				return "", false
 			}
 
@@ -113,6 +187,15 @@ var sourcesRemoveCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		for _, name := range lo.Must(cmd.Flags().GetStringArray("name")) {
 			path := filepath.Join(where.Sources(), name+provider.CustomProviderExtension)
+
+			// declarative sources
+			for _, ext := range generic.DefinitionExtensions {
+				if exists, _ := filesystem.Api().Exists(filepath.Join(where.Sources(), name+ext)); exists {
+					path = filepath.Join(where.Sources(), name+ext)
+					break
+				}
+			}
+
 			handleErr(filesystem.Api().Remove(path))
 			fmt.Printf("%s successfully removed %s\n", icon.Get(icon.Success), style.Fg(color.Yellow)(name))
 		}
@@ -167,6 +250,7 @@ var sourcesGenCmd = &cobra.Command{
 			MangaChaptersFn string
 			ChapterPagesFn  string
 			Author          string
+			Mangal          string
 		}{
 			Name:            lo.Must(cmd.Flags().GetString("name")),
 			URL:             lo.Must(cmd.Flags().GetString("url")),
@@ -174,6 +258,7 @@ var sourcesGenCmd = &cobra.Command{
 			MangaChaptersFn: constant.MangaChaptersFn,
 			ChapterPagesFn:  constant.ChapterPagesFn,
 			Author:          author,
+			Mangal:          constant.Version,
 		}
 
 		funcMap := template.FuncMap{
@@ -198,6 +283,90 @@ var sourcesGenCmd = &cobra.Command{
 	},
 }
 
+func init() {
+	sourcesCmd.AddCommand(sourcesTestCmd)
+
+	sourcesTestCmd.Flags().BoolP("record", "r", false, "make real requests and save them as fixtures")
+	sourcesTestCmd.Flags().BoolP("live", "l", false, "make real requests and ignore fixtures")
+	sourcesTestCmd.Flags().StringP("fixtures", "f", "", "path to the fixtures file")
+	sourcesTestCmd.Flags().BoolP("json", "j", false, "JSON output")
+
+	sourcesTestCmd.MarkFlagsMutuallyExclusive("record", "live")
+	sourcesTestCmd.SetOut(os.Stdout)
+}
+
+var sourcesTestCmd = &cobra.Command{
+	Use:   "test [name or path]",
+	Short: "Test a custom source",
+	Long: `Test a custom source with the sample inputs declared in its manifest.
+
+	-- @test.search   query for SearchManga
+	-- @test.chapters manga url for MangaChapters
+	-- @test.pages    chapter url for ChapterPages
+
+Inputs that are not declared are taken from the first result of the previous function.
+Returned tables are validated and every function must return at least one result.
+
+HTTP requests can be recorded to a fixtures file next to the source with the record flag.
+If the fixtures file exists, requests are replayed from it without network.`,
+	Example: "  mangal sources test example --record",
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path := args[0]
+		if exists, _ := filesystem.Api().Exists(path); !exists {
+			path = filepath.Join(where.Sources(), path+provider.CustomProviderExtension)
+		}
+
+		options := &custom.TestOptions{
+			Fixtures: lo.Must(cmd.Flags().GetString("fixtures")),
+		}
+
+		switch {
+		case lo.Must(cmd.Flags().GetBool("record")):
+			options.Mode = custom.FixtureRecord
+		case lo.Must(cmd.Flags().GetBool("live")):
+			options.Mode = custom.FixtureLive
+		}
+
+		report, err := custom.Test(context.Background(), path, options)
+		handleErr(err)
+
+		if lo.Must(cmd.Flags().GetBool("json")) {
+			handleErr(json.NewEncoder(cmd.OutOrStdout()).Encode(report))
+		} else {
+			printTestReport(cmd, report)
+		}
+
+		if !report.Passed {
+			os.Exit(1)
+		}
+	},
+}
+
+func printTestReport(cmd *cobra.Command, report *custom.TestReport) {
+	cmd.Printf("%s %s\n", style.Bold(report.Source), style.Faint(string(report.Mode)))
+
+	for _, step := range report.Steps {
+		call := fmt.Sprintf("%s(%q)", step.Function, step.Input)
+
+		if step.Passed() {
+			cmd.Printf(
+				"%s %s %s %s\n",
+				icon.Get(icon.Success),
+				call,
+				util.Quantify(step.Results, "result", "results"),
+				style.Faint(step.Duration.Round(time.Millisecond).String()),
+			)
+		} else {
+			cmd.Printf("%s %s %s\n", icon.Get(icon.Fail), call, style.Fg(color.Red)(step.Error))
+		}
+	}
+
+	if report.Mode == custom.FixtureRecord {
+		cmd.Printf("Fixtures saved to %s\n", report.Fixtures)
+	}
+}
+
 func init() {
 	sourcesCmd.AddCommand(sourcesMangaplusGenerateApiKeyCmd)
 }
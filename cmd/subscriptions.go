@@ -0,0 +1,269 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/metafates/mangal/color"
+	"github.com/metafates/mangal/converter"
+	"github.com/metafates/mangal/icon"
+	"github.com/metafates/mangal/inline"
+	"github.com/metafates/mangal/key"
+	"github.com/metafates/mangal/provider"
+	"github.com/metafates/mangal/style"
+	"github.com/metafates/mangal/subscriptions"
+	"github.com/metafates/mangal/util"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	rootCmd.AddCommand(subscriptionsCmd)
+}
+
+var subscriptionsCmd = &cobra.Command{
+	Use:     "subscriptions",
+	Short:   "Manage manga subscriptions",
+	Aliases: []string{"subs"},
+	Long: `Manage manga subscriptions.
+New chapters of the subscribed manga are downloaded by the sync command.`,
+}
+
+func init() {
+	subscriptionsCmd.AddCommand(subscriptionsAddCmd)
+
+	subscriptionsAddCmd.Flags().StringP("query", "q", "", "query to search for")
+	subscriptionsAddCmd.Flags().StringP("manga", "m", "exact", "manga selector")
+	subscriptionsAddCmd.Flags().StringP("format", "F", "", "format to download new chapters in, default format is used if empty")
+	lo.Must0(subscriptionsAddCmd.MarkFlagRequired("query"))
+	lo.Must0(subscriptionsAddCmd.RegisterFlagCompletionFunc("format", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return converter.Available(), cobra.ShellCompDirectiveNoFileComp
+	}))
+
+	subscriptionsAddCmd.SetOut(os.Stdout)
+}
+
+var subscriptionsAddCmd = &cobra.Command{
+	Use:     "add",
+	Short:   "Subscribe to the manga",
+	Long:    "Subscribe to the manga from the first default source. Manga is picked the same way as in the inline mode",
+	Example: "mangal subscriptions add --source Mangadex --query \"Chainsaw Man\" --format cbz",
+	PreRun: func(cmd *cobra.Command, args []string) {
+		if format := lo.Must(cmd.Flags().GetString("format")); format != "" {
+			if _, err := converter.Get(format); err != nil {
+				handleErr(err)
+			}
+		}
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		names := viper.GetStringSlice(key.DownloaderDefaultSources)
+		if len(names) == 0 || names[0] == "" {
+			handleErr(errors.New("source not set"))
+		}
+
+		p, ok := provider.Get(names[0])
+		if !ok {
+			handleErr(fmt.Errorf("source not found: %s", names[0]))
+		}
+
+		query := lo.Must(cmd.Flags().GetString("query"))
+		picker, err := inline.ParseMangaPicker(query, lo.Must(cmd.Flags().GetString("manga")))
+		handleErr(err)
+
+		erase := util.PrintErasable(fmt.Sprintf("%s Searching %s", icon.Get(icon.Progress), style.Fg(color.Purple)(p.Name)))
+		src, err := p.CreateSource()
+		if err != nil {
+			erase()
+			handleErr(err)
+		}
+
+		mangas, err := src.Search(query)
+		erase()
+		handleErr(err)
+
+		manga := picker(mangas)
+		if manga == nil {
+			handleErr(fmt.Errorf("no manga found for %q in %s", query, p.Name))
+		}
+
+		subscription, err := subscriptions.Add(manga, lo.Must(cmd.Flags().GetString("format")))
+		handleErr(err)
+
+		cmd.Printf("%s Subscribed to %s %s\n", icon.Get(icon.Success), style.Bold(subscription.MangaName), style.Faint(subscription.SourceID))
+	},
+}
+
+func init() {
+	subscriptionsCmd.AddCommand(subscriptionsListCmd)
+
+	subscriptionsListCmd.Flags().BoolP("json", "j", false, "JSON output")
+	subscriptionsListCmd.SetOut(os.Stdout)
+}
+
+var subscriptionsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List subscriptions",
+	Run: func(cmd *cobra.Command, args []string) {
+		subs, err := subscriptions.Get()
+		handleErr(err)
+
+		if lo.Must(cmd.Flags().GetBool("json")) {
+			handleErr(json.NewEncoder(cmd.OutOrStdout()).Encode(subs))
+			return
+		}
+
+		if len(subs) == 0 {
+			cmd.Println("No subscriptions")
+			return
+		}
+
+		for _, s := range subs {
+			format := s.Format
+			if format == "" {
+				format = viper.GetString(key.FormatsUse)
+			}
+
+			synced := "never synced"
+			if !s.LastSynced.IsZero() {
+				synced = "synced " + s.LastSynced.Format("2006-01-02 15:04")
+			}
+
+			cmd.Printf(
+				"%s %s %s %s\n",
+				style.Bold(s.MangaName),
+				style.Fg(color.Purple)(s.SourceID),
+				style.Fg(color.Yellow)(format),
+				style.Faint(synced),
+			)
+		}
+	},
+}
+
+func init() {
+	subscriptionsCmd.AddCommand(subscriptionsRemoveCmd)
+	subscriptionsRemoveCmd.SetOut(os.Stdout)
+}
+
+var subscriptionsRemoveCmd = &cobra.Command{
+	Use:     "remove [name or url]",
+	Short:   "Unsubscribe from the manga",
+	Aliases: []string{"rm"},
+	Args:    cobra.MinimumNArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		subs, err := subscriptions.Get()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return lo.Map(subs, func(s *subscriptions.Subscription, _ int) string {
+			return s.MangaName
+		}), cobra.ShellCompDirectiveNoFileComp
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		query := strings.Join(args, " ")
+
+		removed, err := subscriptions.Remove(query)
+		handleErr(err)
+
+		if len(removed) == 0 {
+			handleErr(fmt.Errorf("no subscription matches %q", query))
+		}
+
+		cmd.Printf("%s Removed %s\n", icon.Get(icon.Success), util.Quantify(len(removed), "subscription", "subscriptions"))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(syncCmd)
+
+	syncCmd.Flags().BoolP("json", "j", false, "JSON output")
+	syncCmd.Flags().BoolP("dry-run", "n", false, "only show new chapters without downloading them")
+	syncCmd.Flags().DurationP("timeout", "t", 0, "timeout for fetching chapters of each manga, e.g. 30s. 0 means no timeout")
+	syncCmd.SetOut(os.Stdout)
+}
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Download new chapters of the subscribed manga",
+	Long: `Download new chapters of the subscribed manga.
+Chapters of every subscription are fetched from its source and compared
+against the downloads directory, so only missing chapters are downloaded.
+When volumes are bundled, volumes that are not saved yet are downloaded,
+and saved volumes that are missing new chapters are rebuilt with them.
+Use the json flag to get a machine-readable summary.`,
+	Example: "mangal sync --json",
+	Run: func(cmd *cobra.Command, args []string) {
+		subs, err := subscriptions.Get()
+		handleErr(err)
+
+		asJson := lo.Must(cmd.Flags().GetBool("json"))
+
+		if len(subs) == 0 && !asJson {
+			cmd.Println("No subscriptions, add one with \"mangal subscriptions add\"")
+			return
+		}
+
+		options := &subscriptions.Options{
+			DryRun:  lo.Must(cmd.Flags().GetBool("dry-run")),
+			Timeout: lo.Must(cmd.Flags().GetDuration("timeout")),
+		}
+
+		// progress would break the json output
+		erase := func() {}
+		if !asJson {
+			options.Progress = func(s string) {
+				erase()
+				erase = util.PrintErasable(fmt.Sprintf("%s %s", icon.Get(icon.Progress), s))
+			}
+		}
+
+		summary := subscriptions.Sync(context.Background(), subs, options)
+		erase()
+
+		if asJson {
+			handleErr(json.NewEncoder(cmd.OutOrStdout()).Encode(summary))
+			return
+		}
+
+		printSyncSummary(cmd, summary)
+	},
+}
+
+func printSyncSummary(cmd *cobra.Command, summary *subscriptions.Summary) {
+	for _, report := range summary.Subscriptions {
+		if report.Error != "" {
+			cmd.Printf("%s %s: %s\n", icon.Get(icon.Fail), report.Manga, style.Fg(color.Red)(report.Error))
+			continue
+		}
+
+		for _, chapter := range report.Fetched {
+			name := fmt.Sprintf("%s : %s", report.Manga, chapter.Name)
+			if summary.DryRun {
+				cmd.Printf("%s %s\n", style.Fg(color.Yellow)("[new]"), name)
+			} else {
+				cmd.Printf("%s %s\n", icon.Get(icon.Success), chapter.Path)
+			}
+		}
+
+		for _, chapter := range report.Failed {
+			cmd.Printf("%s %s : %s: %s\n", icon.Get(icon.Fail), report.Manga, chapter.Name, style.Fg(color.Red)(chapter.Error))
+		}
+	}
+
+	verb := "Downloaded"
+	if summary.DryRun {
+		verb = "Found"
+	}
+
+	cmd.Printf(
+		"%s %s new from %s\n",
+		verb,
+		util.Quantify(summary.Fetched, "chapter", "chapters"),
+		util.Quantify(len(summary.Subscriptions), "subscription", "subscriptions"),
+	)
+}
@@ -28,6 +28,9 @@ var wherePaths = []*whereTarget{
 	{"Cache", where.Cache, "cache", mo.None[string](), true},
 	{"Temp", where.Temp, "temp", mo.None[string](), true},
 	{"History", where.History, "history", mo.None[string](), true},
+	{"Queue", where.Queue, "queue", mo.None[string](), true},
+	{"Library", where.Library, "library", mo.None[string](), true},
+	{"Subscriptions", where.Subscriptions, "subscriptions", mo.None[string](), true},
 }
 
 func init() {
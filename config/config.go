@@ -72,6 +72,24 @@ func setDefaults() {
 	}
 }
 
+// Register adds the field that is not known at compile time, e.g. setting of a custom source.
+// Secret fields are masked by config info. Fields that are already defined are ignored
+func Register(field Field, secret bool) {
+	if _, ok := Default[field.Key]; ok {
+		return
+	}
+
+	Default[field.Key] = field
+	EnvExposed = append(EnvExposed, field.Key)
+
+	if secret {
+		secrets[field.Key] = struct{}{}
+	}
+
+	viper.SetDefault(field.Key, field.Value)
+	viper.MustBindEnv(field.Key)
+}
+
 // resolveAliases resolves the aliases for the paths
 func resolveAliases() {
 	home := lo.Must(os.UserHomeDir())
@@ -44,6 +44,22 @@ func (f *Field) typeName() string {
 	}
 }
 
+// secrets are keys of the fields whose values are masked
+var secrets = map[string]struct{}{
+	key.ServePassword: {},
+}
+
+// value returns the current value of the field, masked if the field is secret
+func (f *Field) value() any {
+	value := viper.Get(f.Key)
+
+	if _, ok := secrets[f.Key]; ok && fmt.Sprint(value) != "" {
+		return "********"
+	}
+
+	return value
+}
+
 func (f *Field) MarshalJSON() ([]byte, error) {
 	field := struct {
 		Key         string `json:"key"`
@@ -53,7 +69,7 @@ func (f *Field) MarshalJSON() ([]byte, error) {
 		Type        string `json:"type"`
 	}{
 		Key:         f.Key,
-		Value:       viper.Get(f.Key),
+		Value:       f.value(),
 		Default:     f.Value,
 		Description: f.Description,
 		Type:        f.typeName(),
@@ -68,7 +84,7 @@ var prettyTemplate = lo.Must(template.New("pretty").Funcs(template.FuncMap{
 	"purple": style.Fg(color.Purple),
 	"blue":   style.Fg(color.Blue),
 	"cyan":   style.Fg(color.Cyan),
-	"value":  func(k string) any { return viper.Get(k) },
+	"value":  func(f *Field) any { return f.value() },
 	"hl": func(v any) string {
 		switch value := v.(type) {
 		case bool:
@@ -88,7 +104,7 @@ var prettyTemplate = lo.Must(template.New("pretty").Funcs(template.FuncMap{
 }).Parse(`{{ faint .Description }}
 {{ blue "Key:" }}     {{ purple .Key }}
 {{ blue "Env:" }}     {{ .Env }}
-{{ blue "Value:" }}   {{ hl (value .Key) }}
+{{ blue "Value:" }}   {{ hl (value .) }}
 {{ blue "Default:" }} {{ hl (.Value) }}
 {{ blue "Type:" }}    {{ typename .Value }}`))
 
@@ -204,6 +220,37 @@ Type "mangal sources list" to show available sources`,
 		false,
 		`Stop downloading other chapters on error`,
 	},
+	{
+		key.DownloaderPageRetries,
+		3,
+		`How many times to retry a page download on a transient error
+Transient errors are network errors, 429 and 5xx responses`,
+	},
+	{
+		key.DownloaderPageRetryDelay,
+		500,
+		`Base delay between page download retries in milliseconds
+Delay doubles with each attempt and a random jitter is added to it`,
+	},
+	{
+		key.DownloaderPageWorkers,
+		16,
+		`How many pages to download at the same time
+Used only if asynchronous downloader is enabled`,
+	},
+	{
+		key.DownloaderPageWorkersPerHost,
+		8,
+		`How many pages to download at the same time from a single host
+Set to 0 to disable the limit`,
+	},
+	{
+		key.DownloaderSourcePageWorkers,
+		[]string{},
+		`Override page workers count for specific sources
+Format is <source name>=<workers>
+Example: ["Mangadex=4", "Manganelo=32"]`,
+	},
 	{
 		key.DownloaderDownloadCover,
 		true,
@@ -213,7 +260,7 @@ Type "mangal sources list" to show available sources`,
 		key.FormatsUse,
 		"pdf",
 		`Default format to export chapters
-Available options are: pdf, zip, cbz, plain`,
+Available options are: pdf, zip, cbz, epub, plain`,
 	},
 	{
 		key.FormatsSkipUnsupportedImages,
@@ -221,11 +268,60 @@ Available options are: pdf, zip, cbz, plain`,
 		`Will skip images that can't be converted to the specified format 
 Example: if you want to export to pdf, but some images are gifs, they will be skipped`,
 	},
+	{
+		key.FormatsBundleVolumes,
+		false,
+		`Save chapters of the same volume as a single file instead of a file per chapter
+Works best with library managers such as Komga and Kavita`,
+	},
+	{
+		key.FormatsVolumeChunkSize,
+		10,
+		`How many chapters to bundle together when chapters have no volume
+Used only when volumes are bundled
+Saved volumes are rebuilt when chapters join them later`,
+	},
+
+	{
+		key.ImagesEncode,
+		"",
+		`Format to re-encode pages to before they are converted
+Available options are: jpeg, png
+Leave empty to keep the original format`,
+	},
+	{
+		key.ImagesJPEGQuality,
+		85,
+		`Quality of the re-encoded jpeg images from 1 to 100`,
+	},
+	{
+		key.ImagesMaxHeight,
+		0,
+		`Downscale pages taller than this height in pixels, keeping the aspect ratio
+Set to 0 to disable`,
+	},
+	{
+		key.ImagesGrayscale,
+		false,
+		`Convert pages to grayscale. Useful for e-ink readers`,
+	},
+	{
+		key.ImagesTrimBorders,
+		false,
+		`Trim white borders around pages`,
+	},
+	{
+		key.ImagesSplitSpreads,
+		false,
+		`Split double-page spreads, pages wider than they are tall, in two pages
+Right half goes first for manga read from right to left`,
+	},
 
 	{
 		key.MetadataFetchAnilist,
 		true,
 		`Fetch metadata from Anilist
+Only fields that the source didn't set are filled
 It will also cache the results to not spam the API`,
 	},
 
@@ -280,6 +376,11 @@ Available options are: emoji, kaomoji, plain, squares, nerd (nerd-font required)
 		"",
 		"What app to use to open zip files",
 	},
+	{
+		key.ReaderEPUB,
+		"",
+		"What app to use to open epub files",
+	},
 	{
 		key.RaderPlain,
 		"",
@@ -300,6 +401,18 @@ Available options are: emoji, kaomoji, plain, squares, nerd (nerd-font required)
 		false,
 		"Open chapter url in browser instead of downloading it",
 	},
+	{
+		key.ReaderTerminal,
+		false,
+		`Show chapters in the terminal with the built-in reader instead of opening them with an app.
+Requires a terminal that supports kitty graphics, iTerm2 inline images or sixel`,
+	},
+	{
+		key.ReaderTerminalProtocol,
+		"auto",
+		`Graphics protocol of the built-in terminal reader.
+Available options are: auto, kitty, iterm2, sixel`,
+	},
 	{
 		key.HistorySaveOnRead,
 		true,
@@ -315,6 +428,12 @@ Available options are: emoji, kaomoji, plain, squares, nerd (nerd-font required)
 		true,
 		"Show query suggestions in when searching",
 	},
+	{
+		key.SearchSourceTimeout,
+		30,
+		`How long to wait for a single source to respond when searching in seconds
+Sources that take longer are skipped. Set to 0 to disable the timeout`,
+	},
 	{
 		key.MangadexLanguage,
 		"en",
@@ -371,6 +490,137 @@ Use "any" to show all languages`,
 		"main",
 		"Custom scrapers repository branch",
 	},
+	{
+		key.SandboxEnabled,
+		true,
+		`Run custom Lua sources in a sandbox.
+Sources can only require modules declared in their header with "-- @modules",
+os and io are not available unless granted`,
+	},
+	{
+		key.SandboxTimeout,
+		120,
+		`How long a single call of a custom source can take in seconds.
+Set to 0 to disable the limit`,
+	},
+	{
+		key.SandboxInstructionLimit,
+		1_000_000_000,
+		`How many Lua instructions a single call of a custom source can execute.
+Set to 0 to disable the limit`,
+	},
+	{
+		key.SandboxMemoryLimit,
+		0,
+		`Stop a call of a custom source if the heap of mangal grows by more MiB during it.
+The heap is shared by the whole process, so this is a guard against runaway memory usage,
+downloads and other calls that run at the same time count towards it too.
+Set to 0 to disable the guard`,
+	},
+	{
+		key.SandboxAllowOS,
+		[]string{},
+		`Names of custom sources that are allowed to use the os library and goos, headless modules.
+Other sources can only use os.time, os.clock, os.date and os.difftime`,
+	},
+	{
+		key.SandboxAllowIO,
+		[]string{},
+		`Names of custom sources that are allowed to use the io library and ioutil, storage, log, template, filepath modules`,
+	},
+	{
+		key.HTTPCache,
+		false,
+		`Cache http responses on the disk.
+Responses are revalidated with the server when they become stale.
+Images and responses larger than 4 MiB are not cached.
+Anilist requests are not cached either, anilist has its own cache.`,
+	},
+	{
+		key.HTTPProxy,
+		"",
+		`Proxy url for all requests, e.g. http://localhost:8080 or socks5://localhost:1080.
+Proxy from HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables is used if empty.
+Headless browser of custom scrapers does not use it.`,
+	},
+	{
+		key.HTTPProxyRules,
+		[]string{},
+		`Proxy rules per host as host=proxy, the first matching rule is used.
+Host can be a pattern such as *.example.com, proxy can be "direct" to connect without a proxy.`,
+	},
+	{
+		key.HTTPUserAgent,
+		"",
+		`User agent to send instead of the default one.
+Sources that set their own user agent keep it.`,
+	},
+	{
+		key.HTTPHeaders,
+		[]string{},
+		`Extra headers to send with each request as "Name: value".
+Headers that are set by sources are not overridden.`,
+	},
+	{
+		key.HTTPCABundle,
+		"",
+		"Path to the PEM file with certificates to trust in addition to the system ones",
+	},
+	{
+		key.HTTPInsecureSkipVerify,
+		false,
+		"Do not verify TLS certificates. Use it only if you know what you are doing",
+	},
+	{
+		key.RateLimitEnabled,
+		true,
+		`Limit the rate of requests to each host.
+Servers that respond with 429 and Retry-After pause the requests to them.`,
+	},
+	{
+		key.RateLimitRequestsPerMinute,
+		300,
+		"Maximum number of requests per minute to each host. 0 means no limit",
+	},
+	{
+		key.RateLimitBurst,
+		10,
+		"Number of requests that can be made at once before the limit applies",
+	},
+	{
+		key.RateLimitHosts,
+		[]string{},
+		`Limits per host as host=requests or host=requests/burst.
+Host can be a pattern such as *.mangadex.org, 0 requests means no limit.`,
+	},
+	{
+		key.RateLimitSources,
+		[]string{},
+		`Limits per source as source=requests or source=requests/burst, e.g. Mangadex=60/5.
+They override host limits for the requests of the source,
+API requests of the built-in sources and page downloads included.`,
+	},
+	{
+		key.ServeAddress,
+		":6969",
+		"Address the serve command listens on",
+	},
+	{
+		key.ServeReadOnly,
+		true,
+		`Disallow changes to the library with the serve command.
+Rescanning and deleting chapters are available only when it's disabled`,
+	},
+	{
+		key.ServeUsername,
+		"",
+		"Username for the basic authentication of the serve command. Authentication is disabled if it's empty",
+	},
+	{
+		key.ServePassword,
+		"",
+		"Password for the basic authentication of the serve command",
+	},
 	{
 		key.GenAuthor,
 		"",
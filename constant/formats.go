@@ -5,4 +5,5 @@ const (
 	FormatCBZ   = "cbz"
 	FormatPDF   = "pdf"
 	FormatZIP   = "zip"
+	FormatEPUB  = "epub"
 )
@@ -8,15 +8,22 @@ const (
 
 const SourceTemplate = `{{ $divider := repeat "-" (plus (max (len .URL) (len .Name) (len .Author) 3) 12) }}{{ $divider }}
 -- @name    {{ .Name }} 
+-- @version 0.1.0
 -- @url     {{ .URL }}
 -- @author  {{ .Author }} 
 -- @license MIT
+-- @lang    en
+-- @modules http, html
+-- @mangal  {{ .Mangal }}
 {{ $divider }}
 
 
----@alias manga { name: string, url: string, author: string|nil, genres: string|nil, summary: string|nil }
----@alias chapter { name: string, url: string, volume: string|nil, manga_summary: string|nil, manga_author: string|nil, manga_genres: string|nil }
----@alias page { url: string, index: number }
+---@alias list string[]|string Array of strings or a comma separated string
+---@alias date string|number Date as YYYY-MM-DD or a unix timestamp
+---@alias status "FINISHED"|"RELEASING"|"NOT_YET_RELEASED"|"CANCELLED"|"HIATUS"
+---@alias manga { name: string, url: string, id: string|nil, summary: string|nil, cover: string|nil, banner: string|nil, genres: list|nil, tags: list|nil, characters: list|nil, synonyms: list|nil, urls: list|nil, authors: list|nil, artists: list|nil, translators: list|nil, letterers: list|nil, status: status|nil, language: string|nil, chapters: number|nil, start_date: date|nil, end_date: date|nil }
+---@alias chapter { name: string, url: string, id: string|nil, volume: string|nil, number: string|nil, date: date|nil, scanlators: list|nil, manga_summary: string|nil, manga_genres: list|nil, manga_cover: string|nil, manga_authors: list|nil, manga_status: status|nil }
+---@alias page { url: string, index: number, headers: table<string, string>|nil, cookies: table<string, string>|nil }
 
 
 ----- IMPORTS -----
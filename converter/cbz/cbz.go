@@ -26,6 +26,20 @@ func (*CBZ) SaveTemp(chapter *source.Chapter) (string, error) {
 	return save(chapter, true)
 }
 
+// SaveVolume saves chapters of the volume as a single archive
+func (*CBZ) SaveVolume(volume *source.Volume) (path string, err error) {
+	path, err = volume.Path(false)
+	if err != nil {
+		return
+	}
+
+	if err = write(path, volume.Pages(), volume.ComicInfo()); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
 func save(chapter *source.Chapter, temp bool) (path string, err error) {
 	path, err = chapter.Path(temp)
 	if err != nil {
@@ -41,6 +55,10 @@ func save(chapter *source.Chapter, temp bool) (path string, err error) {
 }
 
 func SaveTo(chapter *source.Chapter, to string) error {
+	return write(to, chapter.Pages, chapter.ComicInfo())
+}
+
+func write(to string, pages []*source.Page, comicInfo *source.ComicInfo) error {
 	cbzFile, err := filesystem.Api().Create(to)
 	if err != nil {
 		return err
@@ -51,14 +69,13 @@ func SaveTo(chapter *source.Chapter, to string) error {
 	zipWriter := zip.NewWriter(cbzFile)
 	defer util.Ignore(zipWriter.Close)
 
-	for _, page := range chapter.Pages {
+	for _, page := range pages {
 		if err = addToZip(zipWriter, page.Contents, page.Filename()); err != nil {
 			return err
 		}
 	}
 
 	if viper.GetBool(key.MetadataComicInfoXML) {
-		comicInfo := chapter.ComicInfo()
 		marshalled, err := xml.MarshalIndent(comicInfo, "", "  ")
 		if err == nil {
 			buf := bytes.NewBuffer(marshalled)
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/metafates/mangal/constant"
 	"github.com/metafates/mangal/converter/cbz"
+	"github.com/metafates/mangal/converter/epub"
 	"github.com/metafates/mangal/converter/pdf"
 	"github.com/metafates/mangal/converter/plain"
 	"github.com/metafates/mangal/converter/zip"
@@ -16,6 +17,8 @@ import (
 type Converter interface {
 	Save(chapter *source.Chapter) (string, error)
 	SaveTemp(chapter *source.Chapter) (string, error)
+	// SaveVolume saves all chapters of the volume as a single file
+	SaveVolume(volume *source.Volume) (string, error)
 }
 
 var converters = map[string]Converter{
@@ -23,6 +26,7 @@ var converters = map[string]Converter{
 	constant.FormatCBZ:   cbz.New(),
 	constant.FormatPDF:   pdf.New(),
 	constant.FormatZIP:   zip.New(),
+	constant.FormatEPUB:  epub.New(),
 }
 
 // Available returns a list of available converters.
@@ -27,7 +27,7 @@ func TestAvailable(t *testing.T) {
 		converters := Available()
 		Convey("Then the available converters should be returned", func() {
 			So(converters, ShouldNotBeNil)
-			So(len(converters), ShouldEqual, 4)
+			So(len(converters), ShouldEqual, 5)
 		})
 	})
 }
@@ -0,0 +1,268 @@
+package epub
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/metafates/mangal/key"
+	"github.com/metafates/mangal/log"
+	"github.com/metafates/mangal/network"
+	"github.com/metafates/mangal/source"
+	"github.com/spf13/viper"
+	_ "golang.org/x/image/webp"
+)
+
+var mediaTypes = map[string]string{
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+}
+
+// picture is an image stored in the book
+type picture struct {
+	ID        string
+	Href      string
+	MediaType string
+	Width     int
+	Height    int
+	IsCover   bool
+	contents  []byte
+}
+
+// page is a fixed-layout XHTML document that shows a single picture
+type page struct {
+	ID      string
+	Href    string
+	Title   string
+	Picture *picture
+}
+
+// section is an entry of the table of contents
+type section struct {
+	Title string
+	Href  string
+}
+
+type creator struct {
+	Name string
+	Role string
+}
+
+// book is everything that is written to the EPUB file
+type book struct {
+	ID          string
+	Title       string
+	Series      string
+	Language    string
+	Description string
+	Creators    []creator
+	Subjects    []string
+	Modified    string
+	Direction   string
+	Cover       *picture
+	Pages       []*page
+	Sections    []section
+}
+
+// newBook creates a book with the metadata of the manga
+func newBook(manga *source.Manga, title, identifier string) *book {
+	b := &book{
+		ID:          uuid(identifier),
+		Title:       title,
+		Series:      manga.Name,
+		Language:    manga.Metadata.LanguageISO,
+		Description: manga.Metadata.Summary,
+		Subjects:    manga.Metadata.Genres,
+		Modified:    time.Now().UTC().Format(time.RFC3339),
+		Direction:   "rtl",
+	}
+
+	if b.Language == "" && manga.Source != nil {
+		b.Language = manga.Source.StdLang()
+	}
+
+	if b.Language == "" {
+		b.Language = "en"
+	}
+
+	if !manga.RightToLeft() {
+		b.Direction = "ltr"
+	}
+
+	staff := manga.Metadata.Staff
+	for _, group := range []struct {
+		names []string
+		role  string
+	}{
+		{staff.Story, "aut"},
+		{staff.Art, "art"},
+		{staff.Translation, "trl"},
+		{staff.Lettering, "ill"},
+	} {
+		for _, name := range group.names {
+			b.Creators = append(b.Creators, creator{Name: name, Role: group.role})
+		}
+	}
+
+	return b
+}
+
+// addChapter adds pages of the chapter to the book.
+// Contents of the pages are consumed.
+func (b *book) addChapter(chapter *source.Chapter) error {
+	var first string
+
+	for _, p := range chapter.Pages {
+		pic, err := newPicture(p, len(b.Pages)+1)
+		if err != nil {
+			if viper.GetBool(key.FormatsSkipUnsupportedImages) {
+				log.Warn(err)
+				continue
+			}
+
+			return err
+		}
+
+		number := len(b.Pages) + 1
+		pg := &page{
+			ID:      fmt.Sprintf("page-%04d", number),
+			Href:    fmt.Sprintf("pages/%04d.xhtml", number),
+			Title:   fmt.Sprintf("%s - %d", chapter.Name, p.Index+1),
+			Picture: pic,
+		}
+
+		if first == "" {
+			first = pg.Href
+		}
+
+		b.Pages = append(b.Pages, pg)
+	}
+
+	if first != "" {
+		b.Sections = append(b.Sections, section{Title: chapter.Name, Href: first})
+	}
+
+	return nil
+}
+
+func newPicture(p *source.Page, number int) (*picture, error) {
+	extension := strings.ToLower(p.Extension)
+	mediaType, ok := mediaTypes[extension]
+	if !ok {
+		return nil, fmt.Errorf("unsupported image format %q of page %d", p.Extension, p.Index)
+	}
+
+	contents, err := io.ReadAll(p.Contents)
+	_ = p.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(contents))
+	if err != nil {
+		return nil, fmt.Errorf("page %d: %w", p.Index, err)
+	}
+
+	return &picture{
+		ID:        fmt.Sprintf("image-%04d", number),
+		Href:      fmt.Sprintf("images/%04d%s", number, extension),
+		MediaType: mediaType,
+		Width:     config.Width,
+		Height:    config.Height,
+		contents:  contents,
+	}, nil
+}
+
+// setCover downloads the cover of the manga.
+// If it is not available, the first page is used as a cover.
+func (b *book) setCover(manga *source.Manga) {
+	if cover, err := downloadCover(manga); err != nil {
+		log.Warn(err)
+	} else {
+		b.Cover = cover
+	}
+
+	if b.Cover == nil && len(b.Pages) > 0 {
+		b.Cover = b.Pages[0].Picture
+	}
+
+	if b.Cover != nil {
+		b.Cover.IsCover = true
+	}
+}
+
+func downloadCover(manga *source.Manga) (*picture, error) {
+	url, err := manga.GetCover()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := network.Client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not download cover: %s", resp.Status)
+	}
+
+	contents, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(contents))
+	if err != nil {
+		return nil, fmt.Errorf("cover: %w", err)
+	}
+
+	extension := "." + format
+	if format == "jpeg" {
+		extension = ".jpg"
+	}
+
+	return &picture{
+		ID:        "cover",
+		Href:      "images/cover" + extension,
+		MediaType: "image/" + format,
+		Width:     config.Width,
+		Height:    config.Height,
+		contents:  contents,
+	}, nil
+}
+
+// Pictures returns all pictures that should be stored in the book
+func (b *book) Pictures() []*picture {
+	pictures := make([]*picture, 0, len(b.Pages)+1)
+	if b.Cover != nil && (len(b.Pages) == 0 || b.Cover != b.Pages[0].Picture) {
+		pictures = append(pictures, b.Cover)
+	}
+
+	for _, p := range b.Pages {
+		pictures = append(pictures, p.Picture)
+	}
+
+	return pictures
+}
+
+// uuid derives a stable name-based identifier, so that the same chapter always gets the same one
+func uuid(name string) string {
+	hash := sha1.Sum([]byte(name))
+	hash[6] = (hash[6] & 0x0f) | 0x50
+	hash[8] = (hash[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", hash[0:4], hash[4:6], hash[6:8], hash[8:10], hash[10:16])
+}
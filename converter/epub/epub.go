@@ -0,0 +1,144 @@
+package epub
+
+import (
+	"archive/zip"
+	"io"
+	"path"
+
+	"github.com/metafates/mangal/filesystem"
+	"github.com/metafates/mangal/source"
+	"github.com/metafates/mangal/util"
+)
+
+type EPUB struct{}
+
+func New() *EPUB {
+	return &EPUB{}
+}
+
+func (*EPUB) Save(chapter *source.Chapter) (string, error) {
+	return save(chapter, false)
+}
+
+func (*EPUB) SaveTemp(chapter *source.Chapter) (string, error) {
+	return save(chapter, true)
+}
+
+func save(chapter *source.Chapter, temp bool) (path string, err error) {
+	path, err = chapter.Path(temp)
+	if err != nil {
+		return
+	}
+
+	err = SaveTo(chapter, path)
+	if err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// SaveVolume saves chapters of the volume as a single book.
+// Each chapter gets its own entry in the table of contents
+func (*EPUB) SaveVolume(volume *source.Volume) (path string, err error) {
+	path, err = volume.Path(false)
+	if err != nil {
+		return
+	}
+
+	manga := volume.Manga
+
+	b := newBook(manga, manga.Name+" - "+volume.Name, volume.Source().ID()+manga.URL+volume.Name)
+	for _, chapter := range volume.Chapters {
+		if err = b.addChapter(chapter); err != nil {
+			return "", err
+		}
+	}
+
+	b.setCover(manga)
+
+	if err = create(path, b); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// SaveTo saves the chapter as a fixed-layout EPUB 3 book with one page per image
+func SaveTo(chapter *source.Chapter, to string) error {
+	manga := chapter.Manga
+
+	b := newBook(manga, manga.Name+" - "+chapter.Name, chapter.Source().ID()+chapter.URL)
+	if err := b.addChapter(chapter); err != nil {
+		return err
+	}
+
+	b.setCover(manga)
+
+	return create(to, b)
+}
+
+func create(path string, b *book) error {
+	file, err := filesystem.Api().Create(path)
+	if err != nil {
+		return err
+	}
+
+	defer util.Ignore(file.Close)
+
+	return write(file, b)
+}
+
+// write writes the book to w in the EPUB container format
+func write(w io.Writer, b *book) error {
+	writer := zip.NewWriter(w)
+
+	// mimetype must be the first file and must not be compressed
+	if err := addFile(writer, "mimetype", zip.Store, []byte("application/epub+zip")); err != nil {
+		return err
+	}
+
+	if err := addFile(writer, "META-INF/container.xml", zip.Deflate, []byte(containerXML)); err != nil {
+		return err
+	}
+
+	for _, name := range []string{"content.opf", "nav.xhtml"} {
+		if err := addTemplate(writer, path.Join("OEBPS", name), name, b); err != nil {
+			return err
+		}
+	}
+
+	for _, p := range b.Pages {
+		if err := addTemplate(writer, path.Join("OEBPS", p.Href), "page.xhtml", p); err != nil {
+			return err
+		}
+	}
+
+	// images are already compressed
+	for _, picture := range b.Pictures() {
+		if err := addFile(writer, path.Join("OEBPS", picture.Href), zip.Store, picture.contents); err != nil {
+			return err
+		}
+	}
+
+	return writer.Close()
+}
+
+func addTemplate(writer *zip.Writer, name, template string, data any) error {
+	file, err := writer.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate})
+	if err != nil {
+		return err
+	}
+
+	return templates.ExecuteTemplate(file, template, data)
+}
+
+func addFile(writer *zip.Writer, name string, method uint16, contents []byte) error {
+	file, err := writer.CreateHeader(&zip.FileHeader{Name: name, Method: method})
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(contents)
+	return err
+}
@@ -0,0 +1,141 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"testing"
+
+	"github.com/metafates/mangal/config"
+	"github.com/metafates/mangal/constant"
+	"github.com/metafates/mangal/filesystem"
+	"github.com/metafates/mangal/key"
+	"github.com/metafates/mangal/source"
+	"github.com/samber/lo"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	filesystem.SetMemMapFs()
+	lo.Must0(config.Setup())
+	viper.Set(key.FormatsUse, constant.FormatEPUB)
+	viper.Set(key.DownloaderCreateSourceDir, false)
+}
+
+type testSource struct{}
+
+func (testSource) Name() string                                        { return "Test" }
+func (testSource) ID() string                                          { return "test" }
+func (testSource) StdLang() string                                     { return "ja" }
+func (testSource) Search(string) ([]*source.Manga, error)              { return nil, nil }
+func (testSource) ChaptersOf(*source.Manga) ([]*source.Chapter, error) { return nil, nil }
+func (testSource) PagesOf(*source.Chapter) ([]*source.Page, error)     { return nil, nil }
+
+func readEntry(reader *zip.Reader, name string) string {
+	file, ok := lo.Find(reader.File, func(f *zip.File) bool {
+		return f.Name == name
+	})
+	So(ok, ShouldBeTrue)
+
+	contents := lo.Must(file.Open())
+	defer contents.Close()
+
+	return string(lo.Must(io.ReadAll(contents)))
+}
+
+func TestEPUB(t *testing.T) {
+	Convey("Given an EPUB converter", t, func() {
+		chapter := sampleChapter(t)
+		pages := len(chapter.Pages)
+
+		Convey("When saving a chapter", func() {
+			result, err := New().Save(chapter)
+			So(err, ShouldBeNil)
+			So(filepath.Ext(result), ShouldEqual, ".epub")
+
+			file := lo.Must(filesystem.Api().Open(result))
+			defer file.Close()
+			reader := lo.Must(zip.NewReader(file, lo.Must(file.Stat()).Size()))
+
+			Convey("Then it should be a valid EPUB container", func() {
+				So(reader.File[0].Name, ShouldEqual, "mimetype")
+				So(reader.File[0].Method, ShouldEqual, zip.Store)
+				So(readEntry(reader, "mimetype"), ShouldEqual, "application/epub+zip")
+				So(readEntry(reader, "META-INF/container.xml"), ShouldContainSubstring, "OEBPS/content.opf")
+			})
+
+			Convey("Then it should have fixed-layout metadata and a page per image", func() {
+				opf := readEntry(reader, "OEBPS/content.opf")
+				So(opf, ShouldContainSubstring, `<dc:title>manga name - chapter name</dc:title>`)
+				So(opf, ShouldContainSubstring, `<dc:language>ja</dc:language>`)
+				So(opf, ShouldContainSubstring, `<dc:subject>Action &amp; Adventure</dc:subject>`)
+				So(opf, ShouldContainSubstring, `scheme="marc:relators">aut</meta>`)
+				So(opf, ShouldContainSubstring, `<meta property="rendition:layout">pre-paginated</meta>`)
+				So(opf, ShouldContainSubstring, `page-progression-direction="rtl"`)
+				So(opf, ShouldContainSubstring, `properties="cover-image"`)
+
+				xhtml := lo.Filter(reader.File, func(f *zip.File, _ int) bool {
+					return filepath.Dir(f.Name) == "OEBPS/pages"
+				})
+				So(xhtml, ShouldHaveLength, pages)
+				So(readEntry(reader, "OEBPS/pages/0001.xhtml"), ShouldContainSubstring, `<meta name="viewport" content="width=`)
+				So(readEntry(reader, "OEBPS/nav.xhtml"), ShouldContainSubstring, `<a href="pages/0001.xhtml">chapter name</a>`)
+			})
+		})
+	})
+}
+
+func sampleChapter(t *testing.T) *source.Chapter {
+	t.Helper()
+	chapter := source.Chapter{
+		Name:  "chapter name",
+		URL:   "chapter url",
+		Index: 1,
+		Pages: []*source.Page{},
+	}
+	manga := source.Manga{
+		Name:     "manga name",
+		URL:      "manga url",
+		Source:   testSource{},
+		Chapters: []*source.Chapter{&chapter},
+	}
+	manga.Metadata.Genres = []string{"Action & Adventure"}
+	manga.Metadata.Staff.Story = []string{"Author"}
+	chapter.Manga = &manga
+
+	// to get images
+	filesystem.SetOsFs()
+	defer filesystem.SetMemMapFs()
+
+	err := filesystem.Api().Walk(
+		filepath.Join("..", "..", "assets", "testdata"),
+		func(path string, info fs.FileInfo, _ error) error {
+			if info.IsDir() || filepath.Ext(path) != ".jpeg" {
+				return nil
+			}
+
+			image, err := filesystem.Api().ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			chapter.Pages = append(chapter.Pages, &source.Page{
+				Index:     uint16(len(chapter.Pages)),
+				Extension: filepath.Ext(path),
+				Chapter:   &chapter,
+				Contents:  bytes.NewBuffer(image),
+			})
+
+			return nil
+		},
+	)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &chapter
+}
@@ -0,0 +1,117 @@
+package epub
+
+import (
+	"bytes"
+	"encoding/xml"
+	"text/template"
+)
+
+const containerXML = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`
+
+var templates = template.Must(template.New("epub").Funcs(template.FuncMap{
+	"escape": escape,
+	"inc": func(i int) int {
+		return i + 1
+	},
+}).Parse(`
+{{ define "content.opf" -}}
+<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="book-id" xml:lang="{{ escape .Language }}" prefix="rendition: http://www.idpf.org/vocab/rendition/#">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="book-id">{{ escape .ID }}</dc:identifier>
+    <dc:title>{{ escape .Title }}</dc:title>
+    <dc:language>{{ escape .Language }}</dc:language>
+    {{- if .Description }}
+    <dc:description>{{ escape .Description }}</dc:description>
+    {{- end }}
+    {{- range $i, $c := .Creators }}
+    <dc:creator id="creator-{{ inc $i }}">{{ escape $c.Name }}</dc:creator>
+    <meta refines="#creator-{{ inc $i }}" property="role" scheme="marc:relators">{{ $c.Role }}</meta>
+    {{- end }}
+    {{- range .Subjects }}
+    <dc:subject>{{ escape . }}</dc:subject>
+    {{- end }}
+    {{- if .Series }}
+    <meta property="belongs-to-collection" id="series">{{ escape .Series }}</meta>
+    <meta refines="#series" property="collection-type">series</meta>
+    {{- end }}
+    <meta property="dcterms:modified">{{ .Modified }}</meta>
+    <meta property="rendition:layout">pre-paginated</meta>
+    <meta property="rendition:orientation">portrait</meta>
+    <meta property="rendition:spread">none</meta>
+    {{- if .Cover }}
+    <meta name="cover" content="{{ .Cover.ID }}"/>
+    {{- end }}
+  </metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    {{- range .Pictures }}
+    <item id="{{ .ID }}" href="{{ .Href }}" media-type="{{ .MediaType }}"{{ if .IsCover }} properties="cover-image"{{ end }}/>
+    {{- end }}
+    {{- range .Pages }}
+    <item id="{{ .ID }}" href="{{ .Href }}" media-type="application/xhtml+xml"/>
+    {{- end }}
+  </manifest>
+  <spine page-progression-direction="{{ .Direction }}">
+    {{- range .Pages }}
+    <itemref idref="{{ .ID }}"/>
+    {{- end }}
+  </spine>
+</package>
+{{ end }}
+
+{{ define "nav.xhtml" -}}
+<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xml:lang="{{ escape .Language }}" lang="{{ escape .Language }}">
+<head>
+  <title>{{ escape .Title }}</title>
+</head>
+<body>
+  <nav epub:type="toc" id="toc">
+    <h1>{{ escape .Title }}</h1>
+    <ol>
+      {{- range .Sections }}
+      <li><a href="{{ .Href }}">{{ escape .Title }}</a></li>
+      {{- end }}
+    </ol>
+  </nav>
+  <nav epub:type="page-list" hidden="">
+    <ol>
+      {{- range $i, $p := .Pages }}
+      <li><a href="{{ $p.Href }}">{{ inc $i }}</a></li>
+      {{- end }}
+    </ol>
+  </nav>
+</body>
+</html>
+{{ end }}
+
+{{ define "page.xhtml" -}}
+<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
+<head>
+  <title>{{ escape .Title }}</title>
+  <meta name="viewport" content="width={{ .Picture.Width }}, height={{ .Picture.Height }}"/>
+  <style>html, body { margin: 0; padding: 0; } img { display: block; width: 100%; height: 100%; }</style>
+</head>
+<body>
+  <img src="../{{ .Picture.Href }}" alt="{{ escape .Title }}"/>
+</body>
+</html>
+{{ end }}
+`))
+
+// escape escapes special XML characters
+func escape(s string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
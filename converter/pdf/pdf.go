@@ -26,6 +26,24 @@ func (*PDF) SaveTemp(chapter *source.Chapter) (string, error) {
 	return save(chapter, true)
 }
 
+// SaveVolume saves chapters of the volume as a single document
+func (*PDF) SaveVolume(volume *source.Volume) (path string, err error) {
+	path, err = volume.Path(false)
+	if err != nil {
+		return
+	}
+
+	file, err := filesystem.Api().Create(path)
+	if err != nil {
+		return
+	}
+
+	defer util.Ignore(file.Close)
+
+	err = pagesToPDF(file, volume.Pages())
+	return
+}
+
 func save(chapter *source.Chapter, temp bool) (path string, err error) {
 	path, err = chapter.Path(temp)
 	if err != nil {
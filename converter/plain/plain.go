@@ -23,20 +23,36 @@ func (*Plain) SaveTemp(chapter *source.Chapter) (string, error) {
 	return save(chapter, true)
 }
 
+// SaveVolume saves pages of all chapters of the volume into a single directory
+func (*Plain) SaveVolume(volume *source.Volume) (path string, err error) {
+	path, err = volume.Path(false)
+	if err != nil {
+		return
+	}
+
+	err = savePages(volume.Pages(), path)
+	return
+}
+
 func save(chapter *source.Chapter, temp bool) (path string, err error) {
 	path, err = chapter.Path(temp)
 	if err != nil {
 		return
 	}
 
+	err = savePages(chapter.Pages, path)
+	return
+}
+
+func savePages(pages []*source.Page, path string) (err error) {
 	err = filesystem.Api().Mkdir(path, os.ModePerm)
 	if err != nil {
 		return
 	}
 
 	wg := sync.WaitGroup{}
-	wg.Add(len(chapter.Pages))
-	for _, page := range chapter.Pages {
+	wg.Add(len(pages))
+	for _, page := range pages {
 		func(page *source.Page) {
 			defer wg.Done()
 
@@ -23,12 +23,34 @@ func (*ZIP) SaveTemp(chapter *source.Chapter) (string, error) {
 	return save(chapter, true)
 }
 
+// SaveVolume saves chapters of the volume as a single archive
+func (*ZIP) SaveVolume(volume *source.Volume) (path string, err error) {
+	path, err = volume.Path(false)
+	if err != nil {
+		return
+	}
+
+	if err = write(path, volume.Pages()); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
 func save(chapter *source.Chapter, temp bool) (path string, err error) {
 	path, err = chapter.Path(temp)
 	if err != nil {
 		return
 	}
 
+	if err = write(path, chapter.Pages); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+func write(path string, pages []*source.Page) (err error) {
 	zipFile, err := filesystem.Api().Create(path)
 	if err != nil {
 		return
@@ -39,9 +61,9 @@ func save(chapter *source.Chapter, temp bool) (path string, err error) {
 	zipWriter := zip.NewWriter(zipFile)
 	defer util.Ignore(zipWriter.Close)
 
-	for _, page := range chapter.Pages {
+	for _, page := range pages {
 		if err = addToZip(zipWriter, page.Contents, page.Filename()); err != nil {
-			return "", err
+			return err
 		}
 	}
 
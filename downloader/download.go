@@ -1,6 +1,7 @@
 package downloader
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -10,15 +11,45 @@ import (
 	"github.com/metafates/mangal/converter"
 	"github.com/metafates/mangal/filesystem"
 	"github.com/metafates/mangal/history"
+	"github.com/metafates/mangal/imaging"
 	"github.com/metafates/mangal/key"
 	"github.com/metafates/mangal/log"
+	"github.com/metafates/mangal/queue"
 	"github.com/metafates/mangal/source"
 	"github.com/metafates/mangal/style"
 	"github.com/spf13/viper"
 )
 
 // Download the chapter using given source.
+// Progress of the download is tracked in the download queue.
 func Download(chapter *source.Chapter, progress func(string)) (string, error) {
+	return DownloadContext(context.Background(), chapter, progress)
+}
+
+// DownloadContext is the same as Download but requests of the source
+// and page downloads are aborted as soon as the context is done.
+func DownloadContext(ctx context.Context, chapter *source.Chapter, progress func(string)) (string, error) {
+	if err := queue.MarkInProgress(chapter); err != nil {
+		log.Warn(err)
+	}
+
+	path, err := download(ctx, chapter, progress)
+	if err != nil {
+		if err := queue.MarkFailed(chapter, err); err != nil {
+			log.Warn(err)
+		}
+
+		return "", err
+	}
+
+	if err := queue.MarkFinished(chapter, path); err != nil {
+		log.Warn(err)
+	}
+
+	return path, nil
+}
+
+func download(ctx context.Context, chapter *source.Chapter, progress func(string)) (string, error) {
 	log.Info("downloading " + chapter.Name)
 
 	path, err := chapter.Path(false)
@@ -41,34 +72,74 @@ func Download(chapter *source.Chapter, progress func(string)) (string, error) {
 	}
 
 	progress("Getting pages")
-	pages, err := chapter.Source().PagesOf(chapter)
+	pages, err := source.WithContext(chapter.Source()).PagesOfContext(ctx, chapter)
 	if err != nil {
 		log.Error(err)
 		return "", err
 	}
 	log.Info("found " + fmt.Sprintf("%d", len(pages)) + " pages")
 
-	err = chapter.DownloadPages(false, progress)
+	err = chapter.DownloadPagesContext(ctx, false, progress)
+	if err != nil {
+		log.Error(err)
+		return "", err
+	}
+
+	imaging.ProcessChapter(chapter, progress)
+
+	saveMangaMetadata(chapter.Manga, progress)
+
+	format := chapter.Manga.SaveFormat()
+	log.Info("getting " + format + " converter")
+	progress(fmt.Sprintf(
+		"Converting %d pages to %s %s",
+		len(pages),
+		style.Fg(color.Yellow)(format),
+		style.Faint(chapter.SizeHuman())),
+	)
+	conv, err := converter.Get(format)
+	if err != nil {
+		log.Error(err)
+		return "", err
+	}
+
+	log.Info("converting " + format)
+	path, err = conv.Save(chapter)
 	if err != nil {
 		log.Error(err)
 		return "", err
 	}
 
+	if err = chapter.ClearStaged(); err != nil {
+		log.Warn(err)
+	}
+
+	saveHistory(chapter)
+	chapter.CleanPages()
+
+	log.Info("downloaded without errors")
+	progress("Downloaded")
+	return path, nil
+}
+
+// saveMangaMetadata populates the manga metadata and saves the files
+// that are shared between chapters, such as series.json and the cover
+func saveMangaMetadata(manga *source.Manga, progress func(string)) {
 	if viper.GetBool(key.MetadataFetchAnilist) {
-		err := chapter.Manga.PopulateMetadata(progress)
+		err := manga.PopulateMetadata(progress)
 		if err != nil {
 			log.Warn(err)
 		}
 	}
 
 	if viper.GetBool(key.MetadataSeriesJSON) {
-		path, err := chapter.Manga.Path(false)
+		path, err := manga.Path(false)
 		if err != nil {
 			log.Warn(err)
 		} else {
 			path = filepath.Join(path, "series.json")
 			progress("Generating series.json")
-			seriesJSON := chapter.Manga.SeriesJSON()
+			seriesJSON := manga.SeriesJSON()
 			buf, err := json.Marshal(seriesJSON)
 			if err != nil {
 				log.Warn(err)
@@ -82,46 +153,23 @@ func Download(chapter *source.Chapter, progress func(string)) (string, error) {
 	}
 
 	if viper.GetBool(key.DownloaderDownloadCover) {
-		coverDir, err := chapter.Manga.Path(false)
+		coverDir, err := manga.Path(false)
 		if err == nil {
-			_ = chapter.Manga.DownloadCover(false, coverDir, progress)
+			_ = manga.DownloadCover(false, coverDir, progress)
 		}
 	}
+}
 
-	log.Info("getting " + viper.GetString(key.FormatsUse) + " converter")
-	progress(fmt.Sprintf(
-		"Converting %d pages to %s %s",
-		len(pages),
-		style.Fg(color.Yellow)(viper.GetString(key.FormatsUse)),
-		style.Faint(chapter.SizeHuman())),
-	)
-	conv, err := converter.Get(viper.GetString(key.FormatsUse))
-	if err != nil {
-		log.Error(err)
-		return "", err
-	}
-
-	log.Info("converting " + viper.GetString(key.FormatsUse))
-	path, err = conv.Save(chapter)
-	if err != nil {
-		log.Error(err)
-		return "", err
-	}
-
-	if viper.GetBool(key.HistorySaveOnDownload) {
-		go func() {
-			err = history.Save(chapter)
-			if err != nil {
-				log.Warn(err)
-			} else {
-				log.Info("history saved")
-			}
-		}()
+func saveHistory(chapter *source.Chapter) {
+	if !viper.GetBool(key.HistorySaveOnDownload) {
+		return
 	}
 
-	chapter.CleanPages()
-
-	log.Info("downloaded without errors")
-	progress("Downloaded")
-	return path, nil
+	go func() {
+		if err := history.Save(chapter, history.ActionDownload); err != nil {
+			log.Warn(err)
+		} else {
+			log.Info("history saved")
+		}
+	}()
 }
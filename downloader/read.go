@@ -6,11 +6,13 @@ import (
 	"github.com/metafates/mangal/constant"
 	"github.com/metafates/mangal/converter"
 	"github.com/metafates/mangal/history"
+	"github.com/metafates/mangal/imaging"
 	"github.com/metafates/mangal/key"
 	"github.com/metafates/mangal/log"
 	"github.com/metafates/mangal/open"
 	"github.com/metafates/mangal/source"
 	"github.com/metafates/mangal/style"
+	"github.com/metafates/mangal/viewer"
 	"github.com/spf13/viper"
 )
 
@@ -24,6 +26,10 @@ func Read(chapter *source.Chapter, progress func(string)) error {
 		)
 	}
 
+	if viper.GetBool(key.ReaderTerminal) {
+		return viewer.Read(chapter)
+	}
+
 	if viper.GetBool(key.DownloaderReadDownloaded) && chapter.IsDownloaded() {
 		path, err := chapter.Path(false)
 		if err == nil {
@@ -46,6 +52,8 @@ func Read(chapter *source.Chapter, progress func(string)) error {
 		return err
 	}
 
+	imaging.ProcessChapter(chapter, progress)
+
 	log.Info("getting " + viper.GetString(key.FormatsUse) + " converter")
 	conv, err := converter.Get(viper.GetString(key.FormatsUse))
 	if err != nil {
@@ -66,6 +74,10 @@ func Read(chapter *source.Chapter, progress func(string)) error {
 		return err
 	}
 
+	if err = chapter.ClearStaged(); err != nil {
+		log.Warn(err)
+	}
+
 	err = openRead(path, chapter, progress)
 	if err != nil {
 		log.Error(err)
@@ -79,7 +91,7 @@ func Read(chapter *source.Chapter, progress func(string)) error {
 func openRead(path string, chapter *source.Chapter, progress func(string)) error {
 	if viper.GetBool(key.HistorySaveOnRead) {
 		go func() {
-			err := history.Save(chapter)
+			err := history.Save(chapter, history.ActionRead)
 			if err != nil {
 				log.Warn(err)
 			} else {
@@ -100,6 +112,8 @@ func openRead(path string, chapter *source.Chapter, progress func(string)) error
 		reader = viper.GetString(key.ReaderCBZ)
 	case constant.FormatZIP:
 		reader = viper.GetString(key.ReaderZIP)
+	case constant.FormatEPUB:
+		reader = viper.GetString(key.ReaderEPUB)
 	case constant.FormatPlain:
 		reader = viper.GetString(key.RaderPlain)
 	}
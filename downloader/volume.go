@@ -0,0 +1,202 @@
+package downloader
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/metafates/mangal/color"
+	"github.com/metafates/mangal/converter"
+	"github.com/metafates/mangal/filesystem"
+	"github.com/metafates/mangal/imaging"
+	"github.com/metafates/mangal/key"
+	"github.com/metafates/mangal/log"
+	"github.com/metafates/mangal/queue"
+	"github.com/metafates/mangal/source"
+	"github.com/metafates/mangal/style"
+	"github.com/metafates/mangal/util"
+	"github.com/samber/lo"
+	"github.com/spf13/viper"
+)
+
+// Volumes groups chapters into volumes using the configured chunk size
+// for chapters without a volume
+func Volumes(chapters []*source.Chapter) []*source.Volume {
+	return source.Volumes(chapters, viper.GetInt(key.FormatsVolumeChunkSize))
+}
+
+// DownloadVolume downloads all chapters of the volume and saves them as a single file.
+// Every chapter of the volume is tracked in the download queue.
+func DownloadVolume(volume *source.Volume, progress func(string)) (string, error) {
+	return DownloadVolumeContext(context.Background(), volume, progress)
+}
+
+// DownloadVolumeContext is the same as DownloadVolume but requests of the source
+// and page downloads are aborted as soon as the context is done.
+func DownloadVolumeContext(ctx context.Context, volume *source.Volume, progress func(string)) (string, error) {
+	for _, chapter := range volume.Chapters {
+		if err := queue.MarkInProgress(chapter); err != nil {
+			log.Warn(err)
+		}
+	}
+
+	path, err := downloadVolume(ctx, volume, progress)
+	if err != nil {
+		for _, chapter := range volume.Chapters {
+			if err := queue.MarkFailed(chapter, err); err != nil {
+				log.Warn(err)
+			}
+		}
+
+		return "", err
+	}
+
+	for _, chapter := range volume.Chapters {
+		if err := queue.MarkFinished(chapter, path); err != nil {
+			log.Warn(err)
+		}
+	}
+
+	return path, nil
+}
+
+func downloadVolume(ctx context.Context, volume *source.Volume, progress func(string)) (string, error) {
+	log.Info("downloading volume " + volume.Name)
+
+	path, err := volume.Path(false)
+	if err != nil {
+		return "", err
+	}
+
+	if viper.GetBool(key.DownloaderRedownloadExisting) {
+		log.Info("deleting volume to redownload it")
+		if err = filesystem.Api().RemoveAll(path); err != nil {
+			log.Warn(err)
+		}
+	} else if volume.IsDownloaded() {
+		log.Info("volume already downloaded, skipping")
+		return path, nil
+	} else if exists, _ := filesystem.Api().Exists(path); exists {
+		log.Info("volume is missing chapters, rebuilding it")
+		if volume, err = withSavedChapters(ctx, volume); err != nil {
+			log.Error(err)
+			return "", err
+		}
+
+		if err = filesystem.Api().RemoveAll(path); err != nil {
+			log.Warn(err)
+		}
+	}
+
+	for i, chapter := range volume.Chapters {
+		prefix := fmt.Sprintf("[%d/%d] %s: ", i+1, len(volume.Chapters), chapter.Name)
+		chapterProgress := func(s string) {
+			progress(prefix + s)
+		}
+
+		chapterProgress("Getting pages")
+		pages, err := source.WithContext(chapter.Source()).PagesOfContext(ctx, chapter)
+		if err != nil {
+			log.Error(err)
+			return "", err
+		}
+		log.Info(fmt.Sprintf("found %d pages of %s", len(pages), chapter.Name))
+
+		if err = chapter.DownloadPagesContext(ctx, false, chapterProgress); err != nil {
+			log.Error(err)
+			return "", err
+		}
+
+		imaging.ProcessChapter(chapter, chapterProgress)
+	}
+
+	saveMangaMetadata(volume.Manga, progress)
+
+	pages := volume.Pages()
+	progress(fmt.Sprintf(
+		"Converting %s to %s",
+		util.Quantify(len(pages), "page", "pages"),
+		style.Fg(color.Yellow)(volume.Manga.SaveFormat()),
+	))
+
+	conv, err := converter.Get(volume.Manga.SaveFormat())
+	if err != nil {
+		log.Error(err)
+		return "", err
+	}
+
+	path, err = conv.SaveVolume(volume)
+	if err != nil {
+		log.Error(err)
+		return "", err
+	}
+
+	if err = volume.MarkSaved(); err != nil {
+		log.Warn(err)
+	}
+
+	for _, chapter := range volume.Chapters {
+		if err = chapter.ClearStaged(); err != nil {
+			log.Warn(err)
+		}
+
+		saveHistory(chapter)
+		chapter.CleanPages()
+	}
+
+	log.Info("volume downloaded without errors")
+	progress("Downloaded")
+	return path, nil
+}
+
+// withSavedChapters returns the volume with the chapters that are already saved in its file,
+// so that the file is rebuilt without losing them.
+// Chapters are taken from the manga or fetched from the source if the manga doesn't have them
+func withSavedChapters(ctx context.Context, volume *source.Volume) (*source.Volume, error) {
+	saved, ok := volume.SavedChapters()
+	if !ok {
+		return volume, nil
+	}
+
+	missing := lo.Without(saved, lo.Map(volume.Chapters, func(c *source.Chapter, _ int) string {
+		return c.URL
+	})...)
+
+	if len(missing) == 0 {
+		return volume, nil
+	}
+
+	isMissing := func(c *source.Chapter) bool {
+		return lo.Contains(missing, c.URL)
+	}
+
+	available := lo.Filter(volume.Manga.Chapters, func(c *source.Chapter, _ int) bool {
+		return isMissing(c)
+	})
+
+	if len(available) < len(missing) {
+		chapters, err := source.WithContext(volume.Source()).ChaptersOfContext(ctx, volume.Manga)
+		if err != nil {
+			return nil, err
+		}
+
+		available = lo.Filter(chapters, func(c *source.Chapter, _ int) bool {
+			return isMissing(c)
+		})
+	}
+
+	chapters := append(append([]*source.Chapter{}, volume.Chapters...), available...)
+	sort.SliceStable(chapters, func(i, j int) bool {
+		return chapters[i].Index < chapters[j].Index
+	})
+
+	for _, chapter := range chapters {
+		chapter.Manga = volume.Manga
+	}
+
+	return &source.Volume{
+		Name:     volume.Name,
+		Manga:    volume.Manga,
+		Chapters: chapters,
+	}, nil
+}
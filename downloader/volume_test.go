@@ -0,0 +1,123 @@
+package downloader
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/metafates/mangal/config"
+	"github.com/metafates/mangal/filesystem"
+	"github.com/metafates/mangal/key"
+	"github.com/metafates/mangal/source"
+	"github.com/samber/lo"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	filesystem.SetMemMapFs()
+	lo.Must0(config.Setup())
+}
+
+// testSource has chapters 21, 22 and 23 with a single page each
+type testSource struct {
+	server string
+}
+
+func (testSource) Name() string                           { return "Test" }
+func (testSource) ID() string                             { return "test" }
+func (testSource) StdLang() string                        { return "en" }
+func (testSource) Search(string) ([]*source.Manga, error) { return nil, nil }
+
+func (s testSource) ChaptersOf(manga *source.Manga) ([]*source.Chapter, error) {
+	var chapters []*source.Chapter
+	for _, index := range []uint16{21, 22, 23} {
+		name := strconv.Itoa(int(index))
+		chapters = append(chapters, &source.Chapter{Name: "Chapter " + name, URL: s.server + "/" + name, Index: index, Manga: manga})
+	}
+
+	return chapters, nil
+}
+
+func (testSource) PagesOf(chapter *source.Chapter) ([]*source.Page, error) {
+	chapter.Pages = []*source.Page{{URL: chapter.URL + "/1.jpg", Index: 1, Extension: ".jpg", Chapter: chapter}}
+	return chapter.Pages, nil
+}
+
+func TestDownloadVolume(t *testing.T) {
+	keys := []string{
+		key.FormatsUse,
+		key.FormatsVolumeChunkSize,
+		key.MetadataFetchAnilist,
+		key.MetadataSeriesJSON,
+		key.DownloaderDownloadCover,
+		key.HistorySaveOnDownload,
+	}
+
+	for _, k := range keys {
+		defer viper.Set(k, viper.Get(k))
+	}
+
+	viper.Set(key.FormatsUse, "zip")
+	viper.Set(key.FormatsVolumeChunkSize, 10)
+	viper.Set(key.MetadataFetchAnilist, false)
+	viper.Set(key.MetadataSeriesJSON, false)
+	viper.Set(key.DownloaderDownloadCover, false)
+	viper.Set(key.HistorySaveOnDownload, false)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("image of " + r.URL.Path))
+	}))
+	defer server.Close()
+
+	// convey runs the setup for each leaf, so each run gets its own manga
+	var runs int
+
+	Convey("Given a chunk that was downloaded partially", t, func() {
+		runs++
+		src := testSource{server: server.URL}
+		manga := &source.Manga{Name: "Partial " + strconv.Itoa(runs), URL: server.URL, Source: src}
+		chapters := lo.Must(src.ChaptersOf(manga))
+
+		path := lo.Must(DownloadVolume(Volumes(chapters[:1])[0], func(string) {}))
+		So(pageCount(path), ShouldEqual, 1)
+
+		Convey("When the whole chunk is requested", func() {
+			volume := Volumes(chapters)[0]
+			So(volume.Name, ShouldEqual, "Chapters 21-30")
+
+			Convey("Then it should not be considered downloaded", func() {
+				So(volume.IsDownloaded(), ShouldBeFalse)
+			})
+
+			Convey("Then it should be rebuilt with all chapters", func() {
+				So(lo.Must(DownloadVolume(volume, func(string) {})), ShouldEqual, path)
+				So(pageCount(path), ShouldEqual, 3)
+				So(volume.IsDownloaded(), ShouldBeTrue)
+			})
+		})
+
+		Convey("When another part of the chunk is requested", func() {
+			// the manga doesn't know the saved chapter, so it is fetched from the source
+			volume := Volumes(lo.Must(src.ChaptersOf(&source.Manga{Name: manga.Name, URL: manga.URL, Source: src}))[1:2])[0]
+
+			Convey("Then the saved chapters should be kept", func() {
+				lo.Must(DownloadVolume(volume, func(string) {}))
+				So(pageCount(path), ShouldEqual, 2)
+			})
+		})
+	})
+}
+
+// pageCount returns the number of files in the zip archive
+func pageCount(path string) int {
+	contents := lo.Must(filesystem.Api().ReadFile(path))
+	reader := lo.Must(zip.NewReader(bytes.NewReader(contents), int64(len(contents))))
+
+	return len(lo.Filter(reader.File, func(f *zip.File, _ int) bool {
+		return f.Name != "ComicInfo.xml"
+	}))
+}
@@ -0,0 +1,33 @@
+package filesystem
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// WriteFileAtomic writes data to the file the same way as WriteFile,
+// but through a temporary file that is renamed afterwards.
+// The file is never left half-written if mangal is killed,
+// and the temporary file has a unique name, so that concurrent mangal processes don't clobber it.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	temp, err := Api().TempFile(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	_, err = temp.Write(data)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = Api().Chmod(temp.Name(), perm)
+	}
+
+	if err != nil {
+		_ = Api().Remove(temp.Name())
+		return err
+	}
+
+	return Api().Rename(temp.Name(), path)
+}
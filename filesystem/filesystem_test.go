@@ -2,7 +2,10 @@ package filesystem
 
 import (
 	. "github.com/smartystreets/goconvey/convey"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestApi(t *testing.T) {
@@ -35,3 +38,59 @@ func TestSetOsFs(t *testing.T) {
 		})
 	})
 }
+
+func TestWriteFileAtomic(t *testing.T) {
+	Convey("Given a file written atomically", t, func() {
+		SetMemMapFs()
+		So(Api().MkdirAll("atomic", os.ModePerm), ShouldBeNil)
+		So(WriteFileAtomic(filepath.Join("atomic", "file.json"), []byte("first"), os.ModePerm), ShouldBeNil)
+
+		Convey("When it is written again", func() {
+			So(WriteFileAtomic(filepath.Join("atomic", "file.json"), []byte("second"), os.ModePerm), ShouldBeNil)
+
+			Convey("Then it should be replaced without leaving temporary files", func() {
+				contents, err := Api().ReadFile(filepath.Join("atomic", "file.json"))
+				So(err, ShouldBeNil)
+				So(string(contents), ShouldEqual, "second")
+
+				files, err := Api().ReadDir("atomic")
+				So(err, ShouldBeNil)
+				So(files, ShouldHaveLength, 1)
+			})
+		})
+	})
+}
+
+func TestLock(t *testing.T) {
+	Convey("Given a locked file", t, func() {
+		SetMemMapFs()
+		unlock, err := Lock("locked.json")
+		So(err, ShouldBeNil)
+
+		Convey("When it is locked again", func() {
+			locked := make(chan struct{})
+			go func() {
+				unlock, err := Lock("locked.json")
+				if err == nil {
+					unlock()
+				}
+				close(locked)
+			}()
+
+			Convey("Then it should wait until the lock is released", func() {
+				select {
+				case <-locked:
+					t.Fatal("lock was taken twice")
+				case <-time.After(200 * time.Millisecond):
+				}
+
+				unlock()
+				<-locked
+
+				exists, err := Api().Exists("locked.json.lock")
+				So(err, ShouldBeNil)
+				So(exists, ShouldBeFalse)
+			})
+		})
+	})
+}
@@ -0,0 +1,49 @@
+package filesystem
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	// lockTimeout is how long Lock waits for other processes
+	lockTimeout = 30 * time.Second
+	// staleLockAge is the age of the lock after which it's considered left by a killed process
+	staleLockAge      = time.Minute
+	lockRetryInterval = 50 * time.Millisecond
+)
+
+// Lock takes the lock of the file that is shared by mangal processes,
+// e.g. sync running from cron while the TUI is open.
+// The lock is a file next to the path, so it should be held only for a short time.
+// Returned function releases the lock.
+func Lock(path string) (unlock func(), err error) {
+	lock := path + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+
+	for {
+		file, err := Api().OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, os.ModePerm)
+		if err == nil {
+			_ = file.Close()
+			return func() {
+				_ = Api().Remove(lock)
+			}, nil
+		}
+
+		if !os.IsExist(err) {
+			return nil, err
+		}
+
+		if info, err := Api().Stat(lock); err == nil && time.Since(info.ModTime()) > staleLockAge {
+			_ = Api().Remove(lock)
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%s is locked by another mangal process, remove %s if there is none", path, lock)
+		}
+
+		time.Sleep(lockRetryInterval)
+	}
+}
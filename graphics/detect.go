@@ -0,0 +1,25 @@
+package graphics
+
+import "strings"
+
+// Detect guesses the protocol supported by the terminal from its environment variables.
+// TERM and LC_TERMINAL are usually forwarded over ssh, so it works for remote sessions too.
+// Returns false if the terminal is unknown
+func Detect(getenv func(string) string) (Protocol, bool) {
+	var (
+		term        = strings.ToLower(getenv("TERM"))
+		termProgram = strings.ToLower(getenv("TERM_PROGRAM"))
+		lcTerminal  = strings.ToLower(getenv("LC_TERMINAL"))
+	)
+
+	switch {
+	case getenv("KITTY_WINDOW_ID") != "", term == "xterm-kitty", term == "xterm-ghostty", termProgram == "ghostty", getenv("KONSOLE_VERSION") != "":
+		return ProtocolKitty, true
+	case termProgram == "iterm.app", lcTerminal == "iterm2", termProgram == "wezterm", termProgram == "mintty", term == "wezterm":
+		return ProtocolITerm2, true
+	case strings.HasPrefix(term, "foot"), strings.HasPrefix(term, "mlterm"), strings.HasPrefix(term, "yaft"), strings.HasPrefix(term, "contour"), term == "xterm-sixel":
+		return ProtocolSixel, true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,108 @@
+// Package graphics draws images in the terminal
+// using kitty graphics protocol, iTerm2 inline images or sixel.
+package graphics
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"strings"
+
+	"github.com/metafates/mangal/util"
+	"github.com/samber/lo"
+)
+
+// Protocol is a way to draw images in the terminal
+type Protocol string
+
+const (
+	ProtocolKitty  Protocol = "kitty"
+	ProtocolITerm2 Protocol = "iterm2"
+	ProtocolSixel  Protocol = "sixel"
+)
+
+// Protocols lists all supported protocols
+var Protocols = []Protocol{
+	ProtocolKitty,
+	ProtocolITerm2,
+	ProtocolSixel,
+}
+
+// Size of the cell in pixels used when the terminal doesn't report it
+const (
+	defaultCellWidth  = 10
+	defaultCellHeight = 20
+)
+
+// Encoder writes escape sequences that draw images
+type Encoder interface {
+	// Encode draws the image at the cursor scaled to the placement
+	Encode(w io.Writer, img image.Image, placement Placement) error
+	// Clear removes images drawn before, if clearing the screen doesn't
+	Clear(w io.Writer) error
+}
+
+// Get returns the encoder of the protocol
+func Get(protocol Protocol) (Encoder, error) {
+	switch protocol {
+	case ProtocolKitty:
+		return &kitty{}, nil
+	case ProtocolITerm2:
+		return &iterm2{}, nil
+	case ProtocolSixel:
+		return &sixel{}, nil
+	default:
+		return nil, fmt.Errorf("unknown graphics protocol %q, available options are: %s", protocol, strings.Join(
+			lo.Map(Protocols, func(p Protocol, _ int) string { return string(p) }),
+			", ",
+		))
+	}
+}
+
+// Area is the part of the terminal to draw in
+type Area struct {
+	Cols, Rows int
+	// CellWidth and CellHeight are the size of the cell in pixels, zero if unknown
+	CellWidth, CellHeight int
+}
+
+// Placement is the size of the image fit into the area
+type Placement struct {
+	// Cols and Rows are the size in cells
+	Cols, Rows int
+	// Width and Height are the size in pixels
+	Width, Height int
+}
+
+// Fit scales the image of the given size to the height of the area keeping the aspect ratio.
+// Images that are too wide are fit to the width instead
+func Fit(width, height int, area Area) Placement {
+	cellWidth, cellHeight := area.CellWidth, area.CellHeight
+	if cellWidth <= 0 || cellHeight <= 0 {
+		cellWidth, cellHeight = defaultCellWidth, defaultCellHeight
+	}
+
+	var (
+		maxWidth  = util.Max(area.Cols, 1) * cellWidth
+		maxHeight = util.Max(area.Rows, 1) * cellHeight
+	)
+
+	width, height = util.Max(width, 1), util.Max(height, 1)
+
+	scaledHeight := maxHeight
+	scaledWidth := width * scaledHeight / height
+
+	if scaledWidth > maxWidth {
+		scaledWidth = maxWidth
+		scaledHeight = height * scaledWidth / width
+	}
+
+	scaledWidth, scaledHeight = util.Max(scaledWidth, 1), util.Max(scaledHeight, 1)
+
+	return Placement{
+		Cols:   (scaledWidth + cellWidth - 1) / cellWidth,
+		Rows:   (scaledHeight + cellHeight - 1) / cellHeight,
+		Width:  scaledWidth,
+		Height: scaledHeight,
+	}
+}
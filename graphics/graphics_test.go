@@ -0,0 +1,168 @@
+package graphics
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/samber/lo"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// testImage is a deterministic noisy image with transparent corner,
+// large enough for the kitty payload to be split into chunks
+func testImage() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 96, 96))
+
+	state := uint32(2463534242)
+	for y := 0; y < 96; y++ {
+		for x := 0; x < 96; x++ {
+			state ^= state << 13
+			state ^= state >> 17
+			state ^= state << 5
+
+			alpha := uint8(0xff)
+			if x < 8 && y < 8 {
+				alpha = 0
+			}
+
+			img.Set(x, y, color.NRGBA{R: uint8(state), G: uint8(state >> 8), B: uint8(x * 2), A: alpha})
+		}
+	}
+
+	return img
+}
+
+func TestEncoders(t *testing.T) {
+	placement := Placement{Cols: 4, Rows: 3, Width: 40, Height: 60}
+
+	for _, protocol := range Protocols {
+		Convey("Given "+string(protocol)+" encoder", t, func() {
+			encoder, err := Get(protocol)
+			So(err, ShouldBeNil)
+
+			Convey("When the image is encoded", func() {
+				var buf bytes.Buffer
+				So(encoder.Encode(&buf, testImage(), placement), ShouldBeNil)
+
+				golden := filepath.Join("testdata", string(protocol)+".golden")
+				if *update {
+					lo.Must0(os.WriteFile(golden, buf.Bytes(), 0644))
+				}
+
+				Convey("Then it should match the golden file", func() {
+					expected, err := os.ReadFile(golden)
+					So(err, ShouldBeNil)
+					So(bytes.Equal(buf.Bytes(), expected), ShouldBeTrue)
+				})
+			})
+		})
+	}
+
+	Convey("Given unknown protocol", t, func() {
+		_, err := Get("ascii")
+
+		Convey("Then it should fail", func() {
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestKittyChunks(t *testing.T) {
+	Convey("Given the image larger than a chunk", t, func() {
+		var buf bytes.Buffer
+		So((&kitty{}).Encode(&buf, testImage(), Placement{Cols: 10, Rows: 10, Width: 96, Height: 96}), ShouldBeNil)
+
+		chunks := strings.SplitAfter(buf.String(), "\x1b\\")
+		chunks = chunks[:len(chunks)-1]
+
+		Convey("Then it should be split into chunks", func() {
+			So(len(chunks), ShouldBeGreaterThan, 1)
+			So(chunks[0], ShouldStartWith, "\x1b_Ga=T,f=100,q=2,c=10,r=10,m=1;")
+
+			for _, chunk := range chunks[1 : len(chunks)-1] {
+				So(chunk, ShouldStartWith, "\x1b_Gm=1;")
+				So(len(chunk), ShouldBeLessThanOrEqualTo, kittyChunkSize+len("\x1b_Gm=1;\x1b\\"))
+			}
+
+			So(chunks[len(chunks)-1], ShouldStartWith, "\x1b_Gm=0;")
+		})
+	})
+}
+
+func TestWriteSixels(t *testing.T) {
+	Convey("Given columns of a band", t, func() {
+		var buf bytes.Buffer
+		out := bufio.NewWriter(&buf)
+
+		writeSixels(out, []byte{1, 1, 1, 1, 1, 2, 2, 0, 63, 0, 0})
+		lo.Must0(out.Flush())
+
+		Convey("Then runs should be encoded and trailing empty columns skipped", func() {
+			So(buf.String(), ShouldEqual, "!5@AA?~")
+		})
+	})
+}
+
+func TestFit(t *testing.T) {
+	area := Area{Cols: 80, Rows: 24, CellWidth: 10, CellHeight: 20}
+
+	Convey("Given a tall page", t, func() {
+		placement := Fit(1000, 1500, area)
+
+		Convey("Then it should be fit to the height", func() {
+			So(placement, ShouldResemble, Placement{Cols: 32, Rows: 24, Width: 320, Height: 480})
+		})
+	})
+
+	Convey("Given a wide spread", t, func() {
+		placement := Fit(4000, 1000, area)
+
+		Convey("Then it should be fit to the width", func() {
+			So(placement, ShouldResemble, Placement{Cols: 80, Rows: 10, Width: 800, Height: 200})
+		})
+	})
+
+	Convey("Given unknown cell size", t, func() {
+		placement := Fit(1000, 1500, Area{Cols: 80, Rows: 24})
+
+		Convey("Then the default cell size should be used", func() {
+			So(placement.Rows, ShouldEqual, 24)
+			So(placement.Height, ShouldEqual, 24*defaultCellHeight)
+		})
+	})
+}
+
+func TestDetect(t *testing.T) {
+	for _, c := range []struct {
+		env      map[string]string
+		protocol Protocol
+		ok       bool
+	}{
+		{map[string]string{"TERM": "xterm-kitty"}, ProtocolKitty, true},
+		{map[string]string{"TERM": "xterm-256color", "KITTY_WINDOW_ID": "1"}, ProtocolKitty, true},
+		{map[string]string{"TERM": "xterm-256color", "LC_TERMINAL": "iTerm2"}, ProtocolITerm2, true},
+		{map[string]string{"TERM_PROGRAM": "WezTerm"}, ProtocolITerm2, true},
+		{map[string]string{"TERM": "foot"}, ProtocolSixel, true},
+		{map[string]string{"TERM": "xterm-256color"}, "", false},
+	} {
+		Convey("Given environment "+strings.Join(lo.Values(c.env), " "), t, func() {
+			protocol, ok := Detect(func(name string) string {
+				return c.env[name]
+			})
+
+			Convey("Then "+string(c.protocol)+" should be detected", func() {
+				So(ok, ShouldEqual, c.ok)
+				So(protocol, ShouldEqual, c.protocol)
+			})
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package graphics
+
+import (
+	"encoding/base64"
+	"fmt"
+	"image"
+	"io"
+)
+
+// iterm2 is the inline images protocol of iTerm2, also supported by WezTerm and mintty.
+// See https://iterm2.com/documentation-images.html
+type iterm2 struct{}
+
+func (*iterm2) Encode(w io.Writer, img image.Image, placement Placement) error {
+	encoded, err := encodePNG(img, placement)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(
+		w,
+		"\x1b]1337;File=inline=1;size=%d;width=%d;height=%d;preserveAspectRatio=1:%s\a",
+		len(encoded),
+		placement.Cols,
+		placement.Rows,
+		base64.StdEncoding.EncodeToString(encoded),
+	)
+
+	return err
+}
+
+func (*iterm2) Clear(io.Writer) error {
+	return nil
+}
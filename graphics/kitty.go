@@ -0,0 +1,70 @@
+package graphics
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+
+	"github.com/metafates/mangal/imaging"
+	"github.com/metafates/mangal/util"
+)
+
+// kittyChunkSize is the max size of the base64 payload of a single escape sequence
+const kittyChunkSize = 4096
+
+// kitty is the kitty graphics protocol.
+// See https://sw.kovidgoyal.net/kitty/graphics-protocol/
+type kitty struct{}
+
+func (*kitty) Encode(w io.Writer, img image.Image, placement Placement) error {
+	encoded, err := encodePNG(img, placement)
+	if err != nil {
+		return err
+	}
+
+	payload := base64.StdEncoding.EncodeToString(encoded)
+
+	for start := 0; start < len(payload); start += kittyChunkSize {
+		var (
+			end  = util.Min(start+kittyChunkSize, len(payload))
+			more = 0
+		)
+
+		if end < len(payload) {
+			more = 1
+		}
+
+		// the first chunk has the parameters, the following ones only continue it.
+		// q=2 suppresses responses of the terminal, so that they don't end up in the input
+		if start == 0 {
+			_, err = fmt.Fprintf(w, "\x1b_Ga=T,f=100,q=2,c=%d,r=%d,m=%d;%s\x1b\\", placement.Cols, placement.Rows, more, payload[start:end])
+		} else {
+			_, err = fmt.Fprintf(w, "\x1b_Gm=%d;%s\x1b\\", more, payload[start:end])
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (*kitty) Clear(w io.Writer) error {
+	// images stay on the screen after it's cleared, delete all of them
+	_, err := io.WriteString(w, "\x1b_Ga=d,q=2\x1b\\")
+	return err
+}
+
+// encodePNG scales the image to the placement and encodes it as png
+func encodePNG(img image.Image, placement Placement) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, imaging.Resize(img, placement.Width, placement.Height)); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
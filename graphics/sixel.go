@@ -0,0 +1,148 @@
+package graphics
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"io"
+
+	"github.com/metafates/mangal/imaging"
+)
+
+// sixelPalette is the web safe palette with additional shades of gray,
+// since most of the manga pages are black and white
+var sixelPalette = func() color.Palette {
+	p := append(color.Palette{}, palette.WebSafe...)
+
+	for v := 0; v <= 0xff; v += 0x11 {
+		// multiples of 0x33 are in the web safe palette already
+		if v%0x33 != 0 {
+			p = append(p, color.RGBA{R: uint8(v), G: uint8(v), B: uint8(v), A: 0xff})
+		}
+	}
+
+	return p
+}()
+
+// sixel draws images as six pixel high bands of colored columns.
+// Supported by xterm, foot, mlterm, WezTerm and others.
+// See https://vt100.net/docs/vt3xx-gp/chapter14.html
+type sixel struct{}
+
+func (*sixel) Encode(w io.Writer, img image.Image, placement Placement) error {
+	scaled := imaging.Resize(img, placement.Width, placement.Height)
+	bounds := scaled.Bounds()
+
+	// transparent pixels are drawn over white background, like the page is
+	opaque := image.NewRGBA(bounds)
+	draw.Draw(opaque, bounds, image.White, image.Point{}, draw.Src)
+	draw.Draw(opaque, bounds, scaled, bounds.Min, draw.Over)
+
+	paletted := image.NewPaletted(bounds, sixelPalette)
+	draw.FloydSteinberg.Draw(paletted, bounds, opaque, bounds.Min)
+
+	var (
+		width  = bounds.Dx()
+		height = bounds.Dy()
+		out    = bufio.NewWriter(w)
+	)
+
+	// "1;1 sets square pixels, otherwise terminals may stretch the image vertically
+	_, _ = fmt.Fprintf(out, "\x1bPq\"1;1;%d;%d", width, height)
+
+	used := make([]bool, len(sixelPalette))
+	for _, index := range paletted.Pix {
+		used[index] = true
+	}
+
+	for index, c := range sixelPalette {
+		if !used[index] {
+			continue
+		}
+
+		r, g, b, _ := c.RGBA()
+		_, _ = fmt.Fprintf(out, "#%d;2;%d;%d;%d", index, r*100/0xffff, g*100/0xffff, b*100/0xffff)
+	}
+
+	// bands[color][x] are the bits of the column, one for each of the six rows
+	bands := make([][]byte, len(sixelPalette))
+
+	for top := 0; top < height; top += 6 {
+		for i := range bands {
+			bands[i] = nil
+		}
+
+		for dy := 0; dy < 6 && top+dy < height; dy++ {
+			row := paletted.Pix[(top+dy)*paletted.Stride:]
+
+			for x := 0; x < width; x++ {
+				index := row[x]
+				if bands[index] == nil {
+					bands[index] = make([]byte, width)
+				}
+
+				bands[index][x] |= 1 << dy
+			}
+		}
+
+		first := true
+		for index, columns := range bands {
+			if columns == nil {
+				continue
+			}
+
+			// $ returns to the start of the band to draw the next color over it
+			if !first {
+				_ = out.WriteByte('$')
+			}
+
+			first = false
+			_, _ = fmt.Fprintf(out, "#%d", index)
+			writeSixels(out, columns)
+		}
+
+		// - moves to the next band
+		_ = out.WriteByte('-')
+	}
+
+	_, _ = out.WriteString("\x1b\\")
+	return out.Flush()
+}
+
+func (*sixel) Clear(io.Writer) error {
+	return nil
+}
+
+// writeSixels writes the columns of the band with run-length encoding.
+// Empty columns at the end are skipped
+func writeSixels(out *bufio.Writer, columns []byte) {
+	end := len(columns)
+	for end > 0 && columns[end-1] == 0 {
+		end--
+	}
+
+	for start := 0; start < end; {
+		run := start + 1
+		for run < end && columns[run] == columns[start] {
+			run++
+		}
+
+		var (
+			char  = columns[start] + '?'
+			count = run - start
+		)
+
+		if count > 3 {
+			_, _ = fmt.Fprintf(out, "!%d%c", count, char)
+		} else {
+			for i := 0; i < count; i++ {
+				_ = out.WriteByte(char)
+			}
+		}
+
+		start = run
+	}
+}
@@ -2,21 +2,44 @@ package history
 
 import (
 	"fmt"
+	"time"
+
 	"github.com/metafates/mangal/source"
 )
 
+// Action is how the chapter was consumed
+type Action string
+
+const (
+	ActionRead     Action = "read"
+	ActionDownload Action = "download"
+)
+
+// Actions lists all known actions
+var Actions = []Action{
+	ActionRead,
+	ActionDownload,
+}
+
+// SavedChapter is an entry of the reading history.
+// Every chapter read or downloaded is saved as a separate entry
 type SavedChapter struct {
-	SourceID           string `json:"source_id"`
-	MangaName          string `json:"manga_name"`
-	MangaURL           string `json:"manga_url"`
-	MangaChaptersTotal int    `json:"manga_chapters_total"`
-	Name               string `json:"name"`
-	URL                string `json:"url"`
-	ID                 string `json:"id"`
-	Index              int    `json:"index"`
-	MangaID            string `json:"manga_id"`
+	SourceID           string    `json:"source_id"`
+	MangaName          string    `json:"manga_name"`
+	MangaURL           string    `json:"manga_url"`
+	MangaChaptersTotal int       `json:"manga_chapters_total"`
+	Name               string    `json:"name"`
+	URL                string    `json:"url"`
+	ID                 string    `json:"id"`
+	Index              int       `json:"index"`
+	Number             string    `json:"number,omitempty"`
+	Volume             string    `json:"volume,omitempty"`
+	MangaID            string    `json:"manga_id"`
+	Action             Action    `json:"action"`
+	Time               time.Time `json:"time"`
 }
 
+// encode returns the key of the manga the chapter belongs to
 func (c *SavedChapter) encode() string {
 	return fmt.Sprintf("%s (%s)", c.MangaName, c.SourceID)
 }
@@ -25,7 +48,7 @@ func (c *SavedChapter) String() string {
 	return fmt.Sprintf("%s : %d / %d", c.MangaName, c.Index, c.MangaChaptersTotal)
 }
 
-func newSavedChapter(chapter *source.Chapter) *SavedChapter {
+func newSavedChapter(chapter *source.Chapter, action Action) *SavedChapter {
 	return &SavedChapter{
 		SourceID:           chapter.Manga.Source.ID(),
 		MangaName:          chapter.Manga.Name,
@@ -36,5 +59,9 @@ func newSavedChapter(chapter *source.Chapter) *SavedChapter {
 		MangaID:            chapter.Manga.ID,
 		MangaChaptersTotal: len(chapter.Manga.Chapters),
 		Index:              int(chapter.Index),
+		Number:             chapter.Number,
+		Volume:             chapter.Volume,
+		Action:             action,
+		Time:               time.Now(),
 	}
-}
\ No newline at end of file
+}
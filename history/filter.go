@@ -0,0 +1,85 @@
+package history
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Filter selects history entries. Zero fields match everything
+type Filter struct {
+	// Manga is a case-insensitive part of the manga name
+	Manga string
+	// Source is a case-insensitive part of the source id
+	Source string
+	Action Action
+	Since  time.Time
+	Until  time.Time
+}
+
+// Match reports whether the entry passes the filter
+func (f *Filter) Match(entry *SavedChapter) bool {
+	contains := func(s, substr string) bool {
+		return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+	}
+
+	switch {
+	case f.Manga != "" && !contains(entry.MangaName, f.Manga):
+		return false
+	case f.Source != "" && !contains(entry.SourceID, f.Source):
+		return false
+	case f.Action != "" && entry.Action != f.Action:
+		return false
+	case !f.Since.IsZero() && entry.Time.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && entry.Time.After(f.Until):
+		return false
+	default:
+		return true
+	}
+}
+
+// Apply returns the entries that pass the filter
+func (f *Filter) Apply(entries []*SavedChapter) []*SavedChapter {
+	filtered := make([]*SavedChapter, 0, len(entries))
+	for _, entry := range entries {
+		if f.Match(entry) {
+			filtered = append(filtered, entry)
+		}
+	}
+
+	return filtered
+}
+
+// WriteCSV writes the entries as csv with the header row
+func WriteCSV(w io.Writer, entries []*SavedChapter) error {
+	writer := csv.NewWriter(w)
+
+	err := writer.Write([]string{"time", "action", "source", "manga", "volume", "number", "index", "chapter", "url"})
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		err = writer.Write([]string{
+			entry.Time.Format(time.RFC3339),
+			string(entry.Action),
+			entry.SourceID,
+			entry.MangaName,
+			entry.Volume,
+			entry.Number,
+			strconv.Itoa(entry.Index),
+			entry.Name,
+			entry.URL,
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
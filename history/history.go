@@ -1,7 +1,12 @@
 package history
 
 import (
-	"github.com/metafates/gache"
+	"encoding/json"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
 	"github.com/metafates/mangal/filesystem"
 	"github.com/metafates/mangal/integration"
 	"github.com/metafates/mangal/key"
@@ -11,30 +16,152 @@ import (
 	"github.com/spf13/viper"
 )
 
-var cacher = gache.New[map[string]*SavedChapter](
-	&gache.Options{
-		Path:       where.History(),
-		FileSystem: &filesystem.GacheFs{},
-	},
-)
+type historyFile struct {
+	Entries []*SavedChapter `json:"entries"`
 
-// Get returns all chapters from the history file
-func Get() (chapters map[string]*SavedChapter, err error) {
-	cached, expired, err := cacher.Get()
+	// Internal and Time are fields of the history written by the previous versions,
+	// which kept only the last chapter of each manga
+	Internal map[string]*SavedChapter `json:"Internal,omitempty"`
+	Time     *time.Time               `json:"Time,omitempty"`
+}
+
+// mutex guards the history file, since chapters are saved in the background
+var mutex sync.Mutex
+
+// read loads the history from the disk.
+// History of the previous versions is migrated and written back,
+// the original file is kept with .bak extension
+func read() ([]*SavedChapter, error) {
+	path := where.History()
+
+	exists, err := filesystem.Api().Exists(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return make([]*SavedChapter, 0), nil
+	}
+
+	contents, err := filesystem.Api().ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file historyFile
+	if err = json.Unmarshal(contents, &file); err != nil {
+		return nil, err
+	}
+
+	if file.Entries != nil || file.Internal == nil {
+		return file.Entries, nil
+	}
+
+	log.Info("Migrating history to the per-chapter format")
+
+	if err = filesystem.Api().WriteFile(path+".bak", contents, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	entries := migrate(&file)
+	if err = write(entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// migrate converts the history of the previous versions to the entries.
+// It didn't keep the time of each chapter, so the time of the last update is used
+func migrate(file *historyFile) []*SavedChapter {
+	updated := time.Now()
+	if file.Time != nil {
+		updated = *file.Time
+	}
+
+	entries := make([]*SavedChapter, 0, len(file.Internal))
+	for _, chapter := range file.Internal {
+		if chapter == nil {
+			continue
+		}
+
+		chapter.Action = ActionRead
+		chapter.Time = updated
+		entries = append(entries, chapter)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].encode() < entries[j].encode()
+	})
+
+	return entries
+}
+
+// write saves the history to the disk.
+// The file is replaced atomically, so that the history is never left half-written if mangal is killed.
+func write(entries []*SavedChapter) error {
+	marshalled, err := json.Marshal(&historyFile{Entries: entries})
+	if err != nil {
+		return err
+	}
+
+	return filesystem.WriteFileAtomic(where.History(), marshalled, os.ModePerm)
+}
+
+// modify reads the history, applies the given function and writes the result back.
+// The file is locked, so that updates of other mangal processes are not lost
+func modify(f func(entries []*SavedChapter) []*SavedChapter) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	unlock, err := filesystem.Lock(where.History())
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	entries, err := read()
+	if err != nil {
+		return err
+	}
+
+	return write(f(entries))
+}
+
+// Entries returns every saved chapter sorted by the time it was saved
+func Entries() ([]*SavedChapter, error) {
+	mutex.Lock()
+	entries, err := read()
+	mutex.Unlock()
 
 	if err != nil {
 		return nil, err
 	}
 
-	if expired || cached == nil {
-		return make(map[string]*SavedChapter), nil
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+
+	return entries, nil
+}
+
+// Get returns the last saved chapter of each manga
+func Get() (chapters map[string]*SavedChapter, err error) {
+	entries, err := Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	chapters = make(map[string]*SavedChapter)
+	for _, entry := range entries {
+		chapters[entry.encode()] = entry
 	}
 
-	return cached, nil
+	return chapters, nil
 }
 
 // Save saves the chapter to the history file
-func Save(chapter *source.Chapter) error {
+func Save(chapter *source.Chapter, action Action) error {
 	if viper.GetBool(key.AnilistEnable) {
 		go func() {
 			log.Info("Saving chapter to anilist")
@@ -45,25 +172,21 @@ func Save(chapter *source.Chapter) error {
 		}()
 	}
 
-	saved, err := Get()
-	if err != nil {
-		return err
-	}
-
-	savedChapter := newSavedChapter(chapter)
-	saved[savedChapter.encode()] = savedChapter
-
-	return cacher.Set(saved)
+	return modify(func(entries []*SavedChapter) []*SavedChapter {
+		return append(entries, newSavedChapter(chapter, action))
+	})
 }
 
-// Remove removes the chapter from the history file
+// Remove removes all chapters of the manga the chapter belongs to from the history file
 func Remove(chapter *SavedChapter) error {
-	saved, err := Get()
-	if err != nil {
-		return err
-	}
-
-	delete(saved, chapter.encode())
+	return modify(func(entries []*SavedChapter) []*SavedChapter {
+		kept := make([]*SavedChapter, 0, len(entries))
+		for _, entry := range entries {
+			if entry.encode() != chapter.encode() {
+				kept = append(kept, entry)
+			}
+		}
 
-	return cacher.Set(saved)
+		return kept
+	})
 }
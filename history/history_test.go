@@ -1,11 +1,16 @@
 package history
 
 import (
+	"bytes"
 	"fmt"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/metafates/mangal/filesystem"
 	"github.com/metafates/mangal/source"
+	"github.com/metafates/mangal/where"
+	"github.com/samber/lo"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -59,7 +64,7 @@ func TestHistory(t *testing.T) {
 		chapter.Manga = &manga
 
 		Convey("When saving the chapter", func() {
-			err := Save(&chapter)
+			err := Save(&chapter, ActionRead)
 			Convey("Then the error should be nil", func() {
 				So(err, ShouldBeNil)
 
@@ -73,3 +78,111 @@ func TestHistory(t *testing.T) {
 		})
 	})
 }
+
+func TestHistoryLog(t *testing.T) {
+	Convey("Given two chapters of the same manga", t, func() {
+		lo.Must0(filesystem.Api().RemoveAll(where.History()))
+
+		manga := source.Manga{Name: "manga", URL: "manga url", Source: testSource{}}
+		first := source.Chapter{Name: "first", URL: "first url", Index: 1, Number: "1", Manga: &manga}
+		second := source.Chapter{Name: "second", URL: "second url", Index: 2, Number: "2", Manga: &manga}
+		manga.Chapters = []*source.Chapter{&first, &second}
+
+		Convey("When both are saved", func() {
+			So(Save(&first, ActionDownload), ShouldBeNil)
+			So(Save(&second, ActionRead), ShouldBeNil)
+
+			Convey("Then every chapter should be kept with its action", func() {
+				entries, err := Entries()
+				So(err, ShouldBeNil)
+				So(len(entries), ShouldEqual, 2)
+				So(entries[0].Name, ShouldEqual, "first")
+				So(entries[0].Action, ShouldEqual, ActionDownload)
+				So(entries[1].Number, ShouldEqual, "2")
+				So(entries[1].Time.IsZero(), ShouldBeFalse)
+			})
+
+			Convey("Then the last chapter should be returned for the manga", func() {
+				chapters, err := Get()
+				So(err, ShouldBeNil)
+				So(len(chapters), ShouldEqual, 1)
+				So(chapters["manga (test source)"].Name, ShouldEqual, "second")
+			})
+
+			Convey("Then removing should remove all chapters of the manga", func() {
+				chapters := lo.Must(Get())
+				So(Remove(chapters["manga (test source)"]), ShouldBeNil)
+				So(lo.Must(Entries()), ShouldBeEmpty)
+			})
+		})
+	})
+
+	Convey("Given the history of the previous version", t, func() {
+		legacy := []byte(`{"Internal":{"manga (test source)":{"source_id":"test source","manga_name":"manga","name":"chapter","index":3}},"Time":"2022-10-01T12:00:00Z"}`)
+		lo.Must0(filesystem.Api().WriteFile(where.History(), legacy, os.ModePerm))
+
+		Convey("When the history is read", func() {
+			entries, err := Entries()
+			So(err, ShouldBeNil)
+
+			Convey("Then it should be migrated", func() {
+				So(len(entries), ShouldEqual, 1)
+				So(entries[0].Name, ShouldEqual, "chapter")
+				So(entries[0].Action, ShouldEqual, ActionRead)
+				So(entries[0].Time.Equal(time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)), ShouldBeTrue)
+			})
+
+			Convey("Then the original should be kept as backup", func() {
+				So(lo.Must(filesystem.Api().ReadFile(where.History()+".bak")), ShouldResemble, legacy)
+			})
+
+			Convey("Then the migrated history should be written", func() {
+				So(string(lo.Must(filesystem.Api().ReadFile(where.History()))), ShouldStartWith, `{"entries":`)
+			})
+		})
+	})
+}
+
+func TestFilter(t *testing.T) {
+	Convey("Given history entries", t, func() {
+		now := time.Now()
+		entries := []*SavedChapter{
+			{MangaName: "One Piece", SourceID: "mangadex", Name: "1", Action: ActionRead, Time: now.Add(-48 * time.Hour)},
+			{MangaName: "Berserk", SourceID: "mangapill", Name: "2", Action: ActionDownload, Time: now.Add(-time.Hour)},
+			{MangaName: "one punch man", SourceID: "Mangapill", Name: "3", Action: ActionRead, Time: now},
+		}
+
+		names := func(filter Filter) []string {
+			return lo.Map(filter.Apply(entries), func(e *SavedChapter, _ int) string {
+				return e.Name
+			})
+		}
+
+		Convey("Then manga and source should match case-insensitive parts", func() {
+			So(names(Filter{Manga: "ONE"}), ShouldResemble, []string{"1", "3"})
+			So(names(Filter{Source: "pill"}), ShouldResemble, []string{"2", "3"})
+		})
+
+		Convey("Then action and time should be matched", func() {
+			So(names(Filter{Action: ActionRead}), ShouldResemble, []string{"1", "3"})
+			So(names(Filter{Since: now.Add(-24 * time.Hour)}), ShouldResemble, []string{"2", "3"})
+			So(names(Filter{Until: now.Add(-24 * time.Hour)}), ShouldResemble, []string{"1"})
+		})
+
+		Convey("Then empty filter should match everything", func() {
+			So(names(Filter{}), ShouldResemble, []string{"1", "2", "3"})
+		})
+
+		Convey("When exported as csv", func() {
+			var buf bytes.Buffer
+			So(WriteCSV(&buf, entries[1:2]), ShouldBeNil)
+
+			Convey("Then it should have the header and the entry", func() {
+				So(buf.String(), ShouldEqual, fmt.Sprintf(
+					"time,action,source,manga,volume,number,index,chapter,url\n%s,download,mangapill,Berserk,,,0,2,\n",
+					entries[1].Time.Format(time.RFC3339),
+				))
+			})
+		})
+	})
+}
@@ -0,0 +1,57 @@
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+)
+
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+)
+
+// Encoder encodes processed images
+type Encoder struct {
+	// Format to encode to. Empty means the format of the original image,
+	// or png if there is no encoder for it
+	Format string
+	// Quality of the JPEG images, 1-100
+	Quality int
+}
+
+// Encode encodes the image and returns its contents with the file extension.
+// Original format is the one the image was decoded from.
+func (e *Encoder) Encode(img image.Image, original string) (*bytes.Buffer, string, error) {
+	format := e.Format
+	if format == "" {
+		format = original
+	}
+
+	var buf bytes.Buffer
+
+	switch format {
+	case FormatJPEG, "jpg":
+		quality := e.Quality
+		if quality < 1 || quality > 100 {
+			quality = jpeg.DefaultQuality
+		}
+
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+			return nil, "", err
+		}
+
+		return &buf, ".jpg", nil
+	case FormatPNG, "gif", "webp":
+		// there are no gif and webp encoders in the standard library
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, "", err
+		}
+
+		return &buf, ".png", nil
+	default:
+		return nil, "", fmt.Errorf("unsupported image format %q, available options are %s, %s", format, FormatJPEG, FormatPNG)
+	}
+}
@@ -0,0 +1,164 @@
+// Package imaging post-processes downloaded pages before they are converted.
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"runtime"
+	"sync"
+
+	"github.com/metafates/mangal/key"
+	"github.com/metafates/mangal/log"
+	"github.com/metafates/mangal/source"
+	"github.com/spf13/viper"
+	_ "golang.org/x/image/webp"
+)
+
+// Step transforms an image.
+// It may return several images, e.g. when a double-page spread is split.
+type Step interface {
+	Name() string
+	Apply(img image.Image) []image.Image
+}
+
+// Pipeline is a sequence of steps applied to every page,
+// followed by encoding the result.
+type Pipeline struct {
+	Steps   []Step
+	Encoder *Encoder
+}
+
+// FromConfig creates a pipeline from the enabled steps in the config.
+// Steps are applied in the fixed order: trim, split, downscale, grayscale.
+// Pages of right-to-left manga are split right half first.
+func FromConfig(rightToLeft bool) *Pipeline {
+	var steps []Step
+
+	if viper.GetBool(key.ImagesTrimBorders) {
+		steps = append(steps, &Trim{Tolerance: defaultTrimTolerance})
+	}
+
+	if viper.GetBool(key.ImagesSplitSpreads) {
+		steps = append(steps, &Split{RightToLeft: rightToLeft})
+	}
+
+	if height := viper.GetInt(key.ImagesMaxHeight); height > 0 {
+		steps = append(steps, &Downscale{MaxHeight: height})
+	}
+
+	if viper.GetBool(key.ImagesGrayscale) {
+		steps = append(steps, &Grayscale{})
+	}
+
+	var encoder *Encoder
+	if format := viper.GetString(key.ImagesEncode); format != "" || len(steps) > 0 {
+		encoder = &Encoder{
+			Format:  format,
+			Quality: viper.GetInt(key.ImagesJPEGQuality),
+		}
+	}
+
+	return &Pipeline{Steps: steps, Encoder: encoder}
+}
+
+// Enabled reports whether the pipeline changes pages at all
+func (p *Pipeline) Enabled() bool {
+	return p.Encoder != nil
+}
+
+// Process runs the pipeline on the page.
+// It returns the resulting pages that replace the given one.
+func (p *Pipeline) Process(page *source.Page) ([]*source.Page, error) {
+	if page.Contents == nil {
+		return nil, fmt.Errorf("page #%d is not downloaded", page.Index)
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(page.Contents.Bytes()))
+	if err != nil {
+		return nil, fmt.Errorf("page #%d: %w", page.Index, err)
+	}
+
+	images := []image.Image{img}
+	for _, step := range p.Steps {
+		var next []image.Image
+		for _, img := range images {
+			next = append(next, step.Apply(img)...)
+		}
+
+		images = next
+	}
+
+	pages := make([]*source.Page, len(images))
+	for i, img := range images {
+		contents, extension, err := p.Encoder.Encode(img, format)
+		if err != nil {
+			return nil, fmt.Errorf("page #%d: %w", page.Index, err)
+		}
+
+		processed := *page
+		processed.Extension = extension
+		processed.Contents = contents
+		processed.Size = uint64(contents.Len())
+		pages[i] = &processed
+	}
+
+	return pages, nil
+}
+
+// ProcessChapter runs the pipeline on all pages of the chapter.
+// Pages are renumbered, since splitting may add new pages.
+// Pages that can not be decoded are left as they are.
+func ProcessChapter(chapter *source.Chapter, progress func(string)) {
+	pipeline := FromConfig(chapter.Manga == nil || chapter.Manga.RightToLeft())
+	if !pipeline.Enabled() || len(chapter.Pages) == 0 {
+		return
+	}
+
+	progress("Processing images")
+
+	var (
+		results   = make([][]*source.Page, len(chapter.Pages))
+		wg        sync.WaitGroup
+		semaphore = make(chan struct{}, runtime.NumCPU())
+	)
+
+	for i, page := range chapter.Pages {
+		wg.Add(1)
+		semaphore <- struct{}{}
+
+		go func(i int, page *source.Page) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+
+			processed, err := pipeline.Process(page)
+			if err != nil {
+				log.Warn(err)
+				processed = []*source.Page{page}
+			}
+
+			results[i] = processed
+		}(i, page)
+	}
+
+	wg.Wait()
+
+	var (
+		pages []*source.Page
+		first = chapter.Pages[0].Index
+	)
+
+	for _, processed := range results {
+		for _, page := range processed {
+			page.Index = first + uint16(len(pages))
+			pages = append(pages, page)
+		}
+	}
+
+	chapter.Pages = pages
+}
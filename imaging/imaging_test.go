@@ -0,0 +1,135 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/metafates/mangal/key"
+	"github.com/metafates/mangal/source"
+	"github.com/samber/lo"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/spf13/viper"
+)
+
+func fixture(name string) []byte {
+	return lo.Must(os.ReadFile(filepath.Join("testdata", name)))
+}
+
+func decode(contents []byte) image.Image {
+	img, _ := lo.Must2(image.Decode(bytes.NewReader(contents)))
+	return img
+}
+
+func catPhoto() []byte {
+	return lo.Must(os.ReadFile(filepath.Join("..", "assets", "testdata", "hey.jpeg")))
+}
+
+func TestSteps(t *testing.T) {
+	Convey("Given an image with white borders", t, func() {
+		img := decode(fixture("bordered.png"))
+
+		Convey("When borders are trimmed", func() {
+			trimmed := (&Trim{Tolerance: defaultTrimTolerance}).Apply(img)
+
+			Convey("Then only the content should be left", func() {
+				So(trimmed, ShouldHaveLength, 1)
+				So(trimmed[0].Bounds(), ShouldResemble, image.Rect(0, 0, 60, 90))
+			})
+		})
+	})
+
+	Convey("Given a double-page spread", t, func() {
+		img := decode(fixture("spread.png"))
+		red := color.RGBA{R: 255, A: 255}
+
+		Convey("When it is split for right-to-left reading", func() {
+			pages := (&Split{RightToLeft: true}).Apply(img)
+
+			Convey("Then the right half should go first", func() {
+				So(pages, ShouldHaveLength, 2)
+				So(pages[0].Bounds().Dx(), ShouldEqual, 100)
+				So(color.RGBAModel.Convert(pages[1].At(50, 50)), ShouldResemble, red)
+			})
+		})
+
+		Convey("When it is split for left-to-right reading", func() {
+			pages := (&Split{}).Apply(img)
+
+			Convey("Then the left half should go first", func() {
+				So(color.RGBAModel.Convert(pages[0].At(50, 50)), ShouldResemble, red)
+			})
+		})
+	})
+
+	Convey("Given a photo", t, func() {
+		img := decode(catPhoto())
+		bounds := img.Bounds()
+
+		Convey("When it is downscaled", func() {
+			scaled := (&Downscale{MaxHeight: bounds.Dy() / 2}).Apply(img)[0]
+
+			Convey("Then it should fit the max height keeping the aspect ratio", func() {
+				So(scaled.Bounds().Dy(), ShouldEqual, bounds.Dy()/2)
+				So(scaled.Bounds().Dx(), ShouldEqual, bounds.Dx()*(bounds.Dy()/2)/bounds.Dy())
+			})
+		})
+
+		Convey("When it is converted to grayscale", func() {
+			gray := (&Grayscale{}).Apply(img)[0]
+
+			Convey("Then it should be a grayscale image of the same size", func() {
+				So(gray.ColorModel(), ShouldEqual, color.GrayModel)
+				So(gray.Bounds().Size(), ShouldResemble, bounds.Size())
+			})
+		})
+	})
+}
+
+func TestProcessChapter(t *testing.T) {
+	Convey("Given a chapter with a spread and pipeline that splits and re-encodes pages", t, func() {
+		viper.Set(key.ImagesSplitSpreads, true)
+		viper.Set(key.ImagesGrayscale, true)
+		viper.Set(key.ImagesEncode, FormatJPEG)
+		defer func() {
+			viper.Set(key.ImagesSplitSpreads, false)
+			viper.Set(key.ImagesGrayscale, false)
+			viper.Set(key.ImagesEncode, "")
+		}()
+
+		chapter := &source.Chapter{Name: "chapter"}
+		chapter.Pages = []*source.Page{
+			{Index: 1, Extension: ".jpeg", Contents: bytes.NewBuffer(catPhoto()), Chapter: chapter},
+			{Index: 2, Extension: ".png", Contents: bytes.NewBuffer(fixture("spread.png")), Chapter: chapter},
+			{Index: 3, Extension: ".png", Contents: bytes.NewBufferString("not an image"), Chapter: chapter},
+		}
+
+		Convey("When it is processed", func() {
+			ProcessChapter(chapter, func(string) {})
+
+			Convey("Then the spread should become two pages and pages should be renumbered", func() {
+				So(chapter.Pages, ShouldHaveLength, 4)
+				for i, page := range chapter.Pages {
+					So(page.Index, ShouldEqual, i+1)
+				}
+			})
+
+			Convey("Then the pages should be encoded as jpeg", func() {
+				for _, page := range chapter.Pages[:3] {
+					So(page.Extension, ShouldEqual, ".jpg")
+					_, format := lo.Must2(image.Decode(bytes.NewReader(page.Contents.Bytes())))
+					So(format, ShouldEqual, "jpeg")
+					So(page.Size, ShouldEqual, page.Contents.Len())
+				}
+			})
+
+			Convey("Then pages that can not be decoded should be left as they are", func() {
+				So(chapter.Pages[3].Extension, ShouldEqual, ".png")
+				So(chapter.Pages[3].Contents.String(), ShouldEqual, "not an image")
+			})
+		})
+	})
+}
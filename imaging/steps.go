@@ -0,0 +1,206 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/metafates/mangal/util"
+)
+
+// defaultTrimTolerance is how far from pure white a pixel can be to still be considered a border
+const defaultTrimTolerance = 16
+
+// Trim removes white borders around the image
+type Trim struct {
+	// Tolerance of the border color, 0-255
+	Tolerance uint8
+}
+
+func (*Trim) Name() string {
+	return "trim"
+}
+
+func (t *Trim) Apply(img image.Image) []image.Image {
+	bounds := img.Bounds()
+	threshold := 0xffff - uint32(t.Tolerance)*0x101
+
+	isBorder := func(x, y int) bool {
+		gray := color.Gray16Model.Convert(img.At(x, y)).(color.Gray16)
+		return uint32(gray.Y) >= threshold
+	}
+
+	rowIsBorder := func(y int) bool {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if !isBorder(x, y) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	colIsBorder := func(x, minY, maxY int) bool {
+		for y := minY; y < maxY; y++ {
+			if !isBorder(x, y) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	trimmed := bounds
+	for trimmed.Min.Y < trimmed.Max.Y && rowIsBorder(trimmed.Min.Y) {
+		trimmed.Min.Y++
+	}
+
+	// blank page, nothing to trim to
+	if trimmed.Min.Y == trimmed.Max.Y {
+		return []image.Image{img}
+	}
+
+	for rowIsBorder(trimmed.Max.Y - 1) {
+		trimmed.Max.Y--
+	}
+
+	for colIsBorder(trimmed.Min.X, trimmed.Min.Y, trimmed.Max.Y) {
+		trimmed.Min.X++
+	}
+
+	for colIsBorder(trimmed.Max.X-1, trimmed.Min.Y, trimmed.Max.Y) {
+		trimmed.Max.X--
+	}
+
+	if trimmed == bounds {
+		return []image.Image{img}
+	}
+
+	return []image.Image{crop(img, trimmed)}
+}
+
+// Split splits double-page spreads, that is images wider than they are tall, in two pages
+type Split struct {
+	// RightToLeft puts the right half first
+	RightToLeft bool
+}
+
+func (*Split) Name() string {
+	return "split"
+}
+
+func (s *Split) Apply(img image.Image) []image.Image {
+	bounds := img.Bounds()
+	if bounds.Dx() <= bounds.Dy() {
+		return []image.Image{img}
+	}
+
+	middle := bounds.Min.X + bounds.Dx()/2
+	left := crop(img, image.Rect(bounds.Min.X, bounds.Min.Y, middle, bounds.Max.Y))
+	right := crop(img, image.Rect(middle, bounds.Min.Y, bounds.Max.X, bounds.Max.Y))
+
+	if s.RightToLeft {
+		return []image.Image{right, left}
+	}
+
+	return []image.Image{left, right}
+}
+
+// Downscale shrinks images taller than the max height keeping the aspect ratio.
+// Each resulting pixel is the average of the source pixels it covers.
+type Downscale struct {
+	MaxHeight int
+}
+
+func (*Downscale) Name() string {
+	return "downscale"
+}
+
+func (d *Downscale) Apply(img image.Image) []image.Image {
+	bounds := img.Bounds()
+	if d.MaxHeight <= 0 || bounds.Dy() <= d.MaxHeight {
+		return []image.Image{img}
+	}
+
+	height := d.MaxHeight
+	width := util.Max(bounds.Dx()*height/bounds.Dy(), 1)
+
+	return []image.Image{Resize(img, width, height)}
+}
+
+// Resize scales the image to the given size.
+// Each resulting pixel is the average of the source pixels it covers,
+// when enlarging it's the nearest source pixel.
+func Resize(img image.Image, width, height int) *image.RGBA {
+	src := toRGBA(img)
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0 := y * src.Rect.Dy() / height
+		y1 := util.Max((y+1)*src.Rect.Dy()/height, y0+1)
+
+		for x := 0; x < width; x++ {
+			x0 := x * src.Rect.Dx() / width
+			x1 := util.Max((x+1)*src.Rect.Dx()/width, x0+1)
+
+			var r, g, b, a, n uint32
+			for sy := y0; sy < y1; sy++ {
+				offset := sy*src.Stride + x0*4
+				for sx := x0; sx < x1; sx++ {
+					r += uint32(src.Pix[offset])
+					g += uint32(src.Pix[offset+1])
+					b += uint32(src.Pix[offset+2])
+					a += uint32(src.Pix[offset+3])
+					offset += 4
+					n++
+				}
+			}
+
+			i := y*dst.Stride + x*4
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
+
+// Grayscale converts images to grayscale, which is what e-ink readers display anyway
+type Grayscale struct{}
+
+func (*Grayscale) Name() string {
+	return "grayscale"
+}
+
+func (*Grayscale) Apply(img image.Image) []image.Image {
+	if _, ok := img.(*image.Gray); ok {
+		return []image.Image{img}
+	}
+
+	bounds := img.Bounds()
+	gray := image.NewGray(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(gray, gray.Bounds(), img, bounds.Min, draw.Src)
+
+	return []image.Image{gray}
+}
+
+// crop copies the part of the image, so that the result starts at the origin
+func crop(img image.Image, rect image.Rectangle) image.Image {
+	cropped := image.NewRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	draw.Draw(cropped, cropped.Bounds(), img, rect.Min, draw.Src)
+	return cropped
+}
+
+// toRGBA returns the image as RGBA with the origin at zero
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Rect.Min == (image.Point{}) {
+		return rgba
+	}
+
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+	return rgba
+}
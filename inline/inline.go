@@ -1,9 +1,12 @@
 package inline
 
 import (
+	"context"
 	"github.com/metafates/mangal/downloader"
 	"github.com/metafates/mangal/key"
 	"github.com/metafates/mangal/log"
+	"github.com/metafates/mangal/queue"
+	"github.com/metafates/mangal/search"
 	"github.com/metafates/mangal/source"
 	"github.com/spf13/viper"
 	"os"
@@ -14,16 +17,28 @@ func Run(options *Options) (err error) {
 		options.Out = os.Stdout
 	}
 
-	var mangas []*source.Manga
-	for _, src := range options.Sources {
-		m, err := src.Search(options.Query)
-		if err != nil {
-			return err
-		}
+	ctx := options.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
+	result := search.Search(ctx, options.Sources, options.Query)
+	if err = result.Err(); err != nil {
+		return err
+	}
 
-		mangas = append(mangas, m...)
+	for name, err := range result.Errors {
+		log.Warnf("source %s has failed: %s", name, err)
 	}
 
+	mangas := result.Mangas()
+
 	if options.MangaPicker.IsAbsent() && options.ChaptersFilter.IsAbsent() {
 		if viper.GetBool(key.MetadataFetchAnilist) {
 			for _, manga := range mangas {
@@ -31,7 +46,7 @@ func Run(options *Options) (err error) {
 			}
 		}
 
-		marshalled, err := asJson(mangas, options)
+		marshalled, err := asJson(mangas, result, options)
 		if err != nil {
 			return err
 		}
@@ -44,12 +59,12 @@ func Run(options *Options) (err error) {
 	if options.MangaPicker.IsAbsent() {
 		// preload all chapters
 		for _, manga := range mangas {
-			if err = prepareManga(manga, options); err != nil {
+			if err = prepareManga(ctx, manga, options); err != nil {
 				return err
 			}
 		}
 
-		marshalled, err := asJson(mangas, options)
+		marshalled, err := asJson(mangas, result, options)
 		if err != nil {
 			return err
 		}
@@ -62,7 +77,7 @@ func Run(options *Options) (err error) {
 
 	if len(mangas) == 0 {
 		if options.Json {
-			marshalled, err := asJson([]*source.Manga{}, options)
+			marshalled, err := asJson([]*source.Manga{}, result, options)
 			if err != nil {
 				return err
 			}
@@ -78,7 +93,7 @@ func Run(options *Options) (err error) {
 
 	if manga == nil {
 		if options.Json {
-			marshalled, err := asJson([]*source.Manga{}, options)
+			marshalled, err := asJson([]*source.Manga{}, result, options)
 			if err != nil {
 				return err
 			}
@@ -90,7 +105,7 @@ func Run(options *Options) (err error) {
 		return nil
 	}
 
-	chapters, err = manga.Source.ChaptersOf(manga)
+	chapters, err = source.WithContext(manga.Source).ChaptersOfContext(ctx, manga)
 	if err != nil {
 		return err
 	}
@@ -103,11 +118,11 @@ func Run(options *Options) (err error) {
 	}
 
 	if options.Json {
-		if err = prepareManga(manga, options); err != nil {
+		if err = prepareManga(ctx, manga, options); err != nil {
 			return err
 		}
 
-		marshalled, err := asJson([]*source.Manga{manga}, options)
+		marshalled, err := asJson([]*source.Manga{manga}, result, options)
 		if err != nil {
 			return err
 		}
@@ -116,6 +131,16 @@ func Run(options *Options) (err error) {
 		return err
 	}
 
+	if options.Download {
+		if err = queue.Enqueue(chapters...); err != nil {
+			log.Warn(err)
+		}
+
+		if viper.GetBool(key.FormatsBundleVolumes) {
+			return downloadVolumes(chapters, options)
+		}
+	}
+
 	for _, chapter := range chapters {
 		if options.Download {
 			path, err := downloader.Download(chapter, func(string) {})
@@ -141,3 +166,23 @@ func Run(options *Options) (err error) {
 
 	return nil
 }
+
+// downloadVolumes downloads chapters bundled by volumes and writes paths of the saved files
+func downloadVolumes(chapters []*source.Chapter, options *Options) error {
+	for _, volume := range downloader.Volumes(chapters) {
+		path, err := downloader.DownloadVolume(volume, func(string) {})
+		if err != nil {
+			if viper.GetBool(key.DownloaderStopOnError) {
+				return err
+			}
+
+			continue
+		}
+
+		if _, err = options.Out.Write([]byte(path + "\n")); err != nil {
+			log.Warn(err)
+		}
+	}
+
+	return nil
+}
@@ -1,9 +1,11 @@
 package inline
 
 import (
+	"context"
 	"encoding/json"
 	"github.com/metafates/mangal/anilist"
 	"github.com/metafates/mangal/key"
+	"github.com/metafates/mangal/search"
 	"github.com/metafates/mangal/source"
 	"github.com/spf13/viper"
 )
@@ -11,6 +13,8 @@ import (
 type Manga struct {
 	// Source that the manga belongs to.
 	Source string `json:"source" jsonschema:"description=Source that the manga belongs to."`
+	// Sources that have a manga with the same title, including this one.
+	Sources []string `json:"sources" jsonschema:"description=Sources that have a manga with the same title, including this one."`
 	// Mangal variant of the manga
 	Mangal *source.Manga `json:"mangal" jsonschema:"description=Mangal variant of the manga"`
 	// Anilist is the closest anilist match to mangal manga
@@ -22,28 +26,39 @@ type Output struct {
 	Result []*Manga `json:"result" jsonschema:"description=Result of the search."`
 }
 
-func asJson(manga []*source.Manga, options *Options) (marshalled []byte, err error) {
+func asJson(manga []*source.Manga, result *search.Result, options *Options) (marshalled []byte, err error) {
+	return json.Marshal(NewOutput(options.Query, manga, result, options.IncludeAnilistManga))
+}
+
+// NewOutput returns the output for the mangas found by the query
+func NewOutput(query string, manga []*source.Manga, result *search.Result, includeAnilistManga bool) *Output {
 	var m = make([]*Manga, len(manga))
 	for i, manga := range manga {
 		al := manga.Anilist.OrElse(nil)
-		if !options.IncludeAnilistManga {
+		if !includeAnilistManga {
 			al = nil
 		}
 
+		sources := []string{manga.Source.Name()}
+		if group, ok := result.GroupOf(manga); ok {
+			sources = group.Sources()
+		}
+
 		m[i] = &Manga{
 			Mangal:  manga,
 			Anilist: al,
 			Source:  manga.Source.Name(),
+			Sources: sources,
 		}
 	}
 
-	return json.Marshal(&Output{
+	return &Output{
 		Result: m,
-		Query:  options.Query,
-	})
+		Query:  query,
+	}
 }
 
-func prepareManga(manga *source.Manga, options *Options) error {
+func prepareManga(ctx context.Context, manga *source.Manga, options *Options) error {
 	var err error
 
 	if options.IncludeAnilistManga {
@@ -54,7 +69,7 @@ func prepareManga(manga *source.Manga, options *Options) error {
 	}
 
 	if options.ChaptersFilter.IsPresent() {
-		chapters, err := manga.Source.ChaptersOf(manga)
+		chapters, err := source.WithContext(manga.Source).ChaptersOfContext(ctx, manga)
 		if err != nil {
 			return err
 		}
@@ -68,7 +83,7 @@ func prepareManga(manga *source.Manga, options *Options) error {
 
 		if options.PopulatePages {
 			for _, chapter := range chapters {
-				_, err := chapter.Source().PagesOf(chapter)
+				_, err := source.WithContext(chapter.Source()).PagesOfContext(ctx, chapter)
 				if err != nil {
 					return err
 				}
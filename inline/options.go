@@ -1,6 +1,7 @@
 package inline
 
 import (
+	"context"
 	"fmt"
 	"github.com/metafates/mangal/source"
 	"github.com/metafates/mangal/util"
@@ -10,6 +11,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type (
@@ -18,6 +20,8 @@ type (
 )
 
 type Options struct {
+	// Context of the run, background context is used if it's nil
+	Context             context.Context
 	Out                 io.Writer
 	Sources             []source.Source
 	IncludeAnilistManga bool
@@ -27,6 +31,8 @@ type Options struct {
 	Query               string
 	MangaPicker         mo.Option[MangaPicker]
 	ChaptersFilter      mo.Option[ChaptersFilter]
+	// Timeout for searching, fetching chapters and pages. Zero means no timeout
+	Timeout time.Duration
 }
 
 func ParseMangaPicker(query, description string) (MangaPicker, error) {
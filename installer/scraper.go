@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/metafates/mangal/filesystem"
+	"github.com/metafates/mangal/network"
 	"github.com/metafates/mangal/where"
 	"io"
 	"net/http"
@@ -37,7 +38,7 @@ func (s *Scraper) download() error {
 		return fmt.Errorf("url must be set")
 	}
 
-	res, err := http.Get(s.URL)
+	res, err := network.Client.Get(s.URL)
 	if err != nil {
 		return err
 	}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"github.com/metafates/mangal/anilist"
 	"github.com/metafates/mangal/log"
+	"github.com/metafates/mangal/network"
 	"github.com/metafates/mangal/source"
 	"net/http"
 	"strconv"
@@ -69,7 +70,7 @@ func (a *Anilist) MarkRead(chapter *source.Chapter) error {
 
 	// send request
 	log.Info("Sending request to Anilist: " + string(jsonBody))
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := network.Client.Do(req)
 	if err != nil {
 		log.Error(err)
 		return err
@@ -3,7 +3,7 @@ package key
 // DefinedFieldsCount is the number of fields defined in this package.
 // You have to manually update this number when you add a new field
 // to check later if every field has a defined default value
-const DefinedFieldsCount = 61
+const DefinedFieldsCount = 100
 
 const (
 	DownloaderPath                     = "downloader.path"
@@ -19,11 +19,27 @@ const (
 	DownloaderDownloadCover            = "downloader.download_cover"
 	DownloaderRedownloadExisting       = "downloader.redownload_existing"
 	DownloaderReadDownloaded           = "downloader.read_downloaded"
+	DownloaderPageRetries              = "downloader.page_retries"
+	DownloaderPageRetryDelay           = "downloader.page_retry_delay"
+	DownloaderPageWorkers              = "downloader.page_workers"
+	DownloaderPageWorkersPerHost       = "downloader.page_workers_per_host"
+	DownloaderSourcePageWorkers        = "downloader.source_page_workers"
 )
 
 const (
 	FormatsUse                   = "formats.use"
 	FormatsSkipUnsupportedImages = "formats.skip_unsupported_images"
+	FormatsBundleVolumes         = "formats.bundle_volumes"
+	FormatsVolumeChunkSize       = "formats.volume_chunk_size"
+)
+
+const (
+	ImagesEncode       = "images.encode"
+	ImagesJPEGQuality  = "images.jpeg_quality"
+	ImagesMaxHeight    = "images.max_height"
+	ImagesGrayscale    = "images.grayscale"
+	ImagesTrimBorders  = "images.trim_borders"
+	ImagesSplitSpreads = "images.split_spreads"
 )
 
 const (
@@ -39,10 +55,14 @@ const (
 	ReaderPDF           = "reader.pdf"
 	ReaderCBZ           = "reader.cbz"
 	ReaderZIP           = "reader.zip"
+	ReaderEPUB          = "reader.epub"
 	RaderPlain          = "reader.plain"
 	ReaderBrowser       = "reader.browser"
 	ReaderFolder        = "reader.folder"
 	ReaderReadInBrowser = "reader.read_in_browser"
+
+	ReaderTerminal         = "reader.terminal"
+	ReaderTerminalProtocol = "reader.terminal_protocol"
 )
 
 const (
@@ -52,6 +72,7 @@ const (
 
 const (
 	SearchShowQuerySuggestions = "search.show_query_suggestions"
+	SearchSourceTimeout        = "search.source_timeout"
 )
 
 const (
@@ -99,6 +120,40 @@ const (
 	InstallerBranch = "installer.branch"
 )
 
+const (
+	SandboxEnabled          = "sandbox.enabled"
+	SandboxTimeout          = "sandbox.timeout"
+	SandboxInstructionLimit = "sandbox.instruction_limit"
+	SandboxMemoryLimit      = "sandbox.memory_limit"
+	SandboxAllowOS          = "sandbox.allow_os"
+	SandboxAllowIO          = "sandbox.allow_io"
+)
+
+const (
+	HTTPCache              = "http.cache"
+	HTTPProxy              = "http.proxy"
+	HTTPProxyRules         = "http.proxy_rules"
+	HTTPUserAgent          = "http.user_agent"
+	HTTPHeaders            = "http.headers"
+	HTTPCABundle           = "http.ca_bundle"
+	HTTPInsecureSkipVerify = "http.insecure_skip_verify"
+)
+
+const (
+	RateLimitEnabled           = "ratelimit.enabled"
+	RateLimitRequestsPerMinute = "ratelimit.requests_per_minute"
+	RateLimitBurst             = "ratelimit.burst"
+	RateLimitHosts             = "ratelimit.hosts"
+	RateLimitSources           = "ratelimit.sources"
+)
+
+const (
+	ServeAddress  = "serve.address"
+	ServeReadOnly = "serve.read_only"
+	ServeUsername = "serve.username"
+	ServePassword = "serve.password"
+)
+
 const (
 	GenAuthor = "gen.author"
 )
@@ -0,0 +1,168 @@
+package library
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/metafates/mangal/filesystem"
+	"github.com/metafates/mangal/util"
+	"github.com/metafates/mangal/where"
+	"github.com/samber/lo"
+)
+
+// ErrNotScanned is returned when the library index does not exist yet
+var ErrNotScanned = errors.New("library was not scanned yet, run \"mangal library scan\" first")
+
+// Index of the downloaded manga
+type Index struct {
+	// Root is the downloads directory that was scanned
+	Root string `json:"root"`
+	// Scanned is the time of the scan
+	Scanned time.Time `json:"scanned"`
+	// Series found in the downloads directory sorted by name
+	Series []*Series `json:"series"`
+}
+
+// Series is a downloaded manga
+type Series struct {
+	// Name of the manga
+	Name string `json:"name"`
+	// Path of the manga directory relative to the root
+	Path string `json:"path"`
+	// Source the manga was downloaded from, if known
+	Source string `json:"source,omitempty"`
+	// Language of the source, if it differs from the standard one
+	Language string `json:"language,omitempty"`
+	// Status of the manga from the series.json
+	Status string `json:"status,omitempty"`
+	// Year the manga started from the series.json
+	Year int `json:"year,omitempty"`
+	// Summary of the manga from the series.json or the ComicInfo.xml
+	Summary string `json:"summary,omitempty"`
+	// Authors of the manga from the ComicInfo.xml
+	Authors []string `json:"authors,omitempty"`
+	// Genres of the manga from the ComicInfo.xml
+	Genres []string `json:"genres,omitempty"`
+	// Formats of the downloaded chapters
+	Formats []string `json:"formats"`
+	// Size of all chapters in bytes
+	Size int64 `json:"size"`
+	// Updated is the modification time of the latest chapter
+	Updated time.Time `json:"updated"`
+	// Chapters of the manga sorted by file name
+	Chapters []*Chapter `json:"chapters"`
+}
+
+// SizeHuman returns a human-readable size of the series
+func (s *Series) SizeHuman() string {
+	return humanize.Bytes(uint64(s.Size))
+}
+
+// Chapter is a downloaded chapter
+type Chapter struct {
+	// Name of the chapter
+	Name string `json:"name"`
+	// Number of the chapter from the ComicInfo.xml
+	Number string `json:"number,omitempty"`
+	// Volume directory the chapter is placed in
+	Volume string `json:"volume,omitempty"`
+	// Path of the chapter relative to the root
+	Path string `json:"path"`
+	// Format of the chapter
+	Format string `json:"format"`
+	// Size of the chapter in bytes
+	Size int64 `json:"size"`
+	// Pages count, if known
+	Pages int `json:"pages,omitempty"`
+	// URL of the chapter from the ComicInfo.xml
+	URL string `json:"url,omitempty"`
+	// Summary of the manga from the ComicInfo.xml
+	Summary string `json:"summary,omitempty"`
+	// Authors of the manga from the ComicInfo.xml
+	Authors []string `json:"authors,omitempty"`
+	// Genres of the manga from the ComicInfo.xml
+	Genres []string `json:"genres,omitempty"`
+	// LanguageISO of the chapter from the ComicInfo.xml
+	LanguageISO string `json:"language_iso,omitempty"`
+	// Modified is the modification time of the chapter
+	Modified time.Time `json:"modified"`
+}
+
+// SizeHuman returns a human-readable size of the chapter
+func (c *Chapter) SizeHuman() string {
+	return humanize.Bytes(uint64(c.Size))
+}
+
+// Chapters returns the total number of chapters in the index
+func (i *Index) Chapters() (count int) {
+	for _, series := range i.Series {
+		count += len(series.Chapters)
+	}
+
+	return
+}
+
+// Find returns the series with the given name or path.
+// Names are compared case-insensitively. If nothing matches exactly,
+// the only series that contains the query in its name is returned.
+func (i *Index) Find(query string) (*Series, bool) {
+	normalized := util.NormalizeName(query)
+
+	series, ok := lo.Find(i.Series, func(s *Series) bool {
+		return util.NormalizeName(s.Name) == normalized || s.Path == query
+	})
+	if ok {
+		return series, true
+	}
+
+	matches := lo.Filter(i.Series, func(s *Series, _ int) bool {
+		return strings.Contains(util.NormalizeName(s.Name), normalized)
+	})
+	if len(matches) == 1 {
+		return matches[0], true
+	}
+
+	return nil, false
+}
+
+// Load reads the index from the disk.
+// ErrNotScanned is returned if there is no index yet.
+func Load() (*Index, error) {
+	path := where.Library()
+
+	exists, err := filesystem.Api().Exists(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, ErrNotScanned
+	}
+
+	contents, err := filesystem.Api().ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var index Index
+	if err = json.Unmarshal(contents, &index); err != nil {
+		return nil, err
+	}
+
+	return &index, nil
+}
+
+// Save writes the index to the disk.
+// The file is replaced atomically, so that the index is never left half-written.
+func Save(index *Index) error {
+	marshalled, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+
+	return filesystem.WriteFileAtomic(where.Library(), marshalled, os.ModePerm)
+}
@@ -0,0 +1,120 @@
+package library
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/metafates/mangal/filesystem"
+	"github.com/metafates/mangal/key"
+	"github.com/metafates/mangal/source"
+	"github.com/samber/lo"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	filesystem.SetMemMapFs()
+}
+
+func writeFile(path string, contents []byte) {
+	lo.Must0(filesystem.Api().MkdirAll(filepath.Dir(path), os.ModePerm))
+	lo.Must0(filesystem.Api().WriteFile(path, contents, os.ModePerm))
+}
+
+func cbz(comicInfo *source.ComicInfo, images ...string) []byte {
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+
+	for _, image := range images {
+		w := lo.Must(writer.Create(image))
+		_, _ = w.Write([]byte("image"))
+	}
+
+	w := lo.Must(writer.Create("ComicInfo.xml"))
+	_, _ = w.Write(lo.Must(xml.Marshal(comicInfo)))
+
+	lo.Must0(writer.Close())
+	return buf.Bytes()
+}
+
+func TestScan(t *testing.T) {
+	viper.Set(key.DownloaderCreateSourceDir, true)
+
+	root := filepath.Join("library", "downloads")
+	berserk := filepath.Join(root, "Mangadex", "Berserk")
+	naruto := filepath.Join(root, "Manganelo [ru]", "Naruto")
+
+	writeFile(filepath.Join(berserk, "series.json"), []byte(`{"metadata":{"name":"Berserk","status":"Continuing","year":1989}}`))
+	writeFile(filepath.Join(berserk, "cover.jpg"), []byte("cover"))
+	writeFile(filepath.Join(berserk, "[0001] Chapter 1.cbz"), cbz(&source.ComicInfo{
+		Series:    "Berserk",
+		Title:     "The Black Swordsman",
+		Number:    "1",
+		Web:       "https://example.com/berserk/1",
+		Writer:    "Kentaro Miura",
+		Penciller: "Kentaro Miura, Studio Gaga",
+		Genre:     "Action,Horror",
+	}, "1.jpg", "2.jpg"))
+	writeFile(filepath.Join(berserk, "Vol 1", "[0002] Chapter 2.pdf"), []byte("pdf"))
+	writeFile(filepath.Join(naruto, "[0001] Chapter 1", "1.jpg"), []byte("image"))
+	writeFile(filepath.Join(naruto, "[0001] Chapter 1", "2.jpg"), []byte("image"))
+
+	Convey("Given a downloads directory", t, func() {
+		Convey("When it is scanned", func() {
+			index, err := Scan(root)
+			So(err, ShouldBeNil)
+
+			Convey("Then all series should be found", func() {
+				So(index.Series, ShouldHaveLength, 2)
+				So(index.Chapters(), ShouldEqual, 3)
+			})
+
+			Convey("Then metadata should be read from series.json and ComicInfo.xml", func() {
+				series, ok := index.Find("berserk")
+				So(ok, ShouldBeTrue)
+				So(series.Source, ShouldEqual, "Mangadex")
+				So(series.Status, ShouldEqual, "Continuing")
+				So(series.Year, ShouldEqual, 1989)
+				So(series.Formats, ShouldResemble, []string{"cbz", "pdf"})
+				So(series.Authors, ShouldResemble, []string{"Kentaro Miura", "Studio Gaga"})
+				So(series.Genres, ShouldResemble, []string{"Action", "Horror"})
+				So(series.Chapters, ShouldHaveLength, 2)
+
+				chapter := series.Chapters[0]
+				So(chapter.Name, ShouldEqual, "The Black Swordsman")
+				So(chapter.Number, ShouldEqual, "1")
+				So(chapter.Pages, ShouldEqual, 2)
+				So(chapter.URL, ShouldEqual, "https://example.com/berserk/1")
+				So(chapter.Path, ShouldEqual, filepath.Join("Mangadex", "Berserk", "[0001] Chapter 1.cbz"))
+
+				So(series.Chapters[1].Volume, ShouldEqual, "Vol 1")
+			})
+
+			Convey("Then plain chapters should be found", func() {
+				series, ok := index.Find("nar")
+				So(ok, ShouldBeTrue)
+				So(series.Name, ShouldEqual, "Naruto")
+				So(series.Source, ShouldEqual, "Manganelo")
+				So(series.Language, ShouldEqual, "ru")
+				So(series.Chapters, ShouldHaveLength, 1)
+				So(series.Chapters[0].Format, ShouldEqual, "plain")
+				So(series.Chapters[0].Pages, ShouldEqual, 2)
+			})
+
+			Convey("And saved", func() {
+				So(Save(index), ShouldBeNil)
+
+				Convey("Then it should be loaded back", func() {
+					loaded, err := Load()
+					So(err, ShouldBeNil)
+					So(loaded.Series, ShouldHaveLength, 2)
+					So(loaded.Chapters(), ShouldEqual, 3)
+				})
+			})
+		})
+	})
+}
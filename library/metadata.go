@@ -0,0 +1,93 @@
+package library
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+
+	"github.com/metafates/mangal/filesystem"
+	"github.com/metafates/mangal/source"
+	"github.com/metafates/mangal/util"
+)
+
+type archiveInfo struct {
+	// comicInfo is nil if archive has no ComicInfo.xml
+	comicInfo *source.ComicInfo
+	pages     int
+}
+
+// readArchive reads ComicInfo.xml and counts images inside a cbz or zip chapter
+func readArchive(path string) (*archiveInfo, error) {
+	file, err := filesystem.Api().Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer util.Ignore(file.Close)
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := zip.NewReader(file, stat.Size())
+	if err != nil {
+		return nil, err
+	}
+
+	var info archiveInfo
+
+	for _, f := range reader.File {
+		switch {
+		case f.Name == "ComicInfo.xml":
+			info.comicInfo, err = readComicInfo(f)
+			if err != nil {
+				return nil, err
+			}
+		case IsImage(f.Name):
+			info.pages++
+		}
+	}
+
+	if info.comicInfo != nil && info.comicInfo.PageCount > 0 {
+		info.pages = info.comicInfo.PageCount
+	}
+
+	return &info, nil
+}
+
+func readComicInfo(f *zip.File) (*source.ComicInfo, error) {
+	reader, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	defer util.Ignore(reader.Close)
+
+	contents, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	var comicInfo source.ComicInfo
+	if err = xml.Unmarshal(contents, &comicInfo); err != nil {
+		return nil, err
+	}
+
+	return &comicInfo, nil
+}
+
+func readSeriesJSON(path string) (*source.SeriesJSON, error) {
+	contents, err := filesystem.Api().ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var seriesJSON source.SeriesJSON
+	if err = json.Unmarshal(contents, &seriesJSON); err != nil {
+		return nil, err
+	}
+
+	return &seriesJSON, nil
+}
@@ -0,0 +1,323 @@
+package library
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/metafates/mangal/constant"
+	"github.com/metafates/mangal/filesystem"
+	"github.com/metafates/mangal/key"
+	"github.com/metafates/mangal/log"
+	"github.com/metafates/mangal/util"
+	"github.com/samber/lo"
+	"github.com/spf13/viper"
+)
+
+// fileFormats are formats of the chapters that are stored as a single file
+var fileFormats = []string{
+	constant.FormatCBZ,
+	constant.FormatPDF,
+	constant.FormatZIP,
+	constant.FormatEPUB,
+}
+
+var imageExtensions = []string{".jpg", ".jpeg", ".png", ".gif", ".webp", ".bmp"}
+
+// sourceDirRegex matches source directories with a non-standard language, e.g. "Mangadex [ru]"
+var sourceDirRegex = regexp.MustCompile(`^(.+) \[([\w-]+)]$`)
+
+// IsImage reports whether the file is an image by its extension
+func IsImage(name string) bool {
+	return lo.Contains(imageExtensions, strings.ToLower(filepath.Ext(name)))
+}
+
+// dirInfo is what is known about the directory after the walk
+type dirInfo struct {
+	images       int
+	size         int64
+	modified     time.Time
+	hasSubdirs   bool
+	hasChapters  bool
+	hasSeriesDef bool
+}
+
+// isPlainChapter reports whether the directory is a chapter saved in the plain format
+func (d *dirInfo) isPlainChapter() bool {
+	return d.images > 0 && !d.hasSubdirs && !d.hasChapters && !d.hasSeriesDef
+}
+
+// Scan walks the root directory and indexes all downloaded chapters.
+// Chapters are grouped into series by the nearest directory with series.json,
+// or by the directory they are placed in and the series name from the ComicInfo.xml.
+func Scan(root string) (*Index, error) {
+	root = filepath.Clean(root)
+
+	var (
+		dirs  = make(map[string]*dirInfo)
+		files = make(map[string]os.FileInfo)
+	)
+
+	dir := func(path string) *dirInfo {
+		info, ok := dirs[path]
+		if !ok {
+			info = &dirInfo{}
+			dirs[path] = info
+		}
+
+		return info
+	}
+
+	err := filesystem.Api().Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			log.Warnf("skipping %s: %s", path, err)
+			return nil
+		}
+
+		if path == root {
+			return nil
+		}
+
+		parent := dir(filepath.Dir(path))
+
+		if info.IsDir() {
+			parent.hasSubdirs = true
+			dir(path)
+			return nil
+		}
+
+		name := info.Name()
+		switch {
+		case name == "series.json":
+			parent.hasSeriesDef = true
+		case lo.Contains(fileFormats, strings.TrimPrefix(strings.ToLower(filepath.Ext(name)), ".")):
+			parent.hasChapters = true
+			files[path] = info
+		case IsImage(name) && util.FileStem(name) != "cover":
+			parent.images++
+			parent.size += info.Size()
+			if info.ModTime().After(parent.modified) {
+				parent.modified = info.ModTime()
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	var chapters []*Chapter
+
+	for path, info := range files {
+		chapters = append(chapters, &Chapter{
+			Name:     util.FileStem(path),
+			Path:     path,
+			Format:   strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), "."),
+			Size:     info.Size(),
+			Modified: info.ModTime(),
+		})
+	}
+
+	for path, info := range dirs {
+		if !info.isPlainChapter() {
+			continue
+		}
+
+		chapters = append(chapters, &Chapter{
+			Name:     filepath.Base(path),
+			Path:     path,
+			Format:   constant.FormatPlain,
+			Size:     info.size,
+			Pages:    info.images,
+			Modified: info.modified,
+		})
+	}
+
+	var (
+		index = &Index{
+			Root:    root,
+			Scanned: time.Now(),
+		}
+		bySeries = make(map[string]*Series)
+	)
+
+	for _, chapter := range chapters {
+		var seriesName string
+
+		if chapter.Format == constant.FormatCBZ || chapter.Format == constant.FormatZIP {
+			if archive, err := readArchive(chapter.Path); err != nil {
+				log.Warnf("could not read %s: %s", chapter.Path, err)
+			} else {
+				chapter.Pages = archive.pages
+
+				if comicInfo := archive.comicInfo; comicInfo != nil {
+					seriesName = comicInfo.Series
+					chapter.Number = comicInfo.Number
+					chapter.URL = comicInfo.Web
+					chapter.Summary = comicInfo.Summary
+					chapter.Authors = splitList(comicInfo.Writer, comicInfo.Penciller)
+					chapter.Genres = splitList(comicInfo.Genre)
+					chapter.LanguageISO = comicInfo.LanguageISO
+					if comicInfo.Title != "" {
+						chapter.Name = comicInfo.Title
+					}
+				}
+			}
+		}
+
+		seriesDir, hasSeriesDef := findSeriesDir(root, chapter.Path, dirs)
+
+		// series directory has no series.json, so several different manga
+		// may be placed in it. Tell them apart by the name from the ComicInfo.xml
+		groupKey := seriesDir
+		if !hasSeriesDef {
+			groupKey += string(filepath.Separator) + seriesName
+		}
+
+		series, ok := bySeries[groupKey]
+		if !ok {
+			series = newSeries(root, seriesDir, hasSeriesDef, seriesName)
+			bySeries[groupKey] = series
+			index.Series = append(index.Series, series)
+		}
+
+		if parent := filepath.Dir(chapter.Path); parent != seriesDir {
+			chapter.Volume = filepath.Base(parent)
+		}
+
+		series.add(chapter)
+	}
+
+	for _, series := range index.Series {
+		for _, chapter := range series.Chapters {
+			chapter.Path = relative(root, chapter.Path)
+		}
+
+		// file names start with the chapter index by default, while volume directories may differ
+		sort.Slice(series.Chapters, func(i, j int) bool {
+			a, b := filepath.Base(series.Chapters[i].Path), filepath.Base(series.Chapters[j].Path)
+			if a == b {
+				return series.Chapters[i].Path < series.Chapters[j].Path
+			}
+
+			return a < b
+		})
+
+		sort.Strings(series.Formats)
+	}
+
+	sort.Slice(index.Series, func(i, j int) bool {
+		a, b := index.Series[i], index.Series[j]
+		if a.Name == b.Name {
+			return a.Source < b.Source
+		}
+
+		return util.NormalizeName(a.Name) < util.NormalizeName(b.Name)
+	})
+
+	return index, nil
+}
+
+// findSeriesDir returns the nearest parent directory of the chapter with series.json.
+// If there is none, the directory the chapter is placed in is returned.
+func findSeriesDir(root, chapter string, dirs map[string]*dirInfo) (path string, hasSeriesDef bool) {
+	parent := filepath.Dir(chapter)
+
+	for path = parent; path != root && strings.HasPrefix(path, root); path = filepath.Dir(path) {
+		if info, ok := dirs[path]; ok && info.hasSeriesDef {
+			return path, true
+		}
+	}
+
+	return parent, false
+}
+
+func newSeries(root, path string, hasSeriesDef bool, name string) *Series {
+	series := &Series{
+		Name:    name,
+		Path:    relative(root, path),
+		Formats: make([]string, 0),
+	}
+
+	if hasSeriesDef {
+		if seriesJSON, err := readSeriesJSON(filepath.Join(path, "series.json")); err != nil {
+			log.Warnf("could not read series.json of %s: %s", path, err)
+		} else {
+			meta := seriesJSON.Metadata
+			series.Status = meta.Status
+			series.Year = meta.Year
+			series.Summary = meta.DescriptionText
+			if meta.Name != "" {
+				series.Name = meta.Name
+			}
+		}
+	}
+
+	if series.Name == "" {
+		series.Name = filepath.Base(path)
+	}
+
+	// downloads are structured as <root>/<source>/<manga> when source directories are enabled
+	if parts := strings.Split(series.Path, string(filepath.Separator)); viper.GetBool(key.DownloaderCreateSourceDir) && len(parts) >= 2 {
+		series.Source = parts[0]
+		if groups := sourceDirRegex.FindStringSubmatch(parts[0]); groups != nil {
+			series.Source, series.Language = groups[1], groups[2]
+		}
+	}
+
+	return series
+}
+
+func (s *Series) add(chapter *Chapter) {
+	s.Chapters = append(s.Chapters, chapter)
+	s.Size += chapter.Size
+
+	if !lo.Contains(s.Formats, chapter.Format) {
+		s.Formats = append(s.Formats, chapter.Format)
+	}
+
+	if chapter.Modified.After(s.Updated) {
+		s.Updated = chapter.Modified
+	}
+
+	// series.json has no authors and genres, so they are taken from the ComicInfo.xml of the chapters
+	if s.Summary == "" {
+		s.Summary = chapter.Summary
+	}
+
+	if len(s.Authors) == 0 {
+		s.Authors = chapter.Authors
+	}
+
+	if len(s.Genres) == 0 {
+		s.Genres = chapter.Genres
+	}
+}
+
+// splitList joins comma separated lists of the ComicInfo.xml without duplicates
+func splitList(lists ...string) []string {
+	var values []string
+
+	for _, list := range lists {
+		for _, value := range strings.Split(list, ",") {
+			if value = strings.TrimSpace(value); value != "" && !lo.Contains(values, value) {
+				values = append(values, value)
+			}
+		}
+	}
+
+	return values
+}
+
+func relative(root, path string) string {
+	if rel, err := filepath.Rel(root, path); err == nil {
+		return rel
+	}
+
+	return path
+}
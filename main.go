@@ -4,11 +4,13 @@ import (
 	"github.com/metafates/mangal/cmd"
 	"github.com/metafates/mangal/config"
 	"github.com/metafates/mangal/log"
+	"github.com/metafates/mangal/provider"
 	"github.com/samber/lo"
 )
 
 func main() {
 	lo.Must0(config.Setup())
 	lo.Must0(log.Setup())
+	provider.RegisterSettings()
 	cmd.Execute()
 }
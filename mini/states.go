@@ -5,7 +5,9 @@ import (
 	"github.com/metafates/mangal/downloader"
 	"github.com/metafates/mangal/history"
 	"github.com/metafates/mangal/key"
+	"github.com/metafates/mangal/log"
 	"github.com/metafates/mangal/provider"
+	"github.com/metafates/mangal/queue"
 	"github.com/metafates/mangal/source"
 	"github.com/metafates/mangal/util"
 	"github.com/samber/lo"
@@ -305,6 +307,26 @@ func (m *mini) handleChapterReadState() error {
 	}
 }
 
+func (m *mini) downloadVolume(volume *source.Volume) error {
+	util.ClearScreen()
+	var erase = func() {}
+
+	title(fmt.Sprintf("Currently downloading %s %s (%s)", volume.Manga.Name, volume.Name, m.selectedSource.Name()))
+
+	_, err := downloader.DownloadVolume(volume, func(s string) {
+		erase()
+		erase = progress(s)
+	})
+
+	erase()
+
+	if err != nil && viper.GetBool(key.DownloaderStopOnError) {
+		return err
+	}
+
+	return nil
+}
+
 func (m *mini) handleChaptersDownloadState() error {
 	var (
 		err          error
@@ -331,10 +353,23 @@ func (m *mini) handleChaptersDownloadState() error {
 		return nil
 	}
 
-	for _, chapter := range m.selectedChapters {
-		err = downloadLoop(chapter)
-		if err != nil {
-			return err
+	if err = queue.Enqueue(m.selectedChapters...); err != nil {
+		log.Warn(err)
+	}
+
+	if viper.GetBool(key.FormatsBundleVolumes) {
+		for _, volume := range downloader.Volumes(m.selectedChapters) {
+			err = m.downloadVolume(volume)
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		for _, chapter := range m.selectedChapters {
+			err = downloadLoop(chapter)
+			if err != nil {
+				return err
+			}
 		}
 	}
 
@@ -364,6 +399,9 @@ func (m *mini) handleHistorySelectState() error {
 	}
 
 	chapters := lo.Values(h)
+	slices.SortFunc(chapters, func(a, b *history.SavedChapter) bool {
+		return a.Time.After(b.Time)
+	})
 
 	title("History Results >>")
 	b, c, err := menu(chapters)
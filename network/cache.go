@@ -0,0 +1,369 @@
+package network
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/metafates/mangal/filesystem"
+	"github.com/metafates/mangal/key"
+	"github.com/metafates/mangal/log"
+	"github.com/metafates/mangal/where"
+	"github.com/spf13/viper"
+)
+
+const (
+	// CacheHeader is set on responses served from the cache.
+	// Its value is "hit" for fresh responses and "revalidated" for the ones confirmed by the server
+	CacheHeader = "X-Mangal-Cache"
+
+	// storedHeader is the time the response was stored or revalidated
+	storedHeader = "X-Mangal-Stored"
+	// varyHeaderPrefix prefixes the request headers the response varies by
+	varyHeaderPrefix = "X-Mangal-Vary-"
+
+	// maxStoredSize is the largest body that is stored, in bytes
+	maxStoredSize = 4 << 20
+)
+
+// now is used to compute freshness, replaced in tests
+var now = time.Now
+
+// WithCache wraps the transport with the http cache that stores responses on the disk.
+// It is enabled with the http.cache option and follows Cache-Control of requests and responses.
+// Stale responses are revalidated with If-None-Match and If-Modified-Since.
+//
+// Images and large bodies are never stored, pages are saved by the downloader anyway
+// and would grow the cache without a bound.
+// Only GET requests are cached, so anilist queries (GraphQL over POST) are not,
+// they are cached by the anilist package instead.
+func WithCache(base http.RoundTripper) http.RoundTripper {
+	return &cacheTransport{base: base, dir: where.HTTPCache}
+}
+
+// cacheTransport is a private http cache, as described in RFC 9111
+type cacheTransport struct {
+	base http.RoundTripper
+	// dir returns the directory of the cache
+	dir func() string
+}
+
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !viper.GetBool(key.HTTPCache) || !cacheable(req) {
+		return t.base.RoundTrip(req)
+	}
+
+	path := filepath.Join(t.dir(), cacheKey(req))
+	cached, err := t.load(path, req)
+	if err != nil {
+		log.Warn(err)
+	}
+
+	if cached == nil {
+		return t.fetch(path, req)
+	}
+
+	if fresh(req, cached) {
+		cached.Header.Set(CacheHeader, "hit")
+		return cached, nil
+	}
+
+	etag, lastModified := cached.Header.Get("ETag"), cached.Header.Get("Last-Modified")
+	if etag == "" && lastModified == "" {
+		_ = cached.Body.Close()
+		return t.fetch(path, req)
+	}
+
+	conditional := req.Clone(req.Context())
+	if etag != "" {
+		conditional.Header.Set("If-None-Match", etag)
+	}
+
+	if lastModified != "" {
+		conditional.Header.Set("If-Modified-Since", lastModified)
+	}
+
+	resp, err := t.base.RoundTrip(conditional)
+	if err != nil {
+		_ = cached.Body.Close()
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusNotModified {
+		_ = cached.Body.Close()
+		return t.store(path, req, resp)
+	}
+
+	_ = resp.Body.Close()
+
+	// headers of 304 response update the stored ones
+	for name, values := range resp.Header {
+		if name != "Content-Length" {
+			cached.Header[name] = values
+		}
+	}
+
+	// the body is read to store the updated response
+	body, err := io.ReadAll(cached.Body)
+	_ = cached.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	cached.Body = io.NopCloser(bytes.NewReader(body))
+	if err := t.write(path, req, cached); err != nil {
+		log.Warn(err)
+	}
+
+	cached.Body = io.NopCloser(bytes.NewReader(body))
+	cached.Header.Set(CacheHeader, "revalidated")
+	return cached, nil
+}
+
+// fetch makes the request and stores the response if it's allowed
+func (t *cacheTransport) fetch(path string, req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.store(path, req, resp)
+}
+
+// store writes the response to the cache if it's storable
+func (t *cacheTransport) store(path string, req *http.Request, resp *http.Response) (*http.Response, error) {
+	if !storable(resp) {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+
+	// length is not known before the body is read for chunked responses
+	if len(body) > maxStoredSize {
+		return resp, nil
+	}
+
+	if err := t.write(path, req, resp); err != nil {
+		log.Warn(err)
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// write dumps the response with its metadata to the file.
+// The file is replaced atomically, so that concurrent readers never see a partial response
+func (t *cacheTransport) write(path string, req *http.Request, resp *http.Response) error {
+	stored := *resp
+	stored.Header = resp.Header.Clone()
+	stored.Header.Del(CacheHeader)
+	stored.Header.Set(storedHeader, strconv.FormatInt(now().Unix(), 10))
+
+	for _, name := range varyNames(resp) {
+		stored.Header.Set(varyHeaderPrefix+name, req.Header.Get(name))
+	}
+
+	// body is already decoded by the transport
+	stored.TransferEncoding = nil
+	stored.Header.Del("Transfer-Encoding")
+
+	dump, err := httputil.DumpResponse(&stored, true)
+	if err != nil {
+		return err
+	}
+
+	return filesystem.WriteFileAtomic(path, dump, os.ModePerm)
+}
+
+// load reads the stored response for the request.
+// Returns nil if there is no response or it varies by the headers that don't match
+func (t *cacheTransport) load(path string, req *http.Request) (*http.Response, error) {
+	data, err := filesystem.Api().ReadFile(path)
+	if err != nil {
+		return nil, nil
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), req)
+	if err != nil {
+		_ = filesystem.Api().Remove(path)
+		return nil, err
+	}
+
+	for _, name := range varyNames(resp) {
+		if resp.Header.Get(varyHeaderPrefix+name) != req.Header.Get(name) {
+			_ = resp.Body.Close()
+			return nil, nil
+		}
+	}
+
+	return resp, nil
+}
+
+// cacheKey is the file name of the cached response
+func cacheKey(req *http.Request) string {
+	sum := sha256.Sum256([]byte(req.Method + " " + req.URL.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+// cacheable is true if the response for the request can be taken from the cache
+func cacheable(req *http.Request) bool {
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return false
+	}
+
+	_, noStore := cacheControl(req.Header)["no-store"]
+	return !noStore
+}
+
+// storable is true if the response can be stored
+func storable(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusOK {
+		return false
+	}
+
+	if _, ok := cacheControl(resp.Header)["no-store"]; ok {
+		return false
+	}
+
+	if vary := resp.Header.Get("Vary"); strings.TrimSpace(vary) == "*" {
+		return false
+	}
+
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "image/") || resp.ContentLength > maxStoredSize {
+		return false
+	}
+
+	// without validators the response is useful only while it's fresh
+	if resp.Header.Get("ETag") == "" && resp.Header.Get("Last-Modified") == "" {
+		return lifetime(resp) > 0
+	}
+
+	return true
+}
+
+// fresh is true if the stored response can be used without revalidation
+func fresh(req *http.Request, resp *http.Response) bool {
+	reqDirectives := cacheControl(req.Header)
+	if _, ok := reqDirectives["no-cache"]; ok {
+		return false
+	}
+
+	respDirectives := cacheControl(resp.Header)
+	if _, ok := respDirectives["no-cache"]; ok {
+		return false
+	}
+
+	maxAge := lifetime(resp)
+	if value, ok := reqDirectives["max-age"]; ok {
+		if seconds, err := strconv.Atoi(value); err == nil && time.Duration(seconds)*time.Second < maxAge {
+			maxAge = time.Duration(seconds) * time.Second
+		}
+	}
+
+	return age(resp) < maxAge
+}
+
+// lifetime is the freshness lifetime of the response.
+// It's taken from max-age or Expires, otherwise it's 10% of the time since Last-Modified
+func lifetime(resp *http.Response) time.Duration {
+	directives := cacheControl(resp.Header)
+
+	if value, ok := directives["max-age"]; ok {
+		seconds, err := strconv.Atoi(value)
+		if err != nil {
+			return 0
+		}
+
+		return time.Duration(seconds) * time.Second
+	}
+
+	date, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		date = storedTime(resp)
+	}
+
+	if expires := resp.Header.Get("Expires"); expires != "" {
+		t, err := http.ParseTime(expires)
+		if err != nil {
+			return 0
+		}
+
+		return t.Sub(date)
+	}
+
+	if lastModified, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		return date.Sub(lastModified) / 10
+	}
+
+	return 0
+}
+
+// age is the time since the response was generated or revalidated
+func age(resp *http.Response) time.Duration {
+	age := now().Sub(storedTime(resp))
+
+	if seconds, err := strconv.Atoi(resp.Header.Get("Age")); err == nil {
+		age += time.Duration(seconds) * time.Second
+	}
+
+	return age
+}
+
+// storedTime is the time the response was stored, now if it wasn't
+func storedTime(resp *http.Response) time.Time {
+	seconds, err := strconv.ParseInt(resp.Header.Get(storedHeader), 10, 64)
+	if err != nil {
+		return now()
+	}
+
+	return time.Unix(seconds, 0)
+}
+
+// varyNames are names of the request headers the response varies by
+func varyNames(resp *http.Response) []string {
+	var names []string
+
+	for _, value := range resp.Header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" && name != "*" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+
+	return names
+}
+
+// cacheControl parses Cache-Control directives, names are lower-cased
+func cacheControl(header http.Header) map[string]string {
+	directives := make(map[string]string)
+
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			if name == "" {
+				continue
+			}
+
+			directives[strings.ToLower(name)] = strings.Trim(value, `"`)
+		}
+	}
+
+	return directives
+}
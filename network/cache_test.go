@@ -0,0 +1,201 @@
+package network
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/metafates/mangal/filesystem"
+	"github.com/metafates/mangal/key"
+	"github.com/samber/lo"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/spf13/viper"
+)
+
+func TestCacheTransport(t *testing.T) {
+	filesystem.SetMemMapFs()
+
+	defer viper.Set(key.HTTPCache, viper.Get(key.HTTPCache))
+	viper.Set(key.HTTPCache, true)
+
+	var (
+		requests    int
+		conditional int
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		switch r.URL.Path {
+		case "/etag":
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.Header().Set("ETag", `"v1"`)
+
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				conditional++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		case "/no-store":
+			w.Header().Set("Cache-Control", "no-store")
+		case "/image":
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.Header().Set("Content-Type", "image/png")
+		case "/large":
+			w.Header().Set("Cache-Control", "max-age=60")
+			_, _ = w.Write(make([]byte, maxStoredSize+1))
+		case "/vary":
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.Header().Set("Vary", "Accept-Language")
+		}
+
+		_, _ = w.Write([]byte("body of " + r.URL.Path))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &cacheTransport{
+		base: http.DefaultTransport,
+		dir: func() string {
+			lo.Must0(filesystem.Api().MkdirAll("http", os.ModePerm))
+			return "http"
+		},
+	}}
+
+	reset := func() {
+		requests, conditional = 0, 0
+		lo.Must0(filesystem.Api().RemoveAll("http"))
+	}
+
+	get := func(path string, header ...string) (string, string) {
+		req := lo.Must(http.NewRequest(http.MethodGet, server.URL+path, nil))
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+
+		resp := lo.Must(client.Do(req))
+		defer resp.Body.Close()
+
+		return string(lo.Must(io.ReadAll(resp.Body))), resp.Header.Get(CacheHeader)
+	}
+
+	Convey("Given a response with max-age and etag", t, func() {
+		reset()
+		body, _ := get("/etag")
+		So(body, ShouldEqual, "body of /etag")
+
+		Convey("When it is requested again while fresh", func() {
+			body, status := get("/etag")
+
+			Convey("Then it should be served from the cache", func() {
+				So(body, ShouldEqual, "body of /etag")
+				So(status, ShouldEqual, "hit")
+				So(requests, ShouldEqual, 1)
+			})
+		})
+
+		Convey("When it is requested again after it became stale", func() {
+			defer func() { now = time.Now }()
+			now = func() time.Time { return time.Now().Add(time.Minute * 2) }
+
+			body, status := get("/etag")
+
+			Convey("Then it should be revalidated", func() {
+				So(body, ShouldEqual, "body of /etag")
+				So(status, ShouldEqual, "revalidated")
+				So(requests, ShouldEqual, 2)
+				So(conditional, ShouldEqual, 1)
+			})
+		})
+
+		Convey("When the request has no-cache", func() {
+			_, status := get("/etag", "Cache-Control", "no-cache")
+
+			Convey("Then it should be revalidated", func() {
+				So(status, ShouldEqual, "revalidated")
+				So(conditional, ShouldEqual, 1)
+			})
+		})
+	})
+
+	Convey("Given a response with no-store", t, func() {
+		reset()
+		get("/no-store")
+
+		Convey("When it is requested again", func() {
+			_, status := get("/no-store")
+
+			Convey("Then it should not be cached", func() {
+				So(status, ShouldBeEmpty)
+				So(requests, ShouldEqual, 2)
+			})
+		})
+	})
+
+	Convey("Given an image response", t, func() {
+		reset()
+		get("/image")
+
+		Convey("When it is requested again", func() {
+			_, status := get("/image")
+
+			Convey("Then it should not be cached", func() {
+				So(status, ShouldBeEmpty)
+				So(requests, ShouldEqual, 2)
+			})
+		})
+	})
+
+	Convey("Given a response larger than the limit", t, func() {
+		reset()
+		get("/large")
+
+		Convey("When it is requested again", func() {
+			_, status := get("/large")
+
+			Convey("Then it should not be cached", func() {
+				So(status, ShouldBeEmpty)
+				So(requests, ShouldEqual, 2)
+			})
+		})
+	})
+
+	Convey("Given a response that varies by language", t, func() {
+		reset()
+		get("/vary", "Accept-Language", "en")
+
+		Convey("When it is requested with the same language", func() {
+			_, status := get("/vary", "Accept-Language", "en")
+
+			Convey("Then it should be served from the cache", func() {
+				So(status, ShouldEqual, "hit")
+				So(requests, ShouldEqual, 1)
+			})
+		})
+
+		Convey("When it is requested with another language", func() {
+			_, status := get("/vary", "Accept-Language", "fr")
+
+			Convey("Then it should be requested", func() {
+				So(status, ShouldBeEmpty)
+				So(requests, ShouldEqual, 2)
+			})
+		})
+	})
+
+	Convey("Given the cache is disabled", t, func() {
+		viper.Set(key.HTTPCache, false)
+		defer viper.Set(key.HTTPCache, true)
+
+		reset()
+		get("/etag")
+		_, status := get("/etag")
+
+		Convey("Then responses should not be cached", func() {
+			So(status, ShouldBeEmpty)
+			So(requests, ShouldEqual, 2)
+		})
+	})
+}
@@ -5,18 +5,28 @@ import (
 	"time"
 )
 
-var transport = http.DefaultTransport.(*http.Transport).Clone()
+var transport = &configuredTransport{
+	base: http.DefaultTransport.(*http.Transport).Clone(),
+}
 
 func init() {
-	transport.MaxIdleConns = 100
-	transport.MaxIdleConnsPerHost = 100
-	transport.MaxConnsPerHost = 200
-	transport.IdleConnTimeout = 30 * time.Second
-	transport.ResponseHeaderTimeout = 30 * time.Second
-	transport.ExpectContinueTimeout = 30 * time.Second
+	transport.base.MaxIdleConns = 100
+	transport.base.MaxIdleConnsPerHost = 100
+	transport.base.MaxConnsPerHost = 200
+	transport.base.IdleConnTimeout = 30 * time.Second
+	transport.base.ResponseHeaderTimeout = 30 * time.Second
+	transport.base.ExpectContinueTimeout = 30 * time.Second
+
+	// clients of the libraries that mangal uses can not be configured,
+	// so the default transport is replaced to apply the http options to them too
+	http.DefaultTransport = transport
 }
 
+// Transport is the transport of the Client with the http options and cache.
+// It can be shared by other clients
+var Transport = WithCache(transport)
+
 var Client = &http.Client{
 	Timeout:   time.Minute,
-	Transport: transport,
+	Transport: Transport,
 }
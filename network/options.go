@@ -0,0 +1,244 @@
+package network
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/metafates/mangal/constant"
+	"github.com/metafates/mangal/filesystem"
+	"github.com/metafates/mangal/key"
+	"github.com/spf13/viper"
+)
+
+// proxyDirect is the proxy rule value to connect without a proxy
+const proxyDirect = "direct"
+
+// DefaultUserAgents are replaced with the configured user agent.
+// Packages that send their own default user agent add it here
+var DefaultUserAgents = []string{"", constant.UserAgent}
+
+// configuredTransport applies the http options and the rate limit to the requests.
+// The transport is created on the first request, so that the config is loaded by then
+type configuredTransport struct {
+	base *http.Transport
+	// overrides are applied on top of the configured options
+	overrides Overrides
+
+	once      sync.Once
+	transport *http.Transport
+	err       error
+}
+
+func (t *configuredTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.once.Do(func() {
+		if t.transport, t.err = newTransport(t.base); t.err == nil {
+			t.overrides.apply(t.transport)
+		}
+	})
+
+	if t.err != nil {
+		return nil, t.err
+	}
+
+	b, err := rateLimiter.wait(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.transport.RoundTrip(withHeaders(req))
+	if err != nil {
+		return nil, err
+	}
+
+	rateLimiter.feedback(b, req, resp)
+	return resp, nil
+}
+
+// Overrides are the options of a client that take precedence over the configured ones
+type Overrides struct {
+	// Proxy is used instead of the configured proxy and rules, if it's set
+	Proxy func(*http.Request) (*url.URL, error)
+	// InsecureSkipVerify disables verification of certificates
+	InsecureSkipVerify bool
+}
+
+// IsZero is true if nothing is overridden
+func (o Overrides) IsZero() bool {
+	return o.Proxy == nil && !o.InsecureSkipVerify
+}
+
+func (o Overrides) apply(transport *http.Transport) {
+	if o.Proxy != nil {
+		transport.Proxy = o.Proxy
+	}
+
+	if o.InsecureSkipVerify {
+		transport.TLSClientConfig.InsecureSkipVerify = true
+	}
+}
+
+// WithOverrides returns the transport of the Client with some options overridden.
+// Rate limit, headers, cache and the rest of the http options are applied as usual
+func WithOverrides(overrides Overrides) http.RoundTripper {
+	if overrides.IsZero() {
+		return Transport
+	}
+
+	return WithCache(&configuredTransport{base: transport.base, overrides: overrides})
+}
+
+// newTransport clones the base transport and sets the proxy and tls options
+func newTransport(base *http.Transport) (*http.Transport, error) {
+	transport := base.Clone()
+
+	proxy, err := newProxy(viper.GetString(key.HTTPProxy), viper.GetStringSlice(key.HTTPProxyRules))
+	if err != nil {
+		return nil, err
+	}
+
+	transport.Proxy = proxy
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: viper.GetBool(key.HTTPInsecureSkipVerify),
+	}
+
+	if bundle := viper.GetString(key.HTTPCABundle); bundle != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		pem, err := filesystem.Api().ReadFile(bundle)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key.HTTPCABundle, err)
+		}
+
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s: no certificates found in %s", key.HTTPCABundle, bundle)
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
+
+// proxyRule routes hosts that match the pattern through the proxy
+type proxyRule struct {
+	pattern string
+	// proxy is nil for direct connections
+	proxy *url.URL
+}
+
+// newProxy creates the proxy function of the transport.
+// Rules are "host=proxy" where host is a glob pattern such as *.example.com
+// and proxy is the url or "direct". The first matching rule is used,
+// then the proxy url if it's set, then the environment (HTTP_PROXY, HTTPS_PROXY, NO_PROXY)
+func newProxy(proxy string, rules []string) (func(*http.Request) (*url.URL, error), error) {
+	parse := func(name, value string) (*url.URL, error) {
+		if value == proxyDirect {
+			return nil, nil
+		}
+
+		u, err := url.Parse(value)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("%s: invalid proxy %q, expected url such as socks5://localhost:1080", name, value)
+		}
+
+		switch u.Scheme {
+		case "http", "https", "socks5", "socks5h":
+			return u, nil
+		default:
+			return nil, fmt.Errorf("%s: unsupported proxy scheme %q, use http, https or socks5", name, u.Scheme)
+		}
+	}
+
+	parsedRules := make([]proxyRule, len(rules))
+	for i, rule := range rules {
+		pattern, value, ok := strings.Cut(rule, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s: invalid rule %q, expected host=proxy", key.HTTPProxyRules, rule)
+		}
+
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("%s: invalid host pattern %q", key.HTTPProxyRules, pattern)
+		}
+
+		u, err := parse(key.HTTPProxyRules, strings.TrimSpace(value))
+		if err != nil {
+			return nil, err
+		}
+
+		parsedRules[i] = proxyRule{pattern: pattern, proxy: u}
+	}
+
+	var fallback *url.URL
+	if proxy != "" {
+		var err error
+		if fallback, err = parse(key.HTTPProxy, proxy); err != nil {
+			return nil, err
+		}
+	}
+
+	return func(req *http.Request) (*url.URL, error) {
+		host := strings.ToLower(req.URL.Hostname())
+
+		for _, rule := range parsedRules {
+			if matched, _ := path.Match(rule.pattern, host); matched {
+				return rule.proxy, nil
+			}
+		}
+
+		if proxy != "" {
+			return fallback, nil
+		}
+
+		return http.ProxyFromEnvironment(req)
+	}, nil
+}
+
+// withHeaders returns the request with the configured user agent and headers.
+// Headers that are already set by the request are kept, except for the default user agent
+func withHeaders(req *http.Request) *http.Request {
+	var (
+		headers   = viper.GetStringSlice(key.HTTPHeaders)
+		userAgent = viper.GetString(key.HTTPUserAgent)
+	)
+
+	if len(headers) == 0 && userAgent == "" {
+		return req
+	}
+
+	req = req.Clone(req.Context())
+
+	if userAgent != "" {
+		for _, ua := range DefaultUserAgents {
+			if req.Header.Get("User-Agent") == ua {
+				req.Header.Set("User-Agent", userAgent)
+				break
+			}
+		}
+	}
+
+	for _, header := range headers {
+		name, value, ok := strings.Cut(header, ":")
+		if !ok {
+			continue
+		}
+
+		name = strings.TrimSpace(name)
+		if req.Header.Get(name) == "" {
+			req.Header.Set(name, strings.TrimSpace(value))
+		}
+	}
+
+	return req
+}
@@ -0,0 +1,174 @@
+package network
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/metafates/mangal/constant"
+	"github.com/metafates/mangal/filesystem"
+	"github.com/metafates/mangal/key"
+	"github.com/samber/lo"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/spf13/viper"
+)
+
+func TestProxy(t *testing.T) {
+	Convey("Given proxy rules", t, func() {
+		proxy, err := newProxy("http://localhost:8080", []string{
+			"*.example.com = socks5://localhost:1080",
+			"example.org=direct",
+		})
+		So(err, ShouldBeNil)
+
+		resolve := func(address string) string {
+			u, err := proxy(lo.Must(http.NewRequest(http.MethodGet, address, nil)))
+			So(err, ShouldBeNil)
+
+			if u == nil {
+				return ""
+			}
+
+			return u.String()
+		}
+
+		Convey("Then matching hosts should use the proxy of the rule", func() {
+			So(resolve("https://cdn.example.com/image.png"), ShouldEqual, "socks5://localhost:1080")
+			So(resolve("https://example.org"), ShouldBeEmpty)
+		})
+
+		Convey("Then other hosts should use the proxy", func() {
+			So(resolve("https://mangadex.org"), ShouldEqual, "http://localhost:8080")
+		})
+	})
+
+	Convey("Given invalid proxies", t, func() {
+		for _, c := range []struct {
+			name  string
+			proxy string
+			rules []string
+		}{
+			{"unsupported scheme", "ftp://localhost", nil},
+			{"no scheme", "localhost", nil},
+			{"rule without proxy", "", []string{"example.com"}},
+			{"invalid pattern", "", []string{"[=http://localhost"}},
+		} {
+			_, err := newProxy(c.proxy, c.rules)
+
+			Convey("Then "+c.name+" should fail", func() {
+				So(err, ShouldNotBeNil)
+			})
+		}
+	})
+}
+
+func TestWithHeaders(t *testing.T) {
+	Convey("Given the user agent and headers are configured", t, func() {
+		for _, k := range []string{key.HTTPUserAgent, key.HTTPHeaders} {
+			defer viper.Set(k, viper.Get(k))
+		}
+
+		viper.Set(key.HTTPUserAgent, "mangal-test")
+		viper.Set(key.HTTPHeaders, []string{"Accept-Language: fr", "Referer: https://example.com"})
+
+		Convey("When the request uses defaults", func() {
+			req := lo.Must(http.NewRequest(http.MethodGet, "https://example.com", nil))
+			req.Header.Set("User-Agent", constant.UserAgent)
+			req.Header.Set("Referer", "https://source.com")
+
+			configured := withHeaders(req)
+
+			Convey("Then the default user agent should be replaced", func() {
+				So(configured.Header.Get("User-Agent"), ShouldEqual, "mangal-test")
+			})
+
+			Convey("Then missing headers should be added and existing ones kept", func() {
+				So(configured.Header.Get("Accept-Language"), ShouldEqual, "fr")
+				So(configured.Header.Get("Referer"), ShouldEqual, "https://source.com")
+			})
+
+			Convey("Then the original request should not be changed", func() {
+				So(req.Header.Get("User-Agent"), ShouldEqual, constant.UserAgent)
+			})
+		})
+
+		Convey("When the request has its own user agent", func() {
+			req := lo.Must(http.NewRequest(http.MethodGet, "https://example.com", nil))
+			req.Header.Set("User-Agent", "source")
+
+			Convey("Then it should be kept", func() {
+				So(withHeaders(req).Header.Get("User-Agent"), ShouldEqual, "source")
+			})
+		})
+	})
+}
+
+func TestNewTransport(t *testing.T) {
+	filesystem.SetMemMapFs()
+
+	Convey("Given the ca bundle without certificates", t, func() {
+		defer viper.Set(key.HTTPCABundle, viper.Get(key.HTTPCABundle))
+
+		lo.Must0(filesystem.Api().WriteFile("ca.pem", []byte("not a certificate"), 0644))
+		viper.Set(key.HTTPCABundle, "ca.pem")
+
+		Convey("When the transport is created", func() {
+			_, err := newTransport(http.DefaultTransport.(*configuredTransport).base)
+
+			Convey("Then it should fail", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+
+	Convey("Given insecure skip verify", t, func() {
+		defer viper.Set(key.HTTPInsecureSkipVerify, viper.Get(key.HTTPInsecureSkipVerify))
+		viper.Set(key.HTTPInsecureSkipVerify, true)
+
+		Convey("When the transport is created", func() {
+			transport, err := newTransport(http.DefaultTransport.(*configuredTransport).base)
+
+			Convey("Then it should not verify certificates", func() {
+				So(err, ShouldBeNil)
+				So(transport.TLSClientConfig.InsecureSkipVerify, ShouldBeTrue)
+			})
+		})
+	})
+}
+
+func TestWithOverrides(t *testing.T) {
+	Convey("Given a proxy of the client and the configured one", t, func() {
+		for _, k := range []string{key.HTTPProxy, key.HTTPUserAgent} {
+			defer viper.Set(k, viper.Get(k))
+		}
+
+		viper.Set(key.HTTPProxy, "http://127.0.0.1:1")
+		viper.Set(key.HTTPUserAgent, "mangal-test")
+
+		var userAgent string
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userAgent = r.Header.Get("User-Agent")
+			_, _ = w.Write([]byte("proxied"))
+		}))
+		defer proxy.Close()
+
+		transport := WithOverrides(Overrides{Proxy: http.ProxyURL(lo.Must(url.Parse(proxy.URL)))})
+
+		Convey("When a request is made", func() {
+			resp, err := transport.RoundTrip(lo.Must(http.NewRequest(http.MethodGet, "http://mangal.invalid/overrides", nil)))
+
+			Convey("Then it should go through the proxy of the client with the configured headers", func() {
+				So(err, ShouldBeNil)
+				So(resp.StatusCode, ShouldEqual, http.StatusOK)
+				So(userAgent, ShouldEqual, "mangal-test")
+			})
+		})
+	})
+
+	Convey("Given no overrides", t, func() {
+		Convey("Then the shared transport should be used", func() {
+			So(WithOverrides(Overrides{}), ShouldEqual, Transport)
+		})
+	})
+}
@@ -0,0 +1,254 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/metafates/mangal/key"
+	"github.com/metafates/mangal/log"
+	"github.com/metafates/mangal/util"
+	"github.com/spf13/viper"
+)
+
+// maxRetryAfter caps Retry-After, so that a misbehaving server can not stall downloads for hours
+const maxRetryAfter = 10 * time.Minute
+
+type sourceKey struct{}
+
+// WithSource returns the context that tells the rate limiter which source makes the requests,
+// so that the source overrides are applied
+func WithSource(ctx context.Context, source string) context.Context {
+	return context.WithValue(ctx, sourceKey{}, source)
+}
+
+func sourceFrom(ctx context.Context) string {
+	source, _ := ctx.Value(sourceKey{}).(string)
+	return source
+}
+
+// rateLimit is the number of requests per minute and the burst
+type rateLimit struct {
+	// name of the host pattern or source the limit is for
+	name      string
+	perMinute int
+	burst     int
+}
+
+// parseRateLimits parses "name=requests" or "name=requests/burst" overrides in order.
+// Names are lower-cased
+func parseRateLimits(field string, overrides []string, burst int) ([]rateLimit, error) {
+	limits := make([]rateLimit, 0, len(overrides))
+
+	for _, override := range overrides {
+		name, value, ok := strings.Cut(override, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s: invalid override %q, expected name=requests or name=requests/burst", field, override)
+		}
+
+		limit := rateLimit{name: strings.ToLower(strings.TrimSpace(name)), burst: burst}
+		perMinute, burstValue, hasBurst := strings.Cut(strings.TrimSpace(value), "/")
+
+		var err error
+		if limit.perMinute, err = strconv.Atoi(perMinute); err != nil || limit.perMinute < 0 {
+			return nil, fmt.Errorf("%s: invalid number of requests in %q", field, override)
+		}
+
+		if hasBurst {
+			if limit.burst, err = strconv.Atoi(burstValue); err != nil || limit.burst < 1 {
+				return nil, fmt.Errorf("%s: invalid burst in %q", field, override)
+			}
+		}
+
+		limits = append(limits, limit)
+	}
+
+	return limits, nil
+}
+
+// bucket is a token bucket implemented as generic cell rate algorithm.
+// It keeps the theoretical arrival time of the next request instead of the number of tokens
+type bucket struct {
+	mutex sync.Mutex
+	limit rateLimit
+	// tat is the theoretical arrival time
+	tat time.Time
+}
+
+func (b *bucket) interval() time.Duration {
+	return time.Minute / time.Duration(b.limit.perMinute)
+}
+
+// reserve takes a token and returns how long to wait before the request
+func (b *bucket) reserve(now time.Time) time.Duration {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	interval := b.interval()
+	tolerance := time.Duration(b.limit.burst-1) * interval
+
+	if b.tat.Before(now) {
+		b.tat = now
+	}
+
+	wait := b.tat.Add(-tolerance).Sub(now)
+	b.tat = b.tat.Add(interval)
+
+	if wait < 0 {
+		return 0
+	}
+
+	return wait
+}
+
+// block makes the requests wait until the time
+func (b *bucket) block(until time.Time) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	tolerance := time.Duration(b.limit.burst-1) * b.interval()
+	if tat := until.Add(tolerance); tat.After(b.tat) {
+		b.tat = tat
+	}
+}
+
+// limiter keeps buckets per host.
+// Hosts requested by the source with its own limit have separate buckets
+type limiter struct {
+	mutex   sync.Mutex
+	buckets map[string]*bucket
+}
+
+var rateLimiter = &limiter{buckets: make(map[string]*bucket)}
+
+// bucket returns the bucket for the request or nil if requests are not limited
+func (l *limiter) bucket(req *http.Request) (*bucket, error) {
+	if !viper.GetBool(key.RateLimitEnabled) {
+		return nil, nil
+	}
+
+	var (
+		host   = strings.ToLower(req.URL.Hostname())
+		source = strings.ToLower(sourceFrom(req.Context()))
+		name   = host
+		limit  = rateLimit{
+			perMinute: viper.GetInt(key.RateLimitRequestsPerMinute),
+			burst:     util.Max(viper.GetInt(key.RateLimitBurst), 1),
+		}
+	)
+
+	hosts, err := parseRateLimits(key.RateLimitHosts, viper.GetStringSlice(key.RateLimitHosts), limit.burst)
+	if err != nil {
+		return nil, err
+	}
+
+	sources, err := parseRateLimits(key.RateLimitSources, viper.GetStringSlice(key.RateLimitSources), limit.burst)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, hostLimit := range hosts {
+		if matched, _ := path.Match(hostLimit.name, host); matched {
+			limit = hostLimit
+			break
+		}
+	}
+
+	for _, sourceLimit := range sources {
+		if source != "" && sourceLimit.name == source {
+			limit = sourceLimit
+			name = source + " " + host
+			break
+		}
+	}
+
+	// zero means no limit
+	if limit.perMinute == 0 {
+		return nil, nil
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	b, ok := l.buckets[name]
+	if !ok {
+		b = &bucket{}
+		l.buckets[name] = b
+	}
+
+	b.mutex.Lock()
+	b.limit = limit
+	b.mutex.Unlock()
+
+	return b, nil
+}
+
+// wait blocks until the request is allowed by its bucket or the context is done
+func (l *limiter) wait(req *http.Request) (*bucket, error) {
+	b, err := l.bucket(req)
+	if err != nil || b == nil {
+		return nil, err
+	}
+
+	delay := b.reserve(now())
+	if delay == 0 {
+		return b, nil
+	}
+
+	log.Tracef("Rate limit of %s, waiting %s", req.URL.Host, delay)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	case <-timer.C:
+		return b, nil
+	}
+}
+
+// feedback blocks the bucket if the server asks to slow down with Retry-After
+func (l *limiter) feedback(b *bucket, req *http.Request, resp *http.Response) {
+	if b == nil {
+		return
+	}
+
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return
+	}
+
+	delay, ok := retryAfter(resp.Header.Get("Retry-After"))
+	if !ok {
+		return
+	}
+
+	if delay > maxRetryAfter {
+		delay = maxRetryAfter
+	}
+
+	log.Warnf("%s asked to retry after %s", req.URL.Host, delay)
+	b.block(now().Add(delay))
+}
+
+// retryAfter parses Retry-After as seconds or http date
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+		return time.Duration(seconds) * time.Second, seconds >= 0
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		return t.Sub(now()), true
+	}
+
+	return 0, false
+}
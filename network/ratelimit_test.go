@@ -0,0 +1,177 @@
+package network
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/metafates/mangal/key"
+	"github.com/samber/lo"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/spf13/viper"
+)
+
+func TestBucket(t *testing.T) {
+	Convey("Given a bucket of 60 requests per minute with burst of 3", t, func() {
+		var (
+			start = time.Now()
+			b     = &bucket{limit: rateLimit{perMinute: 60, burst: 3}}
+		)
+
+		Convey("When the burst is taken", func() {
+			waits := []time.Duration{b.reserve(start), b.reserve(start), b.reserve(start)}
+
+			Convey("Then requests should not wait", func() {
+				So(waits, ShouldResemble, []time.Duration{0, 0, 0})
+			})
+
+			Convey("Then the next request should wait for the interval", func() {
+				So(b.reserve(start), ShouldEqual, time.Second)
+			})
+
+			Convey("Then the token should be refilled after the interval", func() {
+				So(b.reserve(start.Add(time.Second)), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When the bucket is blocked", func() {
+			b.block(start.Add(time.Minute))
+
+			Convey("Then requests should wait until it's unblocked", func() {
+				So(b.reserve(start), ShouldEqual, time.Minute)
+			})
+
+			Convey("Then requests should not wait after it's unblocked", func() {
+				So(b.reserve(start.Add(time.Minute)), ShouldEqual, 0)
+			})
+		})
+	})
+}
+
+func TestParseRateLimits(t *testing.T) {
+	Convey("Given valid overrides", t, func() {
+		limits, err := parseRateLimits(key.RateLimitHosts, []string{"*.Example.com = 30", "example.org=0/5"}, 10)
+
+		Convey("Then they should be parsed in order", func() {
+			So(err, ShouldBeNil)
+			So(limits, ShouldResemble, []rateLimit{
+				{name: "*.example.com", perMinute: 30, burst: 10},
+				{name: "example.org", perMinute: 0, burst: 5},
+			})
+		})
+	})
+
+	Convey("Given invalid overrides", t, func() {
+		for _, override := range []string{"example.com", "example.com=fast", "example.com=-1", "example.com=30/0"} {
+			_, err := parseRateLimits(key.RateLimitHosts, []string{override}, 10)
+
+			Convey("Then "+override+" should fail", func() {
+				So(err, ShouldNotBeNil)
+			})
+		}
+	})
+}
+
+func TestLimiter(t *testing.T) {
+	Convey("Given host and source overrides", t, func() {
+		for _, k := range []string{key.RateLimitEnabled, key.RateLimitRequestsPerMinute, key.RateLimitHosts, key.RateLimitSources} {
+			defer viper.Set(k, viper.Get(k))
+		}
+
+		viper.Set(key.RateLimitEnabled, true)
+		viper.Set(key.RateLimitRequestsPerMinute, 120)
+		viper.Set(key.RateLimitHosts, []string{"*.example.com=30", "example.org=0"})
+		viper.Set(key.RateLimitSources, []string{"Slow=6/1"})
+
+		l := &limiter{buckets: make(map[string]*bucket)}
+
+		limit := func(ctx context.Context, address string) *bucket {
+			b, err := l.bucket(lo.Must(http.NewRequestWithContext(ctx, http.MethodGet, address, nil)))
+			So(err, ShouldBeNil)
+			return b
+		}
+
+		Convey("Then other hosts should use the default limit", func() {
+			So(limit(context.Background(), "https://mangadex.org").limit.perMinute, ShouldEqual, 120)
+		})
+
+		Convey("Then matching hosts should use their limit", func() {
+			So(limit(context.Background(), "https://cdn.example.com/1.png").limit.perMinute, ShouldEqual, 30)
+		})
+
+		Convey("Then hosts with zero limit should not be limited", func() {
+			So(limit(context.Background(), "https://example.org"), ShouldBeNil)
+		})
+
+		Convey("Then the source should have its own bucket", func() {
+			b := limit(WithSource(context.Background(), "slow"), "https://cdn.example.com/1.png")
+			So(b.limit, ShouldResemble, rateLimit{name: "slow", perMinute: 6, burst: 1})
+			So(b, ShouldNotEqual, limit(context.Background(), "https://cdn.example.com/1.png"))
+		})
+
+		Convey("Then the same host should share the bucket across sources", func() {
+			So(limit(WithSource(context.Background(), "fast"), "https://mangadex.org"), ShouldEqual, limit(context.Background(), "https://mangadex.org"))
+		})
+
+		Convey("When it's disabled", func() {
+			viper.Set(key.RateLimitEnabled, false)
+
+			Convey("Then requests should not be limited", func() {
+				So(limit(context.Background(), "https://mangadex.org"), ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestRetryAfter(t *testing.T) {
+	Convey("Given Retry-After in seconds", t, func() {
+		delay, ok := retryAfter("120")
+
+		Convey("Then it should be parsed", func() {
+			So(ok, ShouldBeTrue)
+			So(delay, ShouldEqual, 2*time.Minute)
+		})
+	})
+
+	Convey("Given Retry-After as http date", t, func() {
+		defer func() { now = time.Now }()
+
+		start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+		now = func() time.Time { return start }
+
+		delay, ok := retryAfter(start.Add(time.Minute).Format(http.TimeFormat))
+
+		Convey("Then it should be parsed", func() {
+			So(ok, ShouldBeTrue)
+			So(delay, ShouldEqual, time.Minute)
+		})
+	})
+
+	Convey("Given invalid Retry-After", t, func() {
+		_, ok := retryAfter("soon")
+
+		Convey("Then it should be ignored", func() {
+			So(ok, ShouldBeFalse)
+		})
+	})
+
+	Convey("Given the server responds with 429", t, func() {
+		defer func() { now = time.Now }()
+
+		start := time.Now()
+		now = func() time.Time { return start }
+
+		var (
+			b    = &bucket{limit: rateLimit{perMinute: 60, burst: 1}}
+			req  = lo.Must(http.NewRequest(http.MethodGet, "https://example.com", nil))
+			resp = &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": {"3600"}}}
+		)
+
+		rateLimiter.feedback(b, req, resp)
+
+		Convey("Then the bucket should be blocked no longer than the cap", func() {
+			So(b.reserve(start), ShouldEqual, maxRetryAfter)
+		})
+	})
+}
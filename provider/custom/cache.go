@@ -11,6 +11,7 @@ import (
 	"github.com/samber/mo"
 )
 
+// cacher caches values on the disk. A nil cacher caches nothing
 type cacher[T any] struct {
 	internal *gache.Cache[map[string]T]
 }
@@ -26,6 +27,10 @@ func newCacher[T any](name string) *cacher[T] {
 }
 
 func (c *cacher[T]) Get(key string) mo.Option[T] {
+	if c == nil {
+		return mo.None[T]()
+	}
+
 	data, expired, err := c.internal.Get()
 	if err != nil || expired || data == nil {
 		return mo.None[T]()
@@ -39,6 +44,10 @@ func (c *cacher[T]) Get(key string) mo.Option[T] {
 }
 
 func (c *cacher[T]) Set(key string, t T) error {
+	if c == nil {
+		return nil
+	}
+
 	data, expired, err := c.internal.Get()
 
 	if err != nil {
@@ -1,13 +1,20 @@
 package custom
 
 import (
+	"context"
 	"github.com/metafates/mangal/constant"
 	"github.com/metafates/mangal/source"
 	lua "github.com/yuin/gopher-lua"
-	"strconv"
 )
 
 func (s *luaSource) ChaptersOf(manga *source.Manga) ([]*source.Chapter, error) {
+	return s.ChaptersOfContext(context.Background(), manga)
+}
+
+func (s *luaSource) ChaptersOfContext(ctx context.Context, manga *source.Manga) ([]*source.Chapter, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
 	if chapters := s.cache.chapters.Get(manga.URL); chapters.IsPresent() {
 		c := chapters.MustGet()
 		for _, chapter := range c {
@@ -17,7 +24,7 @@ func (s *luaSource) ChaptersOf(manga *source.Manga) ([]*source.Chapter, error) {
 		return c, nil
 	}
 
-	_, err := s.call(constant.MangaChaptersFn, lua.LTTable, lua.LString(manga.URL))
+	_, err := s.call(ctx, constant.MangaChaptersFn, lua.LTTable, lua.LString(manga.URL))
 
 	if err != nil {
 		return nil, err
@@ -26,29 +33,20 @@ func (s *luaSource) ChaptersOf(manga *source.Manga) ([]*source.Chapter, error) {
 	table := s.state.CheckTable(-1)
 	chapters := make([]*source.Chapter, 0)
 
-	table.ForEach(func(k lua.LValue, v lua.LValue) {
-		if k.Type() != lua.LTNumber {
-			s.state.RaiseError(constant.MangaChaptersFn + " was expected to return a table with numbers as keys, got " + k.Type().String() + " as a key")
-		}
-
-		if v.Type() != lua.LTTable {
-			s.state.RaiseError(constant.MangaChaptersFn + " was expected to return a table with tables as values, got " + v.Type().String() + " as a value")
-		}
-
-		index, err := strconv.ParseUint(k.String(), 10, 16)
+	err = forEachResult(constant.MangaChaptersFn, table, func(index uint16, value *lua.LTable) error {
+		chapter, err := chapterFromTable(value, manga, index)
 		if err != nil {
-			s.state.RaiseError(constant.MangaChaptersFn + " was expected to return a table with unsigned integers as keys. " + err.Error())
-		}
-
-		chapter, err := chapterFromTable(v.(*lua.LTable), manga, uint16(index))
-
-		if err != nil {
-			s.state.RaiseError(err.Error())
+			return err
 		}
 
 		chapters = append(chapters, chapter)
+		return nil
 	})
 
+	if err != nil {
+		return nil, err
+	}
+
 	_ = s.cache.chapters.Set(manga.URL, chapters)
 	return chapters, nil
 }
@@ -0,0 +1,229 @@
+package custom
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/metafates/mangal/filesystem"
+)
+
+// FixturesPath returns the default path of the fixtures file of the source
+func FixturesPath(sourcePath string) string {
+	return strings.TrimSuffix(sourcePath, ".lua") + ".fixtures.json"
+}
+
+// Fixture is a recorded http exchange
+type Fixture struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	RequestBody string      `json:"request_body,omitempty"`
+	Status      int         `json:"status"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        string      `json:"body"`
+	// Base64 is true if the body is not valid utf-8 and is encoded
+	Base64 bool `json:"base64,omitempty"`
+}
+
+func (f *Fixture) matches(method, url, body string) bool {
+	return f.Method == method && f.URL == url && f.RequestBody == body
+}
+
+func (f *Fixture) response(req *http.Request) (*http.Response, error) {
+	body := []byte(f.Body)
+
+	if f.Base64 {
+		var err error
+		body, err = base64.StdEncoding.DecodeString(f.Body)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", f.Status, http.StatusText(f.Status)),
+		StatusCode:    f.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        f.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// Fixtures is a list of recorded http exchanges of the source
+type Fixtures struct {
+	Exchanges []*Fixture `json:"exchanges"`
+
+	mutex sync.Mutex
+	// used tracks how many times each exchange was replayed
+	used map[*Fixture]int
+}
+
+// ReadFixtures reads fixtures from the file
+func ReadFixtures(path string) (*Fixtures, error) {
+	data, err := filesystem.Api().ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var fixtures Fixtures
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		return nil, fmt.Errorf("invalid fixtures %s: %w", path, err)
+	}
+
+	return &fixtures, nil
+}
+
+// Write saves fixtures to the file
+func (f *Fixtures) Write(path string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return filesystem.Api().WriteFile(path, data, 0644)
+}
+
+// Recorder returns the transport that makes real requests with base and records them
+func (f *Fixtures) Recorder(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &recorder{base: base, fixtures: f}
+}
+
+// Replayer returns the transport that responds with recorded exchanges without network.
+// Requests that were not recorded fail
+func (f *Fixtures) Replayer() http.RoundTripper {
+	return &replayer{fixtures: f}
+}
+
+func (f *Fixtures) add(fixture *Fixture) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.Exchanges = append(f.Exchanges, fixture)
+}
+
+// find returns the exchange for the request.
+// Same requests are replayed in the order they were recorded, the last one is repeated
+func (f *Fixtures) find(method, url, body string) (*Fixture, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.used == nil {
+		f.used = make(map[*Fixture]int)
+	}
+
+	var last *Fixture
+	for _, fixture := range f.Exchanges {
+		if !fixture.matches(method, url, body) {
+			continue
+		}
+
+		if f.used[fixture] == 0 {
+			f.used[fixture]++
+			return fixture, true
+		}
+
+		last = fixture
+	}
+
+	if last != nil {
+		f.used[last]++
+		return last, true
+	}
+
+	return nil, false
+}
+
+type recorder struct {
+	base     http.RoundTripper
+	fixtures *Fixtures
+}
+
+func (r *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := r.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	fixture := &Fixture{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		RequestBody: reqBody,
+		Status:      resp.StatusCode,
+		Header:      resp.Header.Clone(),
+	}
+
+	if utf8.Valid(body) {
+		fixture.Body = string(body)
+	} else {
+		fixture.Body = base64.StdEncoding.EncodeToString(body)
+		fixture.Base64 = true
+	}
+
+	r.fixtures.add(fixture)
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+type replayer struct {
+	fixtures *Fixtures
+}
+
+func (r *replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	fixture, ok := r.fixtures.find(req.Method, req.URL.String(), body)
+	if !ok {
+		return nil, fmt.Errorf("no fixture for %s %s, record fixtures again", req.Method, req.URL)
+	}
+
+	return fixture.response(req)
+}
+
+// readRequestBody reads the body of the request and replaces it so that it can be sent
+func readRequestBody(req *http.Request) (string, error) {
+	if req.Body == nil {
+		return "", nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return "", err
+	}
+
+	_ = req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return string(body), nil
+}
@@ -0,0 +1,180 @@
+package custom
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/metafates/mangal/constant"
+	"github.com/metafates/mangal/filesystem"
+	"github.com/metafates/mangal/source"
+	"github.com/samber/lo"
+)
+
+// FixtureMode tells how the test treats http requests
+type FixtureMode string
+
+const (
+	// FixtureLive makes real requests
+	FixtureLive FixtureMode = "live"
+	// FixtureRecord makes real requests and saves them as fixtures
+	FixtureRecord FixtureMode = "record"
+	// FixtureReplay responds with saved fixtures without network
+	FixtureReplay FixtureMode = "replay"
+)
+
+// TestOptions of the source test
+type TestOptions struct {
+	// Fixtures is the path of the fixtures file. FixturesPath of the source is used if empty
+	Fixtures string
+	// Mode of the fixtures. If empty, fixtures are replayed if the file exists, otherwise requests are live
+	Mode FixtureMode
+}
+
+// TestReport is the result of the source test
+type TestReport struct {
+	Source   string      `json:"source"`
+	Mode     FixtureMode `json:"mode"`
+	Fixtures string      `json:"fixtures"`
+	Passed   bool        `json:"passed"`
+	Steps    []*TestStep `json:"steps"`
+}
+
+// TestStep is the result of testing a single function
+type TestStep struct {
+	Function string        `json:"function"`
+	Input    string        `json:"input"`
+	Results  int           `json:"results"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Passed is true if the function returned valid results
+func (t *TestStep) Passed() bool {
+	return t.Error == ""
+}
+
+// Test runs the functions of the source with the sample inputs of its manifest.
+// Returned tables are validated the same way as when the source is used normally.
+// Each function is expected to return at least one result.
+func Test(ctx context.Context, path string, options *TestOptions) (*TestReport, error) {
+	manifest, err := ReadManifest(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if manifest.Tests.Search == "" && manifest.Tests.Chapters == "" && manifest.Tests.Pages == "" {
+		return nil, fmt.Errorf("no test inputs declared, add \"-- @test.search <query>\" to the manifest")
+	}
+
+	report := &TestReport{
+		Source:   path,
+		Fixtures: options.Fixtures,
+		Mode:     options.Mode,
+		Steps:    make([]*TestStep, 0),
+	}
+
+	if report.Fixtures == "" {
+		report.Fixtures = FixturesPath(path)
+	}
+
+	if report.Mode == "" {
+		report.Mode = FixtureLive
+		if exists, _ := filesystem.Api().Exists(report.Fixtures); exists {
+			report.Mode = FixtureReplay
+		}
+	}
+
+	loadOptions := &Options{Validate: true, NoCache: true}
+
+	var fixtures *Fixtures
+	switch report.Mode {
+	case FixtureRecord:
+		fixtures = &Fixtures{}
+		loadOptions.Transport = fixtures.Recorder(nil)
+	case FixtureReplay:
+		fixtures, err = ReadFixtures(report.Fixtures)
+		if err != nil {
+			return nil, err
+		}
+
+		loadOptions.Transport = fixtures.Replayer()
+	case FixtureLive:
+	default:
+		return nil, fmt.Errorf("unknown fixture mode: %s", report.Mode)
+	}
+
+	src, err := Load(path, loadOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	runTests(ctx, src.(source.ContextSource), manifest.Tests, report)
+
+	if report.Mode == FixtureRecord {
+		if err := fixtures.Write(report.Fixtures); err != nil {
+			return nil, err
+		}
+	}
+
+	report.Passed = lo.EveryBy(report.Steps, (*TestStep).Passed)
+	return report, nil
+}
+
+func runTests(ctx context.Context, src source.ContextSource, inputs TestInputs, report *TestReport) {
+	step := func(fn, input string, run func() (int, error)) {
+		t := &TestStep{Function: fn, Input: input}
+		report.Steps = append(report.Steps, t)
+
+		if input == "" {
+			t.Error = "no input, declare it in the manifest or make the previous function return results"
+			return
+		}
+
+		start := time.Now()
+		n, err := run()
+		t.Duration = time.Since(start)
+		t.Results = n
+
+		if err == nil && n == 0 {
+			err = errors.New("no results")
+		}
+
+		if err != nil {
+			t.Error = err.Error()
+		}
+	}
+
+	var (
+		manga   = &source.Manga{Name: "test", URL: inputs.Chapters, Source: src}
+		chapter = &source.Chapter{Name: "test", URL: inputs.Pages, Manga: manga}
+	)
+
+	if inputs.Search != "" {
+		step(constant.SearchMangaFn, inputs.Search, func() (int, error) {
+			mangas, err := src.SearchContext(ctx, inputs.Search)
+			if err == nil && len(mangas) > 0 && manga.URL == "" {
+				manga = mangas[0]
+			}
+
+			return len(mangas), err
+		})
+	}
+
+	if inputs.Search != "" || inputs.Chapters != "" {
+		step(constant.MangaChaptersFn, manga.URL, func() (int, error) {
+			chapters, err := src.ChaptersOfContext(ctx, manga)
+			if err == nil && len(chapters) > 0 && chapter.URL == "" {
+				chapter = chapters[0]
+			}
+
+			return len(chapters), err
+		})
+	}
+
+	step(constant.ChapterPagesFn, chapter.URL, func() (int, error) {
+		pages, err := src.PagesOfContext(ctx, chapter)
+		return len(pages), err
+	})
+}
@@ -0,0 +1,116 @@
+package custom
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/metafates/mangal/filesystem"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const harnessScript = `-- @modules http, json
+-- @test.search %s
+local http = require("http")
+local json = require("json")
+local client = http.client()
+
+local base = "%s"
+
+local function get(path)
+	local response = client:do_request(http.request("GET", base .. path))
+	return json.decode(response.body)
+end
+
+function SearchManga(query)
+	return get("/search?q=" .. query)
+end
+
+function MangaChapters(mangaURL)
+	return get("/chapters")
+end
+
+function ChapterPages(chapterURL)
+	return get("/pages")
+end
+`
+
+func TestHarness(t *testing.T) {
+	Convey("Given a source with test inputs and a server", t, func() {
+		var requests int
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+
+			switch r.URL.Path {
+			case "/search":
+				if r.URL.Query().Get("q") == "broken" {
+					_, _ = fmt.Fprint(w, `[{"name": "no url"}]`)
+					return
+				}
+
+				_, _ = fmt.Fprint(w, `[{"name": "Manga", "url": "https://example.com/manga"}]`)
+			case "/chapters":
+				_, _ = fmt.Fprint(w, `[{"name": "Chapter 1", "url": "https://example.com/1"}]`)
+			case "/pages":
+				_, _ = fmt.Fprint(w, `[{"url": "https://example.com/1.jpg", "index": 1}]`)
+			}
+		}))
+		defer server.Close()
+
+		path := "harness.lua"
+		So(filesystem.Api().WriteFile(path, []byte(fmt.Sprintf(harnessScript, "manga", server.URL)), 0644), ShouldBeNil)
+		_ = filesystem.Api().Remove(FixturesPath(path))
+
+		Convey("When it is tested without fixtures", func() {
+			report, err := Test(context.Background(), path, &TestOptions{})
+			So(err, ShouldBeNil)
+
+			Convey("Then requests should be live and every function should pass", func() {
+				So(report.Mode, ShouldEqual, FixtureLive)
+				So(report.Passed, ShouldBeTrue)
+				So(report.Steps, ShouldHaveLength, 3)
+				So(requests, ShouldEqual, 3)
+			})
+		})
+
+		Convey("When it is recorded", func() {
+			report, err := Test(context.Background(), path, &TestOptions{Mode: FixtureRecord})
+			So(err, ShouldBeNil)
+			So(report.Passed, ShouldBeTrue)
+
+			fixtures, err := ReadFixtures(FixturesPath(path))
+			So(err, ShouldBeNil)
+
+			Convey("Then every request should be saved", func() {
+				So(fixtures.Exchanges, ShouldHaveLength, 3)
+			})
+
+			Convey("And then it is tested again", func() {
+				requests = 0
+				report, err := Test(context.Background(), path, &TestOptions{})
+				So(err, ShouldBeNil)
+
+				Convey("Then fixtures should be replayed without network", func() {
+					So(report.Mode, ShouldEqual, FixtureReplay)
+					So(report.Passed, ShouldBeTrue)
+					So(requests, ShouldEqual, 0)
+				})
+			})
+		})
+
+		Convey("When the source returns invalid tables", func() {
+			So(filesystem.Api().WriteFile(path, []byte(fmt.Sprintf(harnessScript, "broken", server.URL)), 0644), ShouldBeNil)
+
+			report, err := Test(context.Background(), path, &TestOptions{})
+			So(err, ShouldBeNil)
+
+			Convey("Then the validation error should be reported", func() {
+				So(report.Passed, ShouldBeFalse)
+				So(report.Steps[0].Error, ShouldContainSubstring, `"url" is required`)
+			})
+		})
+	})
+}
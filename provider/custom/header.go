@@ -0,0 +1,76 @@
+package custom
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/metafates/mangal/filesystem"
+	"github.com/metafates/mangal/util"
+)
+
+// header is the leading comment block of the script with `-- @key value` lines.
+// It is read without executing the script.
+type header map[string]string
+
+// list returns comma or space separated values of the key
+func (h header) list(key string) []string {
+	return strings.FieldsFunc(h[key], func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+}
+
+func readHeader(path string) (header, error) {
+	file, err := filesystem.Api().Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer util.Ignore(file.Close)
+
+	return parseHeader(file)
+}
+
+func parseHeader(r io.Reader) (header, error) {
+	h := make(header)
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" {
+			continue
+		}
+
+		// header ends with the first line that is not a comment
+		if !strings.HasPrefix(line, "--") {
+			break
+		}
+
+		line = strings.TrimSpace(strings.TrimLeft(line, "-"))
+
+		// a divider closes the header
+		if line == "" && len(h) > 0 {
+			break
+		}
+
+		if !strings.HasPrefix(line, "@") {
+			continue
+		}
+
+		key, value, _ := strings.Cut(line[1:], " ")
+		if key == "" {
+			continue
+		}
+
+		// repeated keys are joined, e.g. several @modules lines
+		value = strings.TrimSpace(value)
+		if prev, ok := h[key]; ok && prev != "" {
+			value = prev + ", " + value
+		}
+
+		h[key] = value
+	}
+
+	return h, scanner.Err()
+}
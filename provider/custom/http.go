@@ -0,0 +1,86 @@
+package custom
+
+import (
+	"net/http"
+	"os"
+
+	libhttp "github.com/metafates/mangal-lua-libs/http"
+	client "github.com/metafates/mangal-lua-libs/http/client"
+	"github.com/metafates/mangal/network"
+	lua "github.com/yuin/gopher-lua"
+)
+
+func init() {
+	network.DefaultUserAgents = append(network.DefaultUserAgents, client.DefaultUserAgent)
+}
+
+// preloadHTTP replaces http modules with the ones whose clients
+// make requests with the context of the state.
+// If transport is not nil, clients use it instead of their own.
+// Otherwise, they use the network transport with the proxy and tls options of the script on top.
+// It must be called after the libs are preloaded.
+func preloadHTTP(state *lua.LState, source string, transport http.RoundTripper) {
+	newClient := func(L *lua.LState) int {
+		n := client.New(L)
+
+		if c, ok := L.CheckUserData(-1).Value.(*client.LuaClient); ok {
+			if transport != nil {
+				c.Transport = transport
+			} else {
+				c.Transport = network.WithOverrides(overrides(c.Transport))
+			}
+
+			c.Transport = &contextTransport{
+				base:   c.Transport,
+				source: source,
+				state:  state,
+			}
+		}
+
+		return n
+	}
+
+	for module, loader := range map[string]lua.LGFunction{
+		"http":        libhttp.Loader,
+		"http_client": client.Loader,
+	} {
+		loader := loader
+		state.PreloadModule(module, func(L *lua.LState) int {
+			n := loader(L)
+			L.SetField(L.CheckTable(-1), "client", L.NewFunction(newClient))
+			return n
+		})
+	}
+}
+
+// overrides are the proxy and tls options the script configured for the client.
+// Proxy from the HTTP_PROXY environment variable is used by the network transport too
+func overrides(transport http.RoundTripper) (o network.Overrides) {
+	t, ok := transport.(*http.Transport)
+	if !ok {
+		return
+	}
+
+	if os.Getenv("HTTP_PROXY") == "" {
+		o.Proxy = t.Proxy
+	}
+
+	o.InsecureSkipVerify = t.TLSClientConfig != nil && t.TLSClientConfig.InsecureSkipVerify
+	return
+}
+
+// contextTransport attaches context of the lua state and the source name to each request
+type contextTransport struct {
+	base   http.RoundTripper
+	source string
+	state  *lua.LState
+}
+
+func (t *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := t.state.Context()
+	if ctx == nil {
+		ctx = req.Context()
+	}
+
+	return t.base.RoundTrip(req.WithContext(network.WithSource(ctx, t.source)))
+}
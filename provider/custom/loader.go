@@ -1,8 +1,10 @@
 package custom
 
 import (
+	"context"
 	"fmt"
-	libs "github.com/metafates/mangal-lua-libs"
+	"net/http"
+
 	"github.com/metafates/mangal/filesystem"
 	"github.com/metafates/mangal/source"
 	"github.com/metafates/mangal/util"
@@ -14,25 +16,55 @@ func IDfromName(name string) string {
 	return name + " custom"
 }
 
+// Options of loading a lua source
+type Options struct {
+	// Validate checks that the required functions are defined
+	Validate bool
+	// Transport is used by http clients of the source instead of the default one
+	Transport http.RoundTripper
+	// NoCache disables caching of the search results and chapters
+	NoCache bool
+}
+
 func LoadSource(path string, validate bool) (source.Source, error) {
+	return Load(path, &Options{Validate: validate})
+}
+
+// Load loads the lua source with the given options
+func Load(path string, options *Options) (source.Source, error) {
 	proto, err := Compile(path)
 	if err != nil {
 		return nil, err
 	}
 
-	state := lua.NewState()
-	libs.Preload(state)
+	manifest, err := ReadManifest(path)
+	if err != nil {
+		return nil, err
+	}
 
+	name := util.FileStem(path)
+
+	if err := manifest.Compatible(); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	box := newSandbox(name, manifest)
+	state := box.newState(options.Transport)
+
+	// top level code runs with the same budget as a single call
+	ctx, cancel := box.limit(context.Background())
+	defer cancel()
+
+	state.SetContext(ctx)
 	lfunc := state.NewFunctionFromProto(proto)
 	state.Push(lfunc)
 	err = state.PCall(0, lua.MultRet, nil)
+	state.RemoveContext()
 	if err != nil {
-		return nil, err
+		return nil, box.err(context.Background(), ctx, err)
 	}
 
-	name := util.FileStem(path)
-
-	if validate {
+	if options.Validate {
 		for _, fn := range mustHave {
 			defined := state.GetGlobal(fn)
 
@@ -42,7 +74,7 @@ func LoadSource(path string, validate bool) (source.Source, error) {
 		}
 	}
 
-	luaSource, err := newLuaSource(name, state)
+	luaSource, err := newLuaSource(name, state, box, manifest, !options.NoCache)
 	if err != nil {
 		return nil, err
 	}
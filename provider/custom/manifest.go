@@ -0,0 +1,123 @@
+package custom
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/metafates/mangal/constant"
+	"github.com/metafates/mangal/version"
+	"github.com/samber/lo"
+)
+
+// Manifest describes the lua source. It is the header of the script
+//
+//	-- @name    example
+//	-- @version 1.0.0
+//	-- @author  someone
+//	-- @lang    en, fr
+//	-- @url     https://example.com
+//	-- @modules http, html
+//	-- @mangal  4.0.0
+//
+//	-- @setting.language string en Preferred language of chapters
+//
+//	-- @test.search   one piece
+//	-- @test.chapters https://example.com/manga/one-piece
+//	-- @test.pages    https://example.com/manga/one-piece/1
+//
+// and is read without executing the script.
+type Manifest struct {
+	Name    string `json:"name,omitempty"`
+	Version string `json:"version,omitempty"`
+	Author  string `json:"author,omitempty"`
+	License string `json:"license,omitempty"`
+	// Languages of the source as ISO 639-1 codes, the first one is the standard language
+	Languages []string `json:"languages"`
+	// URLs are base urls of the websites the source scrapes
+	URLs []string `json:"urls"`
+	// Modules that the source is allowed to require
+	Modules []string `json:"modules"`
+	// MinVersion is the minimum version of mangal the source works with
+	MinVersion string `json:"mangal,omitempty"`
+	// Settings are options of the source that can be changed with mangal config
+	Settings []*Setting `json:"settings"`
+	// Tests are sample inputs for mangal sources test
+	Tests TestInputs `json:"tests"`
+}
+
+// TestInputs are sample inputs of the source functions.
+// Empty inputs of chapters and pages are taken from the results of the previous function
+type TestInputs struct {
+	// Search is the query for SearchManga
+	Search string `json:"search,omitempty"`
+	// Chapters is the manga url for MangaChapters
+	Chapters string `json:"chapters,omitempty"`
+	// Pages is the chapter url for ChapterPages
+	Pages string `json:"pages,omitempty"`
+}
+
+// ReadManifest reads the manifest of the source at the path
+func ReadManifest(path string) (*Manifest, error) {
+	h, err := readHeader(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return newManifest(h)
+}
+
+func newManifest(h header) (*Manifest, error) {
+	settings, err := parseSettings(h)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Manifest{
+		Name:       h["name"],
+		Version:    h["version"],
+		Author:     h["author"],
+		License:    h["license"],
+		Languages:  lo.Uniq(append(h.list("lang"), h.list("languages")...)),
+		URLs:       h.list("url"),
+		Modules:    h.list("modules"),
+		MinVersion: strings.TrimSpace(strings.TrimPrefix(h["mangal"], ">=")),
+		Settings:   settings,
+		Tests: TestInputs{
+			Search:   h["test.search"],
+			Chapters: h["test.chapters"],
+			Pages:    h["test.pages"],
+		},
+	}, nil
+}
+
+// StdLang is the standard language of the source, "en" if not declared
+func (m *Manifest) StdLang() string {
+	if len(m.Languages) == 0 {
+		return "en"
+	}
+
+	return m.Languages[0]
+}
+
+// UsesHeadless is true if the source requires the headless chrome module
+func (m *Manifest) UsesHeadless() bool {
+	return lo.Contains(m.Modules, "headless")
+}
+
+// Compatible returns an error if the source requires a newer version of mangal
+func (m *Manifest) Compatible() error {
+	if m.MinVersion == "" {
+		return nil
+	}
+
+	cmp, err := version.Compare(constant.Version, m.MinVersion)
+	if err != nil {
+		return fmt.Errorf("invalid mangal version %q in the manifest: %w", m.MinVersion, err)
+	}
+
+	if cmp < 0 {
+		return fmt.Errorf("source requires mangal %s or newer, current version is %s", m.MinVersion, constant.Version)
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package custom
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/metafates/mangal/filesystem"
+	"github.com/samber/lo"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const manifestScript = `----------------------------
+-- @name    example
+-- @version 1.2.0
+-- @author  someone
+-- @license MIT
+-- @lang    fr, en
+-- @url     https://example.com
+-- @url     https://mirror.example.com
+-- @modules http, headless
+-- @mangal  >= 4.0.0
+----------------------------
+
+---@alias manga { name: string, url: string }
+`
+
+func TestManifest(t *testing.T) {
+	Convey("Given a script with a manifest", t, func() {
+		h, err := parseHeader(strings.NewReader(manifestScript))
+		So(err, ShouldBeNil)
+
+		Convey("When the manifest is created", func() {
+			manifest := lo.Must(newManifest(h))
+
+			Convey("Then it should have the fields of the header", func() {
+				So(manifest.Name, ShouldEqual, "example")
+				So(manifest.Version, ShouldEqual, "1.2.0")
+				So(manifest.Author, ShouldEqual, "someone")
+				So(manifest.License, ShouldEqual, "MIT")
+				So(manifest.Languages, ShouldResemble, []string{"fr", "en"})
+				So(manifest.URLs, ShouldResemble, []string{"https://example.com", "https://mirror.example.com"})
+				So(manifest.Modules, ShouldResemble, []string{"http", "headless"})
+				So(manifest.MinVersion, ShouldEqual, "4.0.0")
+			})
+
+			Convey("Then the first language should be the standard one", func() {
+				So(manifest.StdLang(), ShouldEqual, "fr")
+			})
+
+			Convey("Then it should use headless", func() {
+				So(manifest.UsesHeadless(), ShouldBeTrue)
+			})
+
+			Convey("Then it should be compatible", func() {
+				So(manifest.Compatible(), ShouldBeNil)
+			})
+		})
+	})
+
+	Convey("Given a script without a manifest", t, func() {
+		manifest := lo.Must(newManifest(lo.Must(parseHeader(strings.NewReader("print(1)")))))
+
+		Convey("Then the defaults should be used", func() {
+			So(manifest.StdLang(), ShouldEqual, "en")
+			So(manifest.UsesHeadless(), ShouldBeFalse)
+			So(manifest.Compatible(), ShouldBeNil)
+		})
+	})
+
+	Convey("Given a script that requires a newer mangal", t, func() {
+		path := "future.lua"
+		script := "-- @mangal 999.0.0\n" + testScript
+		So(filesystem.Api().WriteFile(path, []byte(script), 0644), ShouldBeNil)
+
+		Convey("When it is loaded", func() {
+			_, err := LoadSource(path, true)
+
+			Convey("Then the compatibility error should be returned", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "requires mangal 999.0.0")
+			})
+		})
+	})
+}
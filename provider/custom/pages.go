@@ -1,13 +1,21 @@
 package custom
 
 import (
+	"context"
 	"github.com/metafates/mangal/constant"
 	"github.com/metafates/mangal/source"
 	lua "github.com/yuin/gopher-lua"
 )
 
 func (s *luaSource) PagesOf(chapter *source.Chapter) ([]*source.Page, error) {
-	_, err := s.call(constant.ChapterPagesFn, lua.LTTable, lua.LString(chapter.URL))
+	return s.PagesOfContext(context.Background(), chapter)
+}
+
+func (s *luaSource) PagesOfContext(ctx context.Context, chapter *source.Chapter) ([]*source.Page, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_, err := s.call(ctx, constant.ChapterPagesFn, lua.LTTable, lua.LString(chapter.URL))
 
 	if err != nil {
 		return nil, err
@@ -16,23 +24,19 @@ func (s *luaSource) PagesOf(chapter *source.Chapter) ([]*source.Page, error) {
 	table := s.state.CheckTable(-1)
 	pages := make([]*source.Page, 0)
 
-	table.ForEach(func(k lua.LValue, v lua.LValue) {
-		if k.Type() != lua.LTNumber {
-			s.state.RaiseError(constant.ChapterPagesFn + " was expected to return a table with numbers as keys, got " + k.Type().String() + " as a key")
-		}
-
-		if v.Type() != lua.LTTable {
-			s.state.RaiseError(constant.ChapterPagesFn + " was expected to return a table with tables as values, got " + v.Type().String() + " as a value")
-		}
-
-		page, err := pageFromTable(v.(*lua.LTable), chapter)
-
+	err = forEachResult(constant.ChapterPagesFn, table, func(_ uint16, value *lua.LTable) error {
+		page, err := pageFromTable(value, chapter)
 		if err != nil {
-			s.state.RaiseError(err.Error())
+			return err
 		}
 
 		pages = append(pages, page)
+		return nil
 	})
 
+	if err != nil {
+		return nil, err
+	}
+
 	return pages, nil
 }
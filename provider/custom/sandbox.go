@@ -0,0 +1,422 @@
+package custom
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/metrics"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	libs "github.com/metafates/mangal-lua-libs"
+	"github.com/metafates/mangal/key"
+	"github.com/samber/lo"
+	"github.com/spf13/viper"
+	lua "github.com/yuin/gopher-lua"
+)
+
+const (
+	// registryMaxSize caps the size of the lua stack (in values)
+	registryMaxSize = 1 << 20
+
+	memoryCheckInterval = 10 * time.Millisecond
+)
+
+var (
+	// pureModules do not touch the os nor the filesystem and are available once declared
+	pureModules = []string{
+		"base64", "crypto", "html", "http", "humanize", "inspect", "json",
+		"regexp", "runtime", "shellescape", "stats", "strings", "time", "xmlpath", "yaml",
+	}
+	// osModules give access to the os, they require the os grant.
+	// headless launches a browser
+	osModules = []string{"goos", "headless"}
+	// ioModules give access to the filesystem, they require the io grant.
+	// log writes files, template renders files and filepath lists directories
+	ioModules = []string{"ioutil", "storage", "log", "template", "filepath"}
+	// safeOS are functions of the os library that are available without the grant
+	safeOS = []string{"time", "clock", "date", "difftime"}
+)
+
+var (
+	errInstructionBudget = errors.New("instruction budget exceeded")
+	errMemoryLimit       = errors.New("memory limit exceeded")
+)
+
+// SandboxError is returned when a custom source violates the sandbox
+type SandboxError struct {
+	Source string
+	Reason string
+}
+
+func (e *SandboxError) Error() string {
+	return fmt.Sprintf("source %s %s", e.Source, e.Reason)
+}
+
+// sandbox restricts what a lua source can do.
+// Modules must be declared in the script header with `-- @modules`,
+// os and io must be granted by the user and each call has a time and instruction budget.
+// The size of the lua stack is bounded too, while the memory guard watches the whole process.
+type sandbox struct {
+	source  string
+	enabled bool
+	modules []string
+	// settings are always available to the source with the settings module
+	settings []*Setting
+	allowOS  bool
+	allowIO  bool
+
+	timeout      time.Duration
+	instructions int64
+	memory       uint64
+
+	// violation is the last violation raised inside the state
+	violation *SandboxError
+}
+
+func newSandbox(name string, manifest *Manifest) *sandbox {
+	return &sandbox{
+		source:       name,
+		enabled:      viper.GetBool(key.SandboxEnabled),
+		modules:      manifest.Modules,
+		settings:     manifest.Settings,
+		allowOS:      lo.Contains(viper.GetStringSlice(key.SandboxAllowOS), name),
+		allowIO:      lo.Contains(viper.GetStringSlice(key.SandboxAllowIO), name),
+		timeout:      time.Duration(viper.GetInt(key.SandboxTimeout)) * time.Second,
+		instructions: viper.GetInt64(key.SandboxInstructionLimit),
+		memory:       uint64(viper.GetInt(key.SandboxMemoryLimit)) << 20,
+	}
+}
+
+// newState creates a lua state with only the allowed libraries and modules.
+// Transport is used by the http clients if not nil
+func (s *sandbox) newState(transport http.RoundTripper) *lua.LState {
+	if !s.enabled {
+		state := lua.NewState()
+		libs.Preload(state)
+		preloadHTTP(state, s.source, transport)
+		preloadSettings(state, s.source, s.settings)
+		return state
+	}
+
+	state := lua.NewState(lua.Options{
+		SkipOpenLibs:    true,
+		CallStackSize:   lua.CallStackSize,
+		RegistrySize:    lua.RegistrySize,
+		RegistryMaxSize: registryMaxSize,
+	})
+
+	type lib struct {
+		name string
+		open lua.LGFunction
+	}
+
+	opened := []lib{
+		{lua.LoadLibName, lua.OpenPackage},
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+		{lua.CoroutineLibName, lua.OpenCoroutine},
+		{lua.OsLibName, lua.OpenOs},
+	}
+
+	if s.allowIO {
+		opened = append(opened, lib{lua.IoLibName, lua.OpenIo})
+	}
+
+	for _, l := range opened {
+		state.Push(state.NewFunction(l.open))
+		state.Push(lua.LString(l.name))
+		state.Call(1, 0)
+	}
+
+	s.guardCoroutines(state)
+
+	// scripts can not be loaded from the filesystem
+	state.SetGlobal("dofile", lua.LNil)
+	state.SetGlobal("loadfile", lua.LNil)
+
+	pkg := state.GetGlobal(lua.LoadLibName)
+	state.SetField(pkg, "path", lua.LString(""))
+	state.SetField(pkg, "cpath", lua.LString(""))
+
+	libs.Preload(state)
+	preloadHTTP(state, s.source, transport)
+	s.restrictModules(state)
+	preloadSettings(state, s.source, s.settings)
+
+	if !s.allowOS {
+		s.restrictLib(state, lua.OsLibName, key.SandboxAllowOS, safeOS...)
+	}
+
+	if !s.allowIO {
+		s.restrictLib(state, lua.IoLibName, key.SandboxAllowIO)
+	}
+
+	return state
+}
+
+// restrictModules replaces loaders of the modules that are not allowed
+// with the ones that raise an error
+func (s *sandbox) restrictModules(state *lua.LState) {
+	preload, ok := state.GetField(state.GetGlobal(lua.LoadLibName), "preload").(*lua.LTable)
+	if !ok {
+		return
+	}
+
+	var names []string
+	preload.ForEach(func(k lua.LValue, _ lua.LValue) {
+		names = append(names, k.String())
+	})
+
+	for _, name := range names {
+		var reason string
+
+		switch {
+		case !lo.Contains(s.modules, name):
+			reason = fmt.Sprintf("is not allowed to require module %q, declare it in the header with \"-- @modules %s\"", name, name)
+		case lo.Contains(osModules, name) && !s.allowOS:
+			reason = fmt.Sprintf("is not allowed to require module %q, grant it with %s", name, key.SandboxAllowOS)
+		case lo.Contains(ioModules, name) && !s.allowIO:
+			reason = fmt.Sprintf("is not allowed to require module %q, grant it with %s", name, key.SandboxAllowIO)
+		case lo.Contains(pureModules, name) || lo.Contains(osModules, name) || lo.Contains(ioModules, name):
+			continue
+		default:
+			// modules that were not reviewed are denied, even if declared
+			reason = fmt.Sprintf("is not allowed to require module %q, it is not available in the sandbox", name)
+		}
+
+		state.SetField(preload, name, state.NewFunction(func(L *lua.LState) int {
+			s.raise(L, reason)
+			return 0
+		}))
+	}
+}
+
+// coroutineWrap is coroutine.wrap built on top of the guarded resume
+const coroutineWrap = `
+local create, resume, error = ...
+
+local function pass(ok, ...)
+	if not ok then
+		error((...), 0)
+	end
+
+	return ...
+end
+
+return function(f)
+	local co = create(f)
+	return function(...)
+		return pass(resume(co, ...))
+	end
+end
+`
+
+// guardCoroutines makes coroutines run with the context of the caller.
+// Threads get their own context otherwise, which is not counted by the instruction budget
+func (s *sandbox) guardCoroutines(state *lua.LState) {
+	lib, ok := state.GetGlobal(lua.CoroutineLibName).(*lua.LTable)
+	if !ok {
+		return
+	}
+
+	resume := lib.RawGetString("resume")
+	guarded := state.NewFunction(func(L *lua.LState) int {
+		if thread, ok := L.Get(1).(*lua.LState); ok && L.Context() != nil {
+			thread.SetContext(L.Context())
+		}
+
+		top := L.GetTop()
+		L.Push(resume)
+		for i := 1; i <= top; i++ {
+			L.Push(L.Get(i))
+		}
+
+		L.Call(top, lua.MultRet)
+		return L.GetTop() - top
+	})
+
+	wrap, err := state.LoadString(coroutineWrap)
+	if err != nil {
+		panic(err)
+	}
+
+	state.Push(wrap)
+	state.Push(lib.RawGetString("create"))
+	state.Push(guarded)
+	state.Push(state.GetGlobal("error"))
+	state.Call(3, 1)
+
+	lib.RawSetString("resume", guarded)
+	lib.RawSetString("wrap", state.Get(-1))
+	state.Pop(1)
+}
+
+// restrictLib replaces the library with a table that has only kept functions.
+// Accessing anything else raises an error
+func (s *sandbox) restrictLib(state *lua.LState, name, grant string, keep ...string) {
+	lib := state.NewTable()
+
+	if original, ok := state.GetGlobal(name).(*lua.LTable); ok {
+		for _, fn := range keep {
+			lib.RawSetString(fn, original.RawGetString(fn))
+		}
+	}
+
+	meta := state.NewTable()
+	meta.RawSetString("__index", state.NewFunction(func(L *lua.LState) int {
+		s.raise(L, fmt.Sprintf("is not allowed to use %s.%s, grant it with %s", name, L.Get(2).String(), grant))
+		return 0
+	}))
+	state.SetMetatable(lib, meta)
+
+	state.SetGlobal(name, lib)
+
+	// require should not return the original library either
+	state.SetField(state.GetField(state.Get(lua.RegistryIndex), "_LOADED"), name, lib)
+}
+
+func (s *sandbox) raise(L *lua.LState, reason string) {
+	s.violation = &SandboxError{Source: s.source, Reason: reason}
+	L.RaiseError(s.violation.Error())
+}
+
+// limit returns the context with the budget for a single call
+func (s *sandbox) limit(ctx context.Context) (context.Context, context.CancelFunc) {
+	s.violation = nil
+
+	if !s.enabled {
+		return ctx, func() {}
+	}
+
+	cancelTimeout := func() {}
+	if s.timeout > 0 {
+		ctx, cancelTimeout = context.WithTimeout(ctx, s.timeout)
+	}
+
+	b := newBudget(ctx, s.instructions, s.memory)
+	return b, func() {
+		b.stop(context.Canceled)
+		cancelTimeout()
+	}
+}
+
+// err returns the sandbox error if the call failed because of the sandbox.
+// parent is the context passed by the caller and ctx is the one returned by limit.
+func (s *sandbox) err(parent, ctx context.Context, err error) error {
+	if parent.Err() != nil {
+		return parent.Err()
+	}
+
+	if s.violation != nil {
+		return s.violation
+	}
+
+	switch ctxErr := ctx.Err(); {
+	case ctxErr == nil:
+		return err
+	case errors.Is(ctxErr, context.DeadlineExceeded):
+		return &SandboxError{Source: s.source, Reason: fmt.Sprintf("exceeded the time budget of %s", s.timeout)}
+	case errors.Is(ctxErr, errInstructionBudget):
+		return &SandboxError{Source: s.source, Reason: fmt.Sprintf("exceeded the budget of %d instructions", s.instructions)}
+	case errors.Is(ctxErr, errMemoryLimit):
+		// heap is shared by the whole process, so the source is not necessarily the one to blame
+		return fmt.Errorf("%w: heap of mangal grew by more than %d MiB during the call of source %s", errMemoryLimit, s.memory>>20, s.source)
+	default:
+		return ctxErr
+	}
+}
+
+// budget is a context that is done when the parent is done,
+// when the instruction budget is spent or when the memory limit is exceeded.
+//
+// The lua vm checks Done before each instruction,
+// which is what instructions are counted by.
+type budget struct {
+	context.Context
+	instructions int64
+	spent        int64
+	done         chan struct{}
+	once         sync.Once
+	err          error
+}
+
+func newBudget(parent context.Context, instructions int64, memory uint64) *budget {
+	b := &budget{
+		Context:      parent,
+		instructions: instructions,
+		done:         make(chan struct{}),
+	}
+
+	go b.watch(memory)
+	return b
+}
+
+func (b *budget) Done() <-chan struct{} {
+	if b.instructions > 0 && atomic.AddInt64(&b.spent, 1) > b.instructions {
+		b.stop(errInstructionBudget)
+	}
+
+	return b.done
+}
+
+func (b *budget) Err() error {
+	select {
+	case <-b.done:
+		return b.err
+	default:
+		return nil
+	}
+}
+
+func (b *budget) stop(err error) {
+	b.once.Do(func() {
+		b.err = err
+		close(b.done)
+	})
+}
+
+// watch stops the budget when the parent is done or the heap grows more than the memory limit.
+// Heap is shared by the whole process, so it's a guard against runaway memory usage
+// rather than a limit of the call: other downloads and requests allocate at the same time.
+func (b *budget) watch(memory uint64) {
+	var tick <-chan time.Time
+	var base uint64
+
+	if memory > 0 {
+		base = heapSize()
+		ticker := time.NewTicker(memoryCheckInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-b.Context.Done():
+			b.stop(b.Context.Err())
+			return
+		case <-tick:
+			if size := heapSize(); size > base && size-base > memory {
+				b.stop(errMemoryLimit)
+				return
+			}
+		}
+	}
+}
+
+func heapSize() uint64 {
+	sample := []metrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+	metrics.Read(sample)
+
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+
+	return sample[0].Value.Uint64()
+}
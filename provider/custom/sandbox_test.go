@@ -0,0 +1,242 @@
+package custom
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/metafates/mangal/config"
+	"github.com/metafates/mangal/filesystem"
+	"github.com/metafates/mangal/key"
+	"github.com/metafates/mangal/source"
+	"github.com/samber/lo"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	lo.Must0(config.Setup())
+}
+
+const sandboxScript = `-- @name    sandboxed
+-- @modules strings, log, template, filepath, headless
+local strings = require("strings")
+
+function SearchManga(query)
+	if query == "loop" then
+		while true do end
+	elseif query == "memory" then
+		local t = {}
+		for i = 1, 100000000 do
+			t[i] = string.rep("x", 64) .. i
+		end
+	elseif query == "json" then
+		require("json")
+	elseif query == "log" then
+		require("log").new("/tmp/escaped.log")
+	elseif query == "template" then
+		require("template").choose("mustache"):render_file("/etc/passwd", {})
+	elseif query == "filepath" then
+		require("filepath").glob("/*")
+	elseif query == "headless" then
+		require("headless")
+	elseif query == "coroutine" then
+		coroutine.wrap(function()
+			for i = 1, 3000000 do end
+		end)()
+	elseif query == "resume" then
+		local co = coroutine.create(function()
+			for i = 1, 3000000 do end
+		end)
+		local ok, err = coroutine.resume(co)
+		if not ok then error(err) end
+	elseif query == "yield" then
+		local next = coroutine.wrap(function(a)
+			local b = coroutine.yield(a + 1)
+			coroutine.yield(b * 2)
+		end)
+		assert(next(1) == 2 and next(5) == 10, "coroutine yielded wrong values")
+		local co = coroutine.create(function() coroutine.yield(3) end)
+		local ok, value = coroutine.resume(co)
+		assert(ok and value == 3, "coroutine resumed with wrong values")
+
+	elseif query == "execute" then
+		os.execute("true")
+	elseif query == "io" then
+		io.open("/etc/passwd")
+	elseif query == "time" then
+		os.time()
+	end
+
+	return {}
+end
+
+function MangaChapters(mangaURL)
+	return {}
+end
+
+function ChapterPages(chapterURL)
+	return {}
+end
+`
+
+func loadSandboxed(t *testing.T) source.ContextSource {
+	path := "sandboxed.lua"
+	lo.Must0(filesystem.Api().WriteFile(path, []byte(sandboxScript), 0644))
+
+	src, err := LoadSource(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return src.(source.ContextSource)
+}
+
+func TestParseHeader(t *testing.T) {
+	Convey("Given a script with a header", t, func() {
+		script := `-------------
+-- @name    test
+-- @modules http, html
+-- @modules json
+-------------
+-- @ignored because the header has ended
+print("hello")
+`
+
+		Convey("When the header is parsed", func() {
+			h, err := parseHeader(strings.NewReader(script))
+			So(err, ShouldBeNil)
+
+			Convey("Then it should contain the keys of the leading comment block", func() {
+				So(h["name"], ShouldEqual, "test")
+				So(h.list("modules"), ShouldResemble, []string{"http", "html", "json"})
+				So(h, ShouldNotContainKey, "ignored")
+			})
+		})
+	})
+}
+
+func TestSandbox(t *testing.T) {
+	keys := []string{
+		key.SandboxEnabled,
+		key.SandboxInstructionLimit,
+		key.SandboxTimeout,
+		key.SandboxMemoryLimit,
+		key.SandboxAllowOS,
+		key.SandboxAllowIO,
+	}
+
+	for _, k := range keys {
+		defer viper.Set(k, viper.Get(k))
+	}
+
+	Convey("Given a sandboxed lua source", t, func() {
+		viper.Set(key.SandboxEnabled, true)
+		viper.Set(key.SandboxInstructionLimit, 0)
+		viper.Set(key.SandboxTimeout, 0)
+		viper.Set(key.SandboxMemoryLimit, 0)
+		viper.Set(key.SandboxAllowOS, []string{})
+		viper.Set(key.SandboxAllowIO, []string{})
+
+		assertViolation := func(query, reason string) {
+			_, err := loadSandboxed(t).SearchContext(context.Background(), query)
+
+			var sandboxErr *SandboxError
+			So(errors.As(err, &sandboxErr), ShouldBeTrue)
+			So(sandboxErr.Source, ShouldEqual, "sandboxed")
+			So(sandboxErr.Reason, ShouldContainSubstring, reason)
+		}
+
+		Convey("When it runs out of instructions", func() {
+			viper.Set(key.SandboxInstructionLimit, 10000)
+
+			Convey("Then the instruction budget error should be returned", func() {
+				assertViolation("loop", "budget of 10000 instructions")
+			})
+		})
+
+		Convey("When it runs out of instructions inside a coroutine", func() {
+			viper.Set(key.SandboxInstructionLimit, 100000)
+
+			Convey("Then the instruction budget error should be returned for wrap", func() {
+				assertViolation("coroutine", "budget of 100000 instructions")
+			})
+
+			Convey("Then the instruction budget error should be returned for resume", func() {
+				assertViolation("resume", "budget of 100000 instructions")
+			})
+
+			Convey("Then coroutines within the budget should still yield values", func() {
+				_, err := loadSandboxed(t).SearchContext(context.Background(), "yield")
+				So(err, ShouldBeNil)
+			})
+		})
+
+		Convey("When it runs out of time", func() {
+			viper.Set(key.SandboxTimeout, 1)
+
+			Convey("Then the time budget error should be returned", func() {
+				assertViolation("loop", "time budget of 1s")
+			})
+		})
+
+		Convey("When it allocates too much memory", func() {
+			viper.Set(key.SandboxMemoryLimit, 16)
+
+			Convey("Then the memory guard error should be returned", func() {
+				_, err := loadSandboxed(t).SearchContext(context.Background(), "memory")
+
+				var sandboxErr *SandboxError
+				So(errors.Is(err, errMemoryLimit), ShouldBeTrue)
+				So(errors.As(err, &sandboxErr), ShouldBeFalse)
+				So(err.Error(), ShouldContainSubstring, "more than 16 MiB")
+			})
+		})
+
+		Convey("When it requires a module that is not declared", func() {
+			Convey("Then the module error should be returned", func() {
+				assertViolation("json", `require module "json"`)
+			})
+		})
+
+		Convey("When it uses os without the grant", func() {
+			Convey("Then the os error should be returned", func() {
+				assertViolation("execute", "os.execute")
+			})
+
+			Convey("Then safe os functions should still work", func() {
+				_, err := loadSandboxed(t).SearchContext(context.Background(), "time")
+				So(err, ShouldBeNil)
+			})
+		})
+
+		Convey("When it uses io without the grant", func() {
+			Convey("Then the io error should be returned", func() {
+				assertViolation("io", "io.open")
+			})
+
+			Convey("Then the filesystem modules should not be available", func() {
+				for _, module := range []string{"log", "template", "filepath"} {
+					assertViolation(module, fmt.Sprintf(`require module %q, grant it with %s`, module, key.SandboxAllowIO))
+				}
+			})
+		})
+
+		Convey("When it launches a browser without the grant", func() {
+			Convey("Then the os error should be returned", func() {
+				assertViolation("headless", fmt.Sprintf(`require module "headless", grant it with %s`, key.SandboxAllowOS))
+			})
+		})
+
+		Convey("When it stays within the limits", func() {
+			viper.Set(key.SandboxInstructionLimit, 10000)
+
+			Convey("Then no error should be returned", func() {
+				_, err := loadSandboxed(t).SearchContext(context.Background(), "test")
+				So(err, ShouldBeNil)
+			})
+		})
+	})
+}
@@ -1,13 +1,20 @@
 package custom
 
 import (
+	"context"
 	"github.com/metafates/mangal/constant"
 	"github.com/metafates/mangal/source"
 	lua "github.com/yuin/gopher-lua"
-	"strconv"
 )
 
 func (s *luaSource) Search(query string) ([]*source.Manga, error) {
+	return s.SearchContext(context.Background(), query)
+}
+
+func (s *luaSource) SearchContext(ctx context.Context, query string) ([]*source.Manga, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
 	if mangas := s.cache.mangas.Get(query); mangas.IsPresent() {
 		m := mangas.MustGet()
 		for _, manga := range m {
@@ -17,7 +24,7 @@ func (s *luaSource) Search(query string) ([]*source.Manga, error) {
 		return m, nil
 	}
 
-	_, err := s.call(constant.SearchMangaFn, lua.LTTable, lua.LString(query))
+	_, err := s.call(ctx, constant.SearchMangaFn, lua.LTTable, lua.LString(query))
 
 	if err != nil {
 		return nil, err
@@ -26,30 +33,21 @@ func (s *luaSource) Search(query string) ([]*source.Manga, error) {
 	table := s.state.CheckTable(-1)
 	mangas := make([]*source.Manga, 0)
 
-	table.ForEach(func(k lua.LValue, v lua.LValue) {
-		if k.Type() != lua.LTNumber {
-			s.state.RaiseError(constant.SearchMangaFn + " was expected to return a table with numbers as keys, got " + k.Type().String() + " as a key")
-		}
-
-		if v.Type() != lua.LTTable {
-			s.state.RaiseError(constant.SearchMangaFn + " was expected to return a table with tables as values, got " + v.Type().String() + " as a value")
-		}
-
-		index, err := strconv.ParseUint(k.String(), 10, 16)
+	err = forEachResult(constant.SearchMangaFn, table, func(index uint16, value *lua.LTable) error {
+		manga, err := mangaFromTable(value, index)
 		if err != nil {
-			s.state.RaiseError(constant.SearchMangaFn + " was expected to return a table with unsigned integers as keys. " + err.Error())
-		}
-
-		manga, err := mangaFromTable(v.(*lua.LTable), uint16(index))
-
-		if err != nil {
-			s.state.RaiseError(err.Error())
+			return err
 		}
 
 		manga.Source = s
 		mangas = append(mangas, manga)
+		return nil
 	})
 
+	if err != nil {
+		return nil, err
+	}
+
 	_ = s.cache.mangas.Set(query, mangas)
 	return mangas, nil
 }
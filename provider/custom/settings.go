@@ -0,0 +1,183 @@
+package custom
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// settingsModule is the lua module with the values of the source settings.
+// It is always available and does not have to be declared in the manifest
+const settingsModule = "settings"
+
+var settingNameRegex = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// SettingType is the type of the source setting value
+type SettingType string
+
+const (
+	SettingString SettingType = "string"
+	// SettingSecret is a string that is not shown by mangal config info, e.g. password or token
+	SettingSecret SettingType = "secret"
+	SettingBool   SettingType = "bool"
+	SettingInt    SettingType = "int"
+	// SettingList is a list of strings, default is comma separated
+	SettingList SettingType = "list"
+)
+
+// Setting is an option of the source that can be changed with mangal config.
+// It is declared in the manifest as
+//
+//	-- @setting.<name> <type> <default> <description>
+//
+// Default can be quoted if it contains spaces, "" is an empty string.
+type Setting struct {
+	Name        string      `json:"name"`
+	Type        SettingType `json:"type"`
+	Default     any         `json:"default"`
+	Description string      `json:"description,omitempty"`
+}
+
+// SettingKey returns the config key of the source setting
+func SettingKey(source, setting string) string {
+	return fmt.Sprintf("sources.%s.%s", source, setting)
+}
+
+// parseSettings parses setting declarations of the header sorted by name
+func parseSettings(h header) ([]*Setting, error) {
+	const prefix = "setting."
+
+	settings := make([]*Setting, 0)
+	for k, v := range h {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+
+		setting, err := parseSetting(strings.TrimPrefix(k, prefix), v)
+		if err != nil {
+			return nil, err
+		}
+
+		settings = append(settings, setting)
+	}
+
+	sort.Slice(settings, func(i, j int) bool {
+		return settings[i].Name < settings[j].Name
+	})
+
+	return settings, nil
+}
+
+func parseSetting(name, declaration string) (*Setting, error) {
+	if !settingNameRegex.MatchString(name) {
+		return nil, fmt.Errorf("invalid setting name %q, use lowercase letters, digits and underscores", name)
+	}
+
+	typ, rest, _ := strings.Cut(strings.TrimSpace(declaration), " ")
+	rest = strings.TrimSpace(rest)
+
+	var raw string
+	if strings.HasPrefix(rest, `"`) {
+		quoted, err := strconv.QuotedPrefix(rest)
+		if err != nil {
+			return nil, fmt.Errorf("setting %s: invalid quoted default: %w", name, err)
+		}
+
+		raw, _ = strconv.Unquote(quoted)
+		rest = rest[len(quoted):]
+	} else {
+		raw, rest, _ = strings.Cut(rest, " ")
+	}
+
+	setting := &Setting{
+		Name:        name,
+		Type:        SettingType(typ),
+		Description: strings.TrimSpace(rest),
+	}
+
+	var err error
+	switch setting.Type {
+	case SettingString, SettingSecret:
+		setting.Default = raw
+	case SettingBool:
+		setting.Default, err = strconv.ParseBool(raw)
+	case SettingInt:
+		setting.Default, err = strconv.Atoi(raw)
+	case SettingList:
+		list := make([]string, 0)
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+
+		setting.Default = list
+	default:
+		return nil, fmt.Errorf("setting %s: unknown type %q, use string, secret, bool, int or list", name, typ)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("setting %s: invalid %s default %q", name, setting.Type, raw)
+	}
+
+	return setting, nil
+}
+
+// value returns the configured value of the setting or the default one if it is not set
+func (s *Setting) value(source string) any {
+	k := SettingKey(source, s.Name)
+	if !viper.IsSet(k) {
+		return s.Default
+	}
+
+	switch s.Type {
+	case SettingBool:
+		return viper.GetBool(k)
+	case SettingInt:
+		return viper.GetInt(k)
+	case SettingList:
+		return viper.GetStringSlice(k)
+	default:
+		return viper.GetString(k)
+	}
+}
+
+// preloadSettings adds the module with the values of the source settings
+//
+//	local settings = require("settings")
+//	print(settings.language)
+func preloadSettings(state *lua.LState, source string, settings []*Setting) {
+	state.PreloadModule(settingsModule, func(L *lua.LState) int {
+		module := L.NewTable()
+
+		for _, setting := range settings {
+			var value lua.LValue
+
+			switch v := setting.value(source).(type) {
+			case bool:
+				value = lua.LBool(v)
+			case int:
+				value = lua.LNumber(v)
+			case []string:
+				list := L.NewTable()
+				for _, item := range v {
+					list.Append(lua.LString(item))
+				}
+
+				value = list
+			default:
+				value = lua.LString(fmt.Sprint(v))
+			}
+
+			module.RawSetString(setting.Name, value)
+		}
+
+		L.Push(module)
+		return 1
+	})
+}
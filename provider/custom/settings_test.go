@@ -0,0 +1,131 @@
+package custom
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/metafates/mangal/filesystem"
+	"github.com/metafates/mangal/source"
+	"github.com/samber/lo"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/spf13/viper"
+)
+
+const settingsScript = `-- @name    configurable
+-- @setting.language string en Preferred language
+-- @setting.nsfw     bool   false
+-- @setting.quality  int    2 Image quality
+-- @setting.mirrors  list   "a, b"
+-- @setting.token    secret ""
+local settings = require("settings")
+
+function SearchManga(query)
+	local name = settings.language .. " " .. tostring(settings.nsfw) .. " " .. settings.quality .. " " .. table.concat(settings.mirrors, ",") .. " " .. settings.token
+	return { { name = name, url = "https://example.com" } }
+end
+
+function MangaChapters(mangaURL)
+	return {}
+end
+
+function ChapterPages(chapterURL)
+	return {}
+end
+`
+
+func TestParseSetting(t *testing.T) {
+	Convey("Given setting declarations", t, func() {
+		Convey("When a declaration is valid", func() {
+			setting, err := parseSetting("quality", `string "very high" Quality of the images`)
+
+			Convey("Then it should be parsed", func() {
+				So(err, ShouldBeNil)
+				So(setting.Type, ShouldEqual, SettingString)
+				So(setting.Default, ShouldEqual, "very high")
+				So(setting.Description, ShouldEqual, "Quality of the images")
+			})
+		})
+
+		Convey("When the default does not match the type", func() {
+			_, err := parseSetting("nsfw", "bool maybe")
+
+			Convey("Then it should fail", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+
+		Convey("When the type is unknown", func() {
+			_, err := parseSetting("ratio", "float 1.5")
+
+			Convey("Then it should fail", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+
+		Convey("When the name is invalid", func() {
+			_, err := parseSetting("Language", "string en")
+
+			Convey("Then it should fail", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
+
+func TestSettings(t *testing.T) {
+	Convey("Given a source with settings", t, func() {
+		path := "configurable.lua"
+		lo.Must0(filesystem.Api().WriteFile(path, []byte(settingsScript), 0644))
+
+		manifest, err := ReadManifest(path)
+		So(err, ShouldBeNil)
+
+		Convey("Then the manifest should have them sorted by name", func() {
+			names := lo.Map(manifest.Settings, func(s *Setting, _ int) string { return s.Name })
+			So(names, ShouldResemble, []string{"language", "mirrors", "nsfw", "quality", "token"})
+		})
+
+		search := func() string {
+			src, err := Load(path, &Options{Validate: true, NoCache: true})
+			So(err, ShouldBeNil)
+
+			mangas, err := src.(source.ContextSource).SearchContext(context.Background(), "test")
+			So(err, ShouldBeNil)
+			So(mangas, ShouldHaveLength, 1)
+
+			return mangas[0].Name
+		}
+
+		Convey("When the source is loaded without config", func() {
+			Convey("Then it should read default values", func() {
+				So(search(), ShouldEqual, "en false 2 a,b ")
+			})
+		})
+
+		Convey("When the settings are configured", func() {
+			for _, k := range []string{"language", "nsfw", "quality", "mirrors", "token"} {
+				k = SettingKey("configurable", k)
+				defer viper.Set(k, viper.Get(k))
+			}
+
+			viper.Set(SettingKey("configurable", "language"), "fr")
+			viper.Set(SettingKey("configurable", "nsfw"), "true")
+			viper.Set(SettingKey("configurable", "quality"), 3)
+			viper.Set(SettingKey("configurable", "mirrors"), []string{"c"})
+			viper.Set(SettingKey("configurable", "token"), "secret")
+
+			Convey("Then it should read configured values", func() {
+				So(search(), ShouldEqual, "fr true 3 c secret")
+			})
+		})
+	})
+
+	Convey("Given a source with an invalid setting", t, func() {
+		_, err := newManifest(lo.Must(parseHeader(strings.NewReader("-- @setting.count int many\n"))))
+
+		Convey("Then the manifest should fail", func() {
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
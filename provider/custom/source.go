@@ -1,7 +1,9 @@
 package custom
 
 import (
+	"context"
 	"fmt"
+	"sync"
 
 	"github.com/metafates/mangal/source"
 	lua "github.com/yuin/gopher-lua"
@@ -11,10 +13,15 @@ type luaSource struct {
 	name    string
 	stdLang string
 	state   *lua.LState
-	cache   struct {
+	sandbox *sandbox
+	// manifest is the header of the script
+	manifest *Manifest
+	cache    struct {
 		mangas   *cacher[[]*source.Manga]
 		chapters *cacher[[]*source.Chapter]
 	}
+	// mutex guards the state, which is not safe for concurrent use
+	mutex sync.Mutex
 }
 
 func (s *luaSource) Name() string {
@@ -25,24 +32,41 @@ func (s *luaSource) StdLang() string {
 	return s.stdLang
 }
 
-func newLuaSource(name string, state *lua.LState) (*luaSource, error) {
+func newLuaSource(name string, state *lua.LState, sandbox *sandbox, manifest *Manifest, cache bool) (*luaSource, error) {
 	s := &luaSource{
-		name:    name,
-		state:   state,
-		stdLang: "en",
+		name:     name,
+		state:    state,
+		sandbox:  sandbox,
+		manifest: manifest,
+		stdLang:  manifest.StdLang(),
 	}
 
 	cacheName := func(cacheFor string) string {
 		return fmt.Sprintf("%s_%s", s.ID(), cacheFor)
 	}
 
+	if !cache {
+		return s, nil
+	}
+
 	s.cache.mangas = newCacher[[]*source.Manga](cacheName("mangas"))
 	s.cache.chapters = newCacher[[]*source.Chapter](cacheName("chapters"))
 
 	return s, nil
 }
 
-func (s *luaSource) call(fn string, ret lua.LValueType, args ...lua.LValue) (lua.LValue, error) {
+// call calls the global function with the given arguments.
+// The context is attached to the state for the duration of the call,
+// so cancelling it stops the execution and in-flight http requests made by the source.
+// Exceeding the sandbox budget or violating its restrictions returns a *SandboxError.
+// The state must be locked by the caller.
+func (s *luaSource) call(ctx context.Context, fn string, ret lua.LValueType, args ...lua.LValue) (lua.LValue, error) {
+	limited, cancel := s.sandbox.limit(ctx)
+	defer cancel()
+
+	s.state.SetContext(limited)
+	defer s.state.RemoveContext()
+
 	err := s.state.CallByParam(lua.P{
 		Fn:      s.state.GetGlobal(fn),
 		NRet:    1,
@@ -50,13 +74,13 @@ func (s *luaSource) call(fn string, ret lua.LValueType, args ...lua.LValue) (lua
 	}, args...)
 
 	if err != nil {
-		return nil, err
+		return nil, s.sandbox.err(ctx, limited, err)
 	}
 
 	val := s.state.Get(-1)
 
 	if val.Type() != ret {
-		s.state.RaiseError(fn + " was expected to return a " + ret.String() + ", got " + val.Type().String())
+		return nil, fmt.Errorf("%s was expected to return a %s, got %s", fn, ret, val.Type())
 	}
 
 	return val, nil
@@ -0,0 +1,87 @@
+package custom
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/metafates/mangal/filesystem"
+	"github.com/metafates/mangal/source"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func init() {
+	filesystem.SetMemMapFs()
+}
+
+const testScript = `-- @modules http
+local http = require("http")
+local client = http.client()
+
+function SearchManga(query)
+	return {}
+end
+
+function MangaChapters(mangaURL)
+	return {}
+end
+
+function ChapterPages(chapterURL)
+	if chapterURL == "loop" then
+		while true do end
+	end
+
+	client:do_request(http.request("GET", chapterURL))
+	return {}
+end
+`
+
+func TestLuaSource_Context(t *testing.T) {
+	Convey("Given a lua source", t, func() {
+		path := "test.lua"
+		So(filesystem.Api().WriteFile(path, []byte(testScript), 0644), ShouldBeNil)
+
+		src, err := LoadSource(path, true)
+		So(err, ShouldBeNil)
+
+		ctxSrc, ok := src.(source.ContextSource)
+		So(ok, ShouldBeTrue)
+
+		Convey("When a long running function is cancelled", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			_, err := ctxSrc.PagesOfContext(ctx, &source.Chapter{URL: "loop"})
+
+			Convey("Then the context error should be returned", func() {
+				So(errors.Is(err, context.DeadlineExceeded), ShouldBeTrue)
+			})
+		})
+
+		Convey("When a hanging http request is cancelled", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				<-r.Context().Done()
+			}))
+			defer server.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			start := time.Now()
+			_, err := ctxSrc.PagesOfContext(ctx, &source.Chapter{URL: server.URL})
+
+			Convey("Then the request should be aborted", func() {
+				So(errors.Is(err, context.DeadlineExceeded), ShouldBeTrue)
+				So(time.Since(start), ShouldBeLessThan, 5*time.Second)
+			})
+
+			Convey("And the source should be usable afterwards", func() {
+				_, err := ctxSrc.SearchContext(context.Background(), "test")
+				So(err, ShouldBeNil)
+			})
+		})
+	})
+}
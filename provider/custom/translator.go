@@ -4,81 +4,293 @@ import (
 	"fmt"
 	"net/url"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/metafates/mangal/source"
 	"github.com/samber/lo"
 	lua "github.com/yuin/gopher-lua"
 )
 
-type mapping lo.Tuple4[lua.LValueType, bool, func(string) error, string]
-
-func translate(
-	table *lua.LTable,
-	mappings map[string]mapping,
-) (err error) {
-	for field, t := range mappings {
-		var (
-			type_    = t.A
-			required = t.B
-			handle   = t.C
-			default_ = t.D
-		)
-
-		val := table.RawGetString(field)
+// field of the table returned by a lua function
+type field struct {
+	// kind is the expected type of the value
+	kind lua.LValueType
+	// alt is the alternative type of the value, if any
+	alt lua.LValueType
+	// required fields must be present
+	required bool
+	// set is called with the value if it is present and of the expected type
+	set func(lua.LValue) error
+}
+
+func stringField(required bool, set func(string) error) field {
+	return field{
+		kind:     lua.LTString,
+		required: required,
+		set: func(v lua.LValue) error {
+			return set(v.String())
+		},
+	}
+}
+
+func numberField(set func(float64) error) field {
+	return field{
+		kind: lua.LTNumber,
+		set: func(v lua.LValue) error {
+			return set(float64(v.(lua.LNumber)))
+		},
+	}
+}
+
+// listField is either an array of strings or a comma separated string
+func listField(set func([]string)) field {
+	return field{
+		kind: lua.LTTable,
+		alt:  lua.LTString,
+		set: func(v lua.LValue) error {
+			if s, ok := v.(lua.LString); ok {
+				set(lo.FilterMap(strings.Split(string(s), ","), func(item string, _ int) (string, bool) {
+					item = strings.TrimSpace(item)
+					return item, item != ""
+				}))
+
+				return nil
+			}
+
+			var (
+				items []string
+				err   error
+			)
+
+			v.(*lua.LTable).ForEach(func(k lua.LValue, item lua.LValue) {
+				if err != nil {
+					return
+				}
+
+				if item.Type() != lua.LTString {
+					err = fmt.Errorf("must be an array of strings, got %s at %s", item.Type(), k)
+					return
+				}
+
+				items = append(items, item.String())
+			})
+
+			if err != nil {
+				return err
+			}
+
+			set(items)
+			return nil
+		},
+	}
+}
+
+// mapField is a table with string keys and string values
+func mapField(set func(map[string]string)) field {
+	return field{
+		kind: lua.LTTable,
+		set: func(v lua.LValue) error {
+			var (
+				m   = make(map[string]string)
+				err error
+			)
+
+			v.(*lua.LTable).ForEach(func(k lua.LValue, value lua.LValue) {
+				if err != nil {
+					return
+				}
+
+				if k.Type() != lua.LTString || value.Type() != lua.LTString {
+					err = fmt.Errorf("must be a table of strings with string keys, got %s = %s", k.Type(), value.Type())
+					return
+				}
+
+				m[k.String()] = value.String()
+			})
+
+			if err != nil {
+				return err
+			}
+
+			set(m)
+			return nil
+		},
+	}
+}
+
+// dateField is either a date string (2006-01-02, 2006-01, 2006 or RFC 3339) or a unix timestamp
+func dateField(set func(time.Time)) field {
+	return field{
+		kind: lua.LTString,
+		alt:  lua.LTNumber,
+		set: func(v lua.LValue) error {
+			if n, ok := v.(lua.LNumber); ok {
+				set(time.Unix(int64(n), 0).UTC())
+				return nil
+			}
+
+			for _, layout := range []string{time.RFC3339, "2006-01-02", "2006-01", "2006"} {
+				if t, err := time.Parse(layout, v.String()); err == nil {
+					set(t)
+					return nil
+				}
+			}
+
+			return fmt.Errorf("invalid date %q, expected YYYY-MM-DD or a unix timestamp", v.String())
+		},
+	}
+}
+
+// urlField is a string that must be a valid url
+func urlField(set func(string)) field {
+	return stringField(false, func(v string) error {
+		if v == "" {
+			return nil
+		}
+
+		if _, err := url.Parse(v); err != nil {
+			return err
+		}
+
+		set(v)
+		return nil
+	})
+}
+
+// numericField is a string that may also be given as a number
+func numericField(set func(string)) field {
+	return field{
+		kind: lua.LTString,
+		alt:  lua.LTNumber,
+		set: func(v lua.LValue) error {
+			set(v.String())
+			return nil
+		},
+	}
+}
+
+// enumField is a string that must be one of the values.
+// Values are compared case-insensitively with spaces and dashes treated as underscores
+func enumField(values []string, set func(string)) field {
+	return stringField(false, func(v string) error {
+		normalized := strings.ToUpper(strings.NewReplacer(" ", "_", "-", "_").Replace(strings.TrimSpace(v)))
+		if !lo.Contains(values, normalized) {
+			return fmt.Errorf("must be one of %s, got %q", strings.Join(values, ", "), v)
+		}
+
+		set(normalized)
+		return nil
+	})
+}
+
+func translate(table *lua.LTable, fields map[string]field) error {
+	// sorted, so that errors are reported in the same order
+	names := lo.Keys(fields)
+	sort.Strings(names)
+
+	for _, name := range names {
+		f := fields[name]
+		val := table.RawGetString(name)
+
 		if val.Type() == lua.LTNil {
-			if required {
-				err = fmt.Errorf(`field of "%s" is required`, field)
-			} else {
-				err = handle(default_)
+			if f.required {
+				return fmt.Errorf(`field of "%s" is required`, name)
 			}
-		} else if val.Type() != type_ {
-			err = fmt.Errorf(`field of "%s" must be of type %s`, field, type_)
-		} else {
-			err = handle(val.String())
+
+			continue
 		}
 
+		if val.Type() != f.kind && (f.alt == lua.LTNil || val.Type() != f.alt) {
+			return fmt.Errorf(`field of "%s" must be of type %s, got %s`, name, f.kind, val.Type())
+		}
+
+		if err := f.set(val); err != nil {
+			return fmt.Errorf(`field of "%s": %w`, name, err)
+		}
+	}
+
+	return nil
+}
+
+// forEachResult calls f for each value of the table returned by fn.
+// The table must be an array of tables
+func forEachResult(fn string, table *lua.LTable, f func(index uint16, value *lua.LTable) error) (err error) {
+	table.ForEach(func(k lua.LValue, v lua.LValue) {
 		if err != nil {
 			return
 		}
-	}
+
+		if k.Type() != lua.LTNumber {
+			err = fmt.Errorf("%s was expected to return a table with numbers as keys, got %s as a key", fn, k.Type())
+			return
+		}
+
+		if v.Type() != lua.LTTable {
+			err = fmt.Errorf("%s was expected to return a table with tables as values, got %s as a value", fn, v.Type())
+			return
+		}
+
+		index, parseErr := strconv.ParseUint(k.String(), 10, 16)
+		if parseErr != nil {
+			err = fmt.Errorf("%s was expected to return a table with unsigned integers as keys. %s", fn, parseErr)
+			return
+		}
+
+		err = f(uint16(index), v.(*lua.LTable))
+	})
 
 	return
 }
 
+// mangaStatuses are the valid values of the manga status
+var mangaStatuses = []string{"FINISHED", "RELEASING", "NOT_YET_RELEASED", "CANCELLED", "HIATUS"}
+
 func mangaFromTable(table *lua.LTable, index uint16) (manga *source.Manga, err error) {
 	manga = &source.Manga{
 		Index:    index,
 		Chapters: []*source.Chapter{},
 	}
 
-	mappings := map[string]mapping{
-		"name":    {A: lua.LTString, B: true, C: func(v string) error { manga.Name = v; return nil }},
-		"url":     {A: lua.LTString, B: true, C: func(v string) error { manga.URL = v; return nil }},
-		"summary": {A: lua.LTString, B: false, C: func(v string) error { manga.Metadata.Summary = v; return nil }},
-		"cover": {A: lua.LTString, B: false, C: func(v string) error {
-			if v == "" {
-				return nil
-			}
+	metadata := &manga.Metadata
 
-			_, err := url.Parse(v)
-			if err != nil {
-				return err
+	fields := map[string]field{
+		"name":        stringField(true, func(v string) error { manga.Name = v; return nil }),
+		"url":         stringField(true, func(v string) error { manga.URL = v; return nil }),
+		"id":          numericField(func(v string) { manga.ID = v }),
+		"summary":     stringField(false, func(v string) error { metadata.Summary = v; return nil }),
+		"cover":       urlField(func(v string) { metadata.Cover.ExtraLarge = v }),
+		"banner":      urlField(func(v string) { metadata.BannerImage = v }),
+		"genres":      listField(func(v []string) { metadata.Genres = v }),
+		"tags":        listField(func(v []string) { metadata.Tags = v }),
+		"characters":  listField(func(v []string) { metadata.Characters = v }),
+		"synonyms":    listField(func(v []string) { metadata.Synonyms = v }),
+		"urls":        listField(func(v []string) { metadata.URLs = v }),
+		"authors":     listField(func(v []string) { metadata.Staff.Story = v }),
+		"artists":     listField(func(v []string) { metadata.Staff.Art = v }),
+		"translators": listField(func(v []string) { metadata.Staff.Translation = v }),
+		"letterers":   listField(func(v []string) { metadata.Staff.Lettering = v }),
+		"status":      enumField(mangaStatuses, func(v string) { metadata.Status = v }),
+		"language":    stringField(false, func(v string) error { metadata.LanguageISO = v; return nil }),
+		"chapters": numberField(func(v float64) error {
+			if v < 0 {
+				return fmt.Errorf("must not be negative, got %v", v)
 			}
 
-			manga.Metadata.Cover.ExtraLarge = v
-			return nil
-		}},
-		"genres": {A: lua.LTString, B: false, C: func(v string) error {
-			manga.Metadata.Genres = lo.Map(strings.Split(v, ","), func(genre string, _ int) string {
-				return strings.TrimSpace(genre)
-			})
+			metadata.Chapters = int(v)
 			return nil
-		}},
+		}),
+		"start_date": dateField(func(t time.Time) {
+			metadata.StartDate.Year, metadata.StartDate.Month, metadata.StartDate.Day = t.Year(), int(t.Month()), t.Day()
+		}),
+		"end_date": dateField(func(t time.Time) {
+			metadata.EndDate.Year, metadata.EndDate.Month, metadata.EndDate.Day = t.Year(), int(t.Month()), t.Day()
+		}),
 	}
 
-	err = translate(table, mappings)
+	err = translate(table, fields)
 	return
 }
 
@@ -89,33 +301,24 @@ func chapterFromTable(table *lua.LTable, manga *source.Manga, index uint16) (cha
 		Pages: []*source.Page{},
 	}
 
-	mappings := map[string]mapping{
-		"name":          {A: lua.LTString, B: true, C: func(v string) error { chapter.Name = v; return nil }},
-		"url":           {A: lua.LTString, B: true, C: func(v string) error { chapter.URL = v; return nil }},
-		"volume":        {A: lua.LTString, B: false, C: func(v string) error { chapter.Volume = v; return nil }},
-		"number":        {A: lua.LTString, B: false, C: func(v string) error { chapter.Number = v; return nil }},
-		"manga_summary": {A: lua.LTString, B: false, C: func(v string) error { manga.Metadata.Summary = v; return nil }},
-		"manga_genres": {A: lua.LTString, B: false, C: func(v string) error {
-			manga.Metadata.Genres = lo.Map(strings.Split(v, ","), func(genre string, _ int) string {
-				return strings.TrimSpace(genre)
-			})
-			return nil
-		}},
-		"manga_cover": {A: lua.LTString, B: false, C: func(v string) error {
-			if v == "" {
-				return nil
-			}
-			_, err := url.Parse(v)
-			if err != nil {
-				return err
-			}
-
-			manga.Metadata.Cover.ExtraLarge = v
-			return nil
-		}},
+	fields := map[string]field{
+		"name":       stringField(true, func(v string) error { chapter.Name = v; return nil }),
+		"url":        stringField(true, func(v string) error { chapter.URL = v; return nil }),
+		"id":         numericField(func(v string) { chapter.ID = v }),
+		"volume":     numericField(func(v string) { chapter.Volume = v }),
+		"number":     numericField(func(v string) { chapter.Number = v }),
+		"scanlators": listField(func(v []string) { chapter.Scanlators = v }),
+		"date": dateField(func(t time.Time) {
+			chapter.Date.Year, chapter.Date.Month, chapter.Date.Day = t.Year(), int(t.Month()), t.Day()
+		}),
+		"manga_summary": stringField(false, func(v string) error { manga.Metadata.Summary = v; return nil }),
+		"manga_genres":  listField(func(v []string) { manga.Metadata.Genres = v }),
+		"manga_cover":   urlField(func(v string) { manga.Metadata.Cover.ExtraLarge = v }),
+		"manga_authors": listField(func(v []string) { manga.Metadata.Staff.Story = v }),
+		"manga_status":  enumField(mangaStatuses, func(v string) { manga.Metadata.Status = v }),
 	}
 
-	err = translate(table, mappings)
+	err = translate(table, fields)
 	manga.Chapters = append(manga.Chapters, chapter)
 	return
 }
@@ -125,10 +328,10 @@ func pageFromTable(table *lua.LTable, chapter *source.Chapter) (page *source.Pag
 		Chapter: chapter,
 	}
 
-	mappings := map[string]mapping{
-		"url": {A: lua.LTString, B: true, C: func(v string) error { page.URL = v; return nil }},
-		"index": {A: lua.LTNumber, B: true, C: func(v string) error {
-			num, err := strconv.ParseUint(v, 10, 16)
+	fields := map[string]field{
+		"url": stringField(true, func(v string) error { page.URL = v; return nil }),
+		"index": {kind: lua.LTNumber, required: true, set: func(v lua.LValue) error {
+			num, err := strconv.ParseUint(v.String(), 10, 16)
 			if err != nil {
 				return err
 			}
@@ -136,9 +339,11 @@ func pageFromTable(table *lua.LTable, chapter *source.Chapter) (page *source.Pag
 			page.Index = uint16(num)
 			return nil
 		}},
+		"headers": mapField(func(v map[string]string) { page.Headers = v }),
+		"cookies": mapField(func(v map[string]string) { page.Cookies = v }),
 	}
 
-	err = translate(table, mappings)
+	err = translate(table, fields)
 	if err != nil {
 		return
 	}
@@ -0,0 +1,183 @@
+package custom
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/metafates/mangal/anilist"
+	"github.com/metafates/mangal/source"
+	"github.com/samber/mo"
+	. "github.com/smartystreets/goconvey/convey"
+	lua "github.com/yuin/gopher-lua"
+)
+
+func luaTable(code string) *lua.LTable {
+	state := lua.NewState()
+	if err := state.DoString("return " + code); err != nil {
+		panic(err)
+	}
+
+	return state.CheckTable(-1)
+}
+
+func TestMangaFromTable(t *testing.T) {
+	Convey("Given a manga table with metadata", t, func() {
+		table := luaTable(`{
+			name = "Manga",
+			url = "https://example.com/manga",
+			id = 42,
+			summary = "Summary",
+			cover = "https://example.com/cover.jpg",
+			genres = "Action, Drama",
+			tags = { "Pirates", "Adventure" },
+			synonyms = { "Other name" },
+			authors = { "Author" },
+			artists = { "Artist" },
+			status = "not yet released",
+			language = "ja",
+			chapters = 100,
+			start_date = "1997-07-22",
+			end_date = 946684800,
+		}`)
+
+		Convey("When it is translated", func() {
+			manga, err := mangaFromTable(table, 1)
+			So(err, ShouldBeNil)
+
+			Convey("Then all fields should be set", func() {
+				So(manga.Name, ShouldEqual, "Manga")
+				So(manga.ID, ShouldEqual, "42")
+				So(manga.Metadata.Genres, ShouldResemble, []string{"Action", "Drama"})
+				So(manga.Metadata.Tags, ShouldResemble, []string{"Pirates", "Adventure"})
+				So(manga.Metadata.Synonyms, ShouldResemble, []string{"Other name"})
+				So(manga.Metadata.Staff.Story, ShouldResemble, []string{"Author"})
+				So(manga.Metadata.Staff.Art, ShouldResemble, []string{"Artist"})
+				So(manga.Metadata.Status, ShouldEqual, "NOT_YET_RELEASED")
+				So(manga.Metadata.LanguageISO, ShouldEqual, "ja")
+				So(manga.Metadata.Chapters, ShouldEqual, 100)
+				So(manga.Metadata.StartDate.Year, ShouldEqual, 1997)
+				So(manga.Metadata.StartDate.Month, ShouldEqual, 7)
+				So(manga.Metadata.StartDate.Day, ShouldEqual, 22)
+				So(manga.Metadata.EndDate.Year, ShouldEqual, 2000)
+			})
+
+			Convey("And metadata is populated from anilist", func() {
+				var found anilist.Manga
+				So(json.Unmarshal([]byte(`{
+					"status": "FINISHED",
+					"bannerImage": "https://anilist.co/banner.jpg",
+					"characters": { "nodes": [ { "name": { "full": "Character" } } ] },
+					"staff": { "edges": [ { "role": "Story & Art", "node": { "name": { "full": "Anilist Author" } } } ] },
+					"startDate": { "year": 1990 }
+				}`), &found), ShouldBeNil)
+
+				// bound manga are not searched on anilist
+				manga.Anilist = mo.Some(&found)
+				So(manga.PopulateMetadata(func(string) {}), ShouldBeNil)
+
+				Convey("Then fields of the source should be kept", func() {
+					So(manga.Metadata.Status, ShouldEqual, "NOT_YET_RELEASED")
+					So(manga.Metadata.Staff.Story, ShouldResemble, []string{"Author"})
+					So(manga.Metadata.Staff.Art, ShouldResemble, []string{"Artist"})
+					So(manga.Metadata.StartDate.Year, ShouldEqual, 1997)
+					So(manga.Metadata.Summary, ShouldEqual, "Summary")
+				})
+
+				Convey("Then empty fields should be filled from anilist", func() {
+					So(manga.Metadata.BannerImage, ShouldEqual, "https://anilist.co/banner.jpg")
+					So(manga.Metadata.Characters, ShouldResemble, []string{"Character"})
+				})
+			})
+		})
+	})
+
+	Convey("Given manga tables with invalid fields", t, func() {
+		for code, message := range map[string]string{
+			`{ url = "u" }`: `"name" is required`,
+			`{ name = "n", url = "u", status = "dropped" }`:  `"status": must be one of`,
+			`{ name = "n", url = "u", tags = { 1, 2 } }`:     `"tags": must be an array of strings`,
+			`{ name = "n", url = "u", start_date = "July" }`: `"start_date": invalid date`,
+			`{ name = "n", url = "u", chapters = "many" }`:   `"chapters" must be of type number`,
+			`{ name = "n", url = "u", authors = true }`:      `"authors" must be of type table`,
+			`{ name = "n", url = "u", chapters = -1 }`:       `must not be negative`,
+		} {
+			Convey("When "+code+" is translated", func() {
+				_, err := mangaFromTable(luaTable(code), 1)
+
+				Convey("Then the error should be returned", func() {
+					So(err, ShouldNotBeNil)
+					So(err.Error(), ShouldContainSubstring, message)
+				})
+			})
+		}
+	})
+}
+
+func TestChapterFromTable(t *testing.T) {
+	Convey("Given a chapter table with metadata", t, func() {
+		table := luaTable(`{
+			name = "Chapter 1",
+			url = "https://example.com/1",
+			number = 1.5,
+			volume = "2",
+			date = "2020-05-17T10:00:00Z",
+			scanlators = { "Group" },
+			manga_status = "finished",
+		}`)
+
+		Convey("When it is translated", func() {
+			manga := &source.Manga{Name: "Manga"}
+			manga.Metadata.Summary = "Summary"
+
+			chapter, err := chapterFromTable(table, manga, 1)
+			So(err, ShouldBeNil)
+
+			Convey("Then all fields should be set", func() {
+				So(chapter.Number, ShouldEqual, "1.5")
+				So(chapter.Volume, ShouldEqual, "2")
+				So(chapter.Date.Year, ShouldEqual, 2020)
+				So(chapter.Date.Day, ShouldEqual, 17)
+				So(chapter.Scanlators, ShouldResemble, []string{"Group"})
+				So(manga.Metadata.Status, ShouldEqual, "FINISHED")
+			})
+
+			Convey("Then missing manga fields should not overwrite the manga", func() {
+				So(manga.Metadata.Summary, ShouldEqual, "Summary")
+			})
+		})
+	})
+}
+
+func TestPageFromTable(t *testing.T) {
+	Convey("Given a page table with headers and cookies", t, func() {
+		table := luaTable(`{
+			url = "https://example.com/1.jpg",
+			index = 1,
+			headers = { Referer = "https://example.com/" },
+			cookies = { session = "secret" },
+		}`)
+
+		Convey("When it is translated", func() {
+			page, err := pageFromTable(table, &source.Chapter{})
+			So(err, ShouldBeNil)
+
+			Convey("Then headers and cookies should be set", func() {
+				So(page.Headers, ShouldResemble, map[string]string{"Referer": "https://example.com/"})
+				So(page.Cookies, ShouldResemble, map[string]string{"session": "secret"})
+			})
+		})
+	})
+
+	Convey("Given a page table with invalid headers", t, func() {
+		table := luaTable(`{ url = "u", index = 1, headers = { "no key" } }`)
+
+		Convey("When it is translated", func() {
+			_, err := pageFromTable(table, &source.Chapter{})
+
+			Convey("Then the error should be returned", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, `"headers"`)
+			})
+		})
+	})
+}
@@ -1,6 +1,7 @@
 package generic
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/gocolly/colly/v2"
@@ -10,14 +11,24 @@ import (
 
 // ChaptersOf given source.Manga
 func (s *Scraper) ChaptersOf(manga *source.Manga) ([]*source.Chapter, error) {
+	return s.ChaptersOfContext(context.Background(), manga)
+}
+
+// ChaptersOfContext is like ChaptersOf but cancels requests when the context is done
+func (s *Scraper) ChaptersOfContext(ctx context.Context, manga *source.Manga) ([]*source.Chapter, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
 	address_path := util.UrlGetPath(manga.URL)
 	if chapters, ok := s.chapters[address_path]; ok {
 		return chapters, nil
 	}
 
-	ctx := colly.NewContext()
-	ctx.Put("manga", manga)
-	err := s.chaptersCollector.Request(http.MethodGet, manga.URL, nil, ctx, nil)
+	collyCtx := colly.NewContext()
+	collyCtx.Put("manga", manga)
+	defer s.transport.use(ctx)()
+
+	err := s.chaptersCollector.Request(http.MethodGet, manga.URL, nil, collyCtx, nil)
 
 	if err != nil {
 		return nil, err
@@ -25,6 +36,10 @@ func (s *Scraper) ChaptersOf(manga *source.Manga) ([]*source.Chapter, error) {
 
 	s.chaptersCollector.Wait()
 
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	if s.config.ReverseChapters {
 		// reverse chapters
 		chapters := s.chapters[address_path]
@@ -22,6 +22,10 @@ type Extractor struct {
 	Cover func(*goquery.Selection) string
 	// Language function to get language from element found by selector. Used by manga extractor
 	Language func(*goquery.Selection) string
+	// Headers function to get headers of the image request from element found by selector. Used by pages extractor
+	Headers func(*goquery.Selection) map[string]string
+	// Cookies function to get cookies of the image request from element found by selector. Used by pages extractor
+	Cookies func(*goquery.Selection) map[string]string
 }
 
 // Configuration is a generic scraper configuration that defines behavior of the scraper
@@ -37,6 +41,8 @@ type Configuration struct {
 
 	// ReverseChapters if true, chapters will be shown in reverse order
 	ReverseChapters bool
+	// Custom is true if the configuration is loaded from the user definition
+	Custom bool
 
 	// BaseURL of the source
 	BaseURL string
@@ -53,5 +59,9 @@ type Configuration struct {
 }
 
 func (c *Configuration) ID() string {
+	if c.Custom {
+		return c.Name + " custom"
+	}
+
 	return c.Name + " built-in"
 }
@@ -0,0 +1,306 @@
+package generic
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/metafates/mangal/filesystem"
+	"github.com/metafates/mangal/util"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// DefinitionExtensions are extensions of the definition files
+var DefinitionExtensions = []string{".toml", ".yaml", ".yml"}
+
+// queryPlaceholder is replaced with the escaped query in the search url
+const queryPlaceholder = "{query}"
+
+// Definition is a declarative scraper. It is loaded from toml or yaml file
+//
+//	base_url   = "https://example.com"
+//	search_url = "https://example.com/search?q={query}"
+//
+//	[manga]
+//	selector = ".manga"
+//	name     = { selector = "a.title", trim = true }
+//	url      = { selector = "a.title", attr = "href" }
+//
+//	[chapter]
+//	selector = ".chapters li"
+//	name     = { selector = "a", trim = true }
+//	url      = { selector = "a", attr = "href" }
+//	number   = { selector = "a", regex = 'Chapter (\d+)' }
+//
+//	[page]
+//	selector = ".reader img"
+//	url      = { attr = "data-src", trim = true, absolute = true }
+type Definition struct {
+	// Name of the scraper, used only for the info
+	Name string `toml:"name" yaml:"name"`
+	// Version of the definition
+	Version string `toml:"version" yaml:"version"`
+	// Author of the definition
+	Author string `toml:"author" yaml:"author"`
+	// Lang is the standard language of the scraper, "en" by default
+	Lang string `toml:"lang" yaml:"lang"`
+	// Mangal is the minimum version of mangal the definition works with
+	Mangal string `toml:"mangal" yaml:"mangal"`
+
+	BaseURL   string `toml:"base_url" yaml:"base_url"`
+	SearchURL string `toml:"search_url" yaml:"search_url"`
+	// Delay between requests as duration, e.g. 50ms
+	Delay           string `toml:"delay" yaml:"delay"`
+	Parallelism     uint8  `toml:"parallelism" yaml:"parallelism"`
+	ReverseChapters bool   `toml:"reverse_chapters" yaml:"reverse_chapters"`
+
+	Manga   ElementDefinition `toml:"manga" yaml:"manga"`
+	Chapter ElementDefinition `toml:"chapter" yaml:"chapter"`
+	Page    ElementDefinition `toml:"page" yaml:"page"`
+}
+
+// ElementDefinition declares how to find elements and extract their data
+type ElementDefinition struct {
+	// Selector of the elements
+	Selector string `toml:"selector" yaml:"selector"`
+
+	Name     *ValueDefinition `toml:"name" yaml:"name"`
+	URL      *ValueDefinition `toml:"url" yaml:"url"`
+	Volume   *ValueDefinition `toml:"volume" yaml:"volume"`
+	Number   *ValueDefinition `toml:"number" yaml:"number"`
+	Cover    *ValueDefinition `toml:"cover" yaml:"cover"`
+	Language *ValueDefinition `toml:"language" yaml:"language"`
+
+	// Headers to send with the image request. Used by page
+	Headers map[string]string `toml:"headers" yaml:"headers"`
+	// Cookies to send with the image request. Used by page
+	Cookies map[string]string `toml:"cookies" yaml:"cookies"`
+}
+
+// ValueDefinition declares how to extract a value from the element.
+// Transforms are applied in the order: trim, regex, absolute
+type ValueDefinition struct {
+	// Selector of the child element, the element itself if empty
+	Selector string `toml:"selector" yaml:"selector"`
+	// Attr to get the value from, the text is used if empty
+	Attr string `toml:"attr" yaml:"attr"`
+	// Value is the constant value, the element is not used if set
+	Value string `toml:"value" yaml:"value"`
+	// Trim spaces around the value
+	Trim bool `toml:"trim" yaml:"trim"`
+	// Regex to match the value with. The first capture group is used, the whole match if there are no groups
+	Regex string `toml:"regex" yaml:"regex"`
+	// Absolute resolves the value as url relative to the base url
+	Absolute bool `toml:"absolute" yaml:"absolute"`
+}
+
+// ReadDefinition reads the definition from the toml or yaml file
+func ReadDefinition(path string) (*Definition, error) {
+	data, err := filesystem.Api().ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// unknown fields are rejected, so that typos are not ignored
+	var definition Definition
+	if filepath.Ext(path) == ".toml" {
+		decoder := toml.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&definition)
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&definition)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid definition %s: %w", path, err)
+	}
+
+	if definition.Lang == "" {
+		definition.Lang = "en"
+	}
+
+	if definition.Parallelism == 0 {
+		definition.Parallelism = 10
+	}
+
+	return &definition, nil
+}
+
+// LoadDefinition reads the definition from the file and creates the configuration.
+// Name of the configuration is the name of the file
+func LoadDefinition(path string) (*Configuration, error) {
+	definition, err := ReadDefinition(path)
+	if err != nil {
+		return nil, err
+	}
+
+	conf, err := definition.Configuration(util.FileStem(path))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return conf, nil
+}
+
+// Configuration creates the generic configuration with the given name from the definition
+func (d *Definition) Configuration(name string) (*Configuration, error) {
+	base, err := url.Parse(d.BaseURL)
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("invalid base_url %q", d.BaseURL)
+	}
+
+	if !strings.Contains(d.SearchURL, queryPlaceholder) {
+		return nil, fmt.Errorf("search_url must contain %s", queryPlaceholder)
+	}
+
+	var delay time.Duration
+	if d.Delay != "" {
+		if delay, err = time.ParseDuration(d.Delay); err != nil {
+			return nil, fmt.Errorf("invalid delay %q", d.Delay)
+		}
+	}
+
+	conf := &Configuration{
+		Name:            name,
+		StdLang:         d.Lang,
+		Delay:           delay,
+		Parallelism:     d.Parallelism,
+		ReverseChapters: d.ReverseChapters,
+		BaseURL:         d.BaseURL,
+		Custom:          true,
+		GenerateSearchURL: func(query string) string {
+			query = url.QueryEscape(strings.TrimSpace(query))
+			return strings.ReplaceAll(d.SearchURL, queryPlaceholder, query)
+		},
+	}
+
+	var (
+		b     = &extractorBuilder{base: base}
+		blank = func(*goquery.Selection) string { return "" }
+		lang  = func(*goquery.Selection) string { return d.Lang }
+	)
+
+	conf.MangaExtractor = &Extractor{
+		Selector: b.selector("manga", d.Manga.Selector),
+		Name:     b.value("manga.name", d.Manga.Name, nil),
+		URL:      b.value("manga.url", d.Manga.URL, nil),
+		Cover:    b.value("manga.cover", d.Manga.Cover, blank),
+		Language: b.value("manga.language", d.Manga.Language, lang),
+	}
+
+	conf.ChapterExtractor = &Extractor{
+		Selector: b.selector("chapter", d.Chapter.Selector),
+		Name:     b.value("chapter.name", d.Chapter.Name, nil),
+		URL:      b.value("chapter.url", d.Chapter.URL, nil),
+		Volume:   b.value("chapter.volume", d.Chapter.Volume, blank),
+		Number:   b.value("chapter.number", d.Chapter.Number, blank),
+	}
+
+	conf.PageExtractor = &Extractor{
+		Selector: b.selector("page", d.Page.Selector),
+		URL:      b.value("page.url", d.Page.URL, nil),
+	}
+
+	if len(d.Page.Headers) > 0 {
+		conf.PageExtractor.Headers = func(*goquery.Selection) map[string]string {
+			return d.Page.Headers
+		}
+	}
+
+	if len(d.Page.Cookies) > 0 {
+		conf.PageExtractor.Cookies = func(*goquery.Selection) map[string]string {
+			return d.Page.Cookies
+		}
+	}
+
+	if len(b.errs) > 0 {
+		return nil, errors.New(strings.Join(b.errs, ", "))
+	}
+
+	return conf, nil
+}
+
+// extractorBuilder creates extractor functions from the definitions and collects their errors
+type extractorBuilder struct {
+	base *url.URL
+	errs []string
+}
+
+func (b *extractorBuilder) selector(field, selector string) string {
+	if selector == "" {
+		b.errs = append(b.errs, field+".selector is required")
+	}
+
+	return selector
+}
+
+// value creates the function that extracts the value.
+// If the definition is nil, fallback is used, a nil fallback means the value is required
+func (b *extractorBuilder) value(field string, v *ValueDefinition, fallback func(*goquery.Selection) string) func(*goquery.Selection) string {
+	if v == nil {
+		if fallback == nil {
+			b.errs = append(b.errs, field+" is required")
+		}
+
+		return fallback
+	}
+
+	var re *regexp.Regexp
+	if v.Regex != "" {
+		var err error
+		if re, err = regexp.Compile(v.Regex); err != nil {
+			b.errs = append(b.errs, fmt.Sprintf("%s.regex is invalid: %s", field, err))
+			return fallback
+		}
+	}
+
+	return func(selection *goquery.Selection) string {
+		value := v.Value
+
+		if value == "" {
+			if v.Selector != "" {
+				selection = selection.Find(v.Selector).First()
+			}
+
+			if v.Attr != "" {
+				value = selection.AttrOr(v.Attr, "")
+			} else {
+				value = selection.Text()
+			}
+		}
+
+		if v.Trim {
+			value = strings.TrimSpace(value)
+		}
+
+		if re != nil {
+			match := re.FindStringSubmatch(value)
+
+			switch {
+			case match == nil:
+				value = ""
+			case len(match) > 1:
+				value = match[1]
+			default:
+				value = match[0]
+			}
+		}
+
+		if v.Absolute && value != "" {
+			if ref, err := url.Parse(value); err == nil {
+				value = b.base.ResolveReference(ref).String()
+			}
+		}
+
+		return value
+	}
+}
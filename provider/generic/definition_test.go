@@ -0,0 +1,156 @@
+package generic
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/metafates/mangal/filesystem"
+	"github.com/samber/lo"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const testSite = `<html><body>
+<div class="manga"><a class="title" href="/manga/one"> One </a><img data-src="/covers/one.png"></div>
+<ul class="chapters">
+	<li><a href="/manga/one/2">Vol.1 Chapter 2</a></li>
+	<li><a href="/manga/one/1">Vol.1 Chapter 1</a></li>
+</ul>
+<div class="reader"><img data-src=" /pages/1.png "><img data-src=" /pages/2.png "></div>
+</body></html>`
+
+const testDefinition = `
+name: Example
+version: 1.0.0
+base_url: %s
+search_url: %s/search?q={query}
+delay: 10ms
+manga:
+  selector: .manga
+  name: { selector: a.title, trim: true }
+  url: { selector: a.title, attr: href }
+  cover: { selector: img, attr: data-src, absolute: true }
+chapter:
+  selector: .chapters li
+  name: { selector: a, regex: 'Chapter \d+' }
+  url: { selector: a, attr: href }
+  volume: { selector: a, regex: 'Vol\.(\d+)' }
+page:
+  selector: .reader img
+  url: { attr: data-src, trim: true, absolute: true }
+  headers:
+    Referer: https://example.com/
+  cookies:
+    SessionID: abc
+`
+
+func TestDefinition(t *testing.T) {
+	filesystem.SetMemMapFs()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(testSite))
+	}))
+	defer server.Close()
+
+	Convey("Given a yaml definition", t, func() {
+		path := "example.yaml"
+		lo.Must0(filesystem.Api().WriteFile(path, []byte(fmt.Sprintf(testDefinition, server.URL, server.URL)), 0644))
+
+		Convey("When it is loaded", func() {
+			conf, err := LoadDefinition(path)
+			So(err, ShouldBeNil)
+
+			Convey("Then the configuration should be custom and named after the file", func() {
+				So(conf.Name, ShouldEqual, "example")
+				So(conf.ID(), ShouldEqual, "example custom")
+				So(conf.StdLang, ShouldEqual, "en")
+				So(conf.GenerateSearchURL(" one piece "), ShouldEqual, server.URL+"/search?q=one+piece")
+			})
+
+			Convey("Then the scraper should extract mangas, chapters and pages", func() {
+				scraper := New(conf)
+
+				mangas, err := scraper.Search("one")
+				So(err, ShouldBeNil)
+				So(mangas, ShouldHaveLength, 1)
+				So(mangas[0].Name, ShouldEqual, "One")
+				So(mangas[0].URL, ShouldEqual, server.URL+"/manga/one")
+				So(mangas[0].Metadata.Cover.ExtraLarge, ShouldEqual, server.URL+"/covers/one.png")
+				So(mangas[0].Metadata.LanguageISO, ShouldEqual, "en")
+
+				chapters, err := scraper.ChaptersOf(mangas[0])
+				So(err, ShouldBeNil)
+				So(chapters, ShouldHaveLength, 2)
+				So(chapters[0].Name, ShouldEqual, "Chapter 2")
+				So(chapters[0].Volume, ShouldEqual, "1")
+
+				pages, err := scraper.PagesOf(chapters[0])
+				So(err, ShouldBeNil)
+				So(pages, ShouldHaveLength, 2)
+				So(pages[0].URL, ShouldEqual, server.URL+"/pages/1.png")
+				So(pages[0].Headers, ShouldResemble, map[string]string{"Referer": "https://example.com/"})
+				So(pages[0].Cookies, ShouldResemble, map[string]string{"SessionID": "abc"})
+			})
+		})
+	})
+
+	Convey("Given a toml definition", t, func() {
+		path := "example.toml"
+		definition := `
+base_url   = "https://example.com"
+search_url = "https://example.com/search/{query}"
+
+[manga]
+selector = ".manga"
+name     = { selector = "a" }
+url      = { selector = "a", attr = "href" }
+
+[chapter]
+selector = "li"
+name     = { selector = "a" }
+url      = { selector = "a", attr = "href" }
+
+[page]
+selector = "img"
+url      = { attr = "src" }
+`
+		lo.Must0(filesystem.Api().WriteFile(path, []byte(definition), 0644))
+
+		Convey("When it is loaded", func() {
+			conf, err := LoadDefinition(path)
+
+			Convey("Then it should succeed", func() {
+				So(err, ShouldBeNil)
+				So(conf.BaseURL, ShouldEqual, "https://example.com")
+			})
+		})
+	})
+
+	Convey("Given invalid definitions", t, func() {
+		for name, definition := range map[string]string{
+			"unknown field":    "base_url = \"https://example.com\"\nsearch_ur = \"https://example.com/{query}\"",
+			"no placeholder":   "base_url = \"https://example.com\"\nsearch_url = \"https://example.com/search\"",
+			"missing selector": "base_url = \"https://example.com\"\nsearch_url = \"https://example.com/{query}\"",
+			"invalid regex": strings.Join([]string{
+				`base_url = "https://example.com"`,
+				`search_url = "https://example.com/{query}"`,
+				`[manga]`,
+				`selector = "a"`,
+				`name = { regex = "(" }`,
+			}, "\n"),
+		} {
+			path := strings.ReplaceAll(name, " ", "-") + ".toml"
+			lo.Must0(filesystem.Api().WriteFile(path, []byte(definition), 0644))
+
+			Convey("When "+name+" is loaded", func() {
+				_, err := LoadDefinition(path)
+
+				Convey("Then it should fail", func() {
+					So(err, ShouldNotBeNil)
+				})
+			})
+		}
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/PuerkitoBio/goquery"
 	"github.com/gocolly/colly/v2"
 	"github.com/metafates/mangal/constant"
+	"github.com/metafates/mangal/network"
 	"github.com/metafates/mangal/source"
 	"github.com/metafates/mangal/util"
 	"github.com/metafates/mangal/where"
@@ -20,6 +21,10 @@ func New(conf *Configuration) source.Source {
 		chapters: make(map[string][]*source.Chapter),
 		pages:    make(map[string][]*source.Page),
 		config:   conf,
+		transport: &contextTransport{
+			base:   network.Transport,
+			source: conf.Name,
+		},
 	}
 
 	collectorOptions := []colly.CollectorOption{
@@ -30,6 +35,8 @@ func New(conf *Configuration) source.Source {
 
 	baseCollector := colly.NewCollector(collectorOptions...)
 	baseCollector.SetRequestTimeout(20 * time.Second)
+	// cloned collectors share the http client of the base one
+	baseCollector.WithTransport(s.transport)
 
 	mangasCollector := baseCollector.Clone()
 	mangasCollector.OnRequest(func(r *colly.Request) {
@@ -140,6 +147,15 @@ func New(conf *Configuration) source.Source {
 				Chapter:   chapter,
 				Extension: ext,
 			}
+
+			if s.config.PageExtractor.Headers != nil {
+				page.Headers = s.config.PageExtractor.Headers(selection)
+			}
+
+			if s.config.PageExtractor.Cookies != nil {
+				page.Cookies = s.config.PageExtractor.Cookies(selection)
+			}
+
 			s.pages[path][i] = &page
 		})
 		chapter.Pages = s.pages[path]
@@ -1,6 +1,7 @@
 package generic
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/gocolly/colly/v2"
@@ -10,14 +11,24 @@ import (
 
 // PagesOf given source.Chapter
 func (s *Scraper) PagesOf(chapter *source.Chapter) ([]*source.Page, error) {
+	return s.PagesOfContext(context.Background(), chapter)
+}
+
+// PagesOfContext is like PagesOf but cancels requests when the context is done
+func (s *Scraper) PagesOfContext(ctx context.Context, chapter *source.Chapter) ([]*source.Page, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
 	address_path := util.UrlGetPath(chapter.URL)
 	if pages, ok := s.pages[address_path]; ok {
 		return pages, nil
 	}
 
-	ctx := colly.NewContext()
-	ctx.Put("chapter", chapter)
-	err := s.pagesCollector.Request(http.MethodGet, chapter.URL, nil, ctx, nil)
+	collyCtx := colly.NewContext()
+	collyCtx.Put("chapter", chapter)
+	defer s.transport.use(ctx)()
+
+	err := s.pagesCollector.Request(http.MethodGet, chapter.URL, nil, collyCtx, nil)
 
 	if err != nil {
 		return nil, err
@@ -25,5 +36,9 @@ func (s *Scraper) PagesOf(chapter *source.Chapter) ([]*source.Page, error) {
 
 	s.pagesCollector.Wait()
 
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	return s.pages[address_path], nil
 }
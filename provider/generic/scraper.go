@@ -1,7 +1,12 @@
 package generic
 
 import (
+	"context"
+	"net/http"
+	"sync"
+
 	"github.com/gocolly/colly/v2"
+	"github.com/metafates/mangal/network"
 	"github.com/metafates/mangal/source"
 )
 
@@ -14,8 +19,13 @@ type Scraper struct {
 	mangas   map[string][]*source.Manga
 	chapters map[string][]*source.Chapter
 	pages    map[string][]*source.Page
+	// mutex serializes calls, since collectors and the transport are shared
+	mutex sync.Mutex
 
 	config *Configuration
+
+	// transport is shared by all collectors
+	transport *contextTransport
 }
 
 // Name of the scraper
@@ -32,3 +42,43 @@ func (s *Scraper) StdLang() string {
 func (s *Scraper) ID() string {
 	return s.config.ID()
 }
+
+// contextTransport attaches the context of the current call to each request made by collectors.
+// Colly does not support contexts per request, so the context is set before each visit.
+type contextTransport struct {
+	base http.RoundTripper
+	// source is the name of the scraper, it's attached to the requests for the rate limiter
+	source string
+	mutex  sync.RWMutex
+	ctx    context.Context
+}
+
+// use sets the context to attach to the requests.
+// Returned function must be called to reset it when the call is done.
+func (t *contextTransport) use(ctx context.Context) (reset func()) {
+	t.mutex.Lock()
+	t.ctx = ctx
+	t.mutex.Unlock()
+
+	return func() {
+		t.mutex.Lock()
+		t.ctx = nil
+		t.mutex.Unlock()
+	}
+}
+
+func (t *contextTransport) context() context.Context {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	return t.ctx
+}
+
+func (t *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := t.context()
+	if ctx == nil {
+		ctx = req.Context()
+	}
+
+	return t.base.RoundTrip(req.WithContext(network.WithSource(ctx, t.source)))
+}
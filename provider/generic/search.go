@@ -1,18 +1,29 @@
 package generic
 
 import (
+	"context"
 	"github.com/metafates/mangal/source"
 	"github.com/metafates/mangal/util"
 )
 
 // Search for mangas by given title
 func (s *Scraper) Search(query string) ([]*source.Manga, error) {
+	return s.SearchContext(context.Background(), query)
+}
+
+// SearchContext is like Search but cancels requests when the context is done
+func (s *Scraper) SearchContext(ctx context.Context, query string) ([]*source.Manga, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
 	address := s.config.GenerateSearchURL(query)
 	address_path := util.UrlGetPath(address)
 	if urls, ok := s.mangas[address_path]; ok {
 		return urls, nil
 	}
 
+	defer s.transport.use(ctx)()
+
 	err := s.mangasCollector.Visit(address)
 
 	if err != nil {
@@ -20,5 +31,10 @@ func (s *Scraper) Search(query string) ([]*source.Manga, error) {
 	}
 
 	s.mangasCollector.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	return s.mangas[address_path], nil
 }
@@ -2,6 +2,7 @@ package mangadex
 
 import (
 	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/metafates/gache"
@@ -10,8 +11,13 @@ import (
 	"github.com/samber/mo"
 )
 
+// cacher is safe for concurrent use.
+// Maps stored in the cache are never modified, Set stores a copy instead,
+// since Get of gache returns the stored map itself
 type cacher[T any] struct {
 	internal *gache.Cache[map[string]T]
+	// mutex serializes Set, so that concurrent updates are not lost
+	mutex sync.Mutex
 }
 
 func newCacher[T any](name string) *cacher[T] {
@@ -40,15 +46,21 @@ func (c *cacher[T]) Get(key string) mo.Option[T] {
 }
 
 func (c *cacher[T]) Set(key string, value T) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
 	cached, expired, err := c.internal.Get()
 	if err != nil {
 		return err
 	}
 
-	if expired || cached == nil {
-		cached = map[string]T{}
+	updated := make(map[string]T, len(cached)+1)
+	if !expired {
+		for k, v := range cached {
+			updated[k] = v
+		}
 	}
 
-	cached[key] = value
-	return c.internal.Set(cached)
+	updated[key] = value
+	return c.internal.Set(updated)
 }
@@ -0,0 +1,38 @@
+package mangadex
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/metafates/mangal/filesystem"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCacher(t *testing.T) {
+	filesystem.SetMemMapFs()
+
+	Convey("Given a cacher", t, func() {
+		cache := newCacher[int]("concurrent")
+
+		Convey("When it is used concurrently", func(c C) {
+			var wg sync.WaitGroup
+			for i := 0; i < 50; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					key := strconv.Itoa(i)
+					c.So(cache.Set(key, i), ShouldBeNil)
+					cache.Get(key)
+				}(i)
+			}
+			wg.Wait()
+
+			Convey("Then no value should be lost", func() {
+				for i := 0; i < 50; i++ {
+					So(cache.Get(strconv.Itoa(i)).OrEmpty(), ShouldEqual, i)
+				}
+			})
+		})
+	})
+}
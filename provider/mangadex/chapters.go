@@ -1,6 +1,7 @@
 package mangadex
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	"strconv"
@@ -13,6 +14,11 @@ import (
 )
 
 func (m *Mangadex) ChaptersOf(manga *source.Manga) ([]*source.Chapter, error) {
+	return m.ChaptersOfContext(context.Background(), manga)
+}
+
+// ChaptersOfContext is like ChaptersOf but cancels requests when the context is done
+func (m *Mangadex) ChaptersOfContext(ctx context.Context, manga *source.Manga) ([]*source.Chapter, error) {
 	if cached, ok := m.cache.chapters.Get(manga.URL).Get(); ok {
 		for _, chapter := range cached {
 			chapter.Manga = manga
@@ -46,7 +52,7 @@ func (m *Mangadex) ChaptersOf(manga *source.Manga) ([]*source.Chapter, error) {
 
 	for {
 		params.Set("offset", strconv.Itoa(currOffset))
-		list, err := m.client.Chapter.GetMangaChapters(manga.ID, params)
+		list, err := m.client.Chapter.GetMangaChaptersContext(withSource(ctx), manga.ID, params)
 		if err != nil {
 			return nil, err
 		}
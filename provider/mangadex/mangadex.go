@@ -1,7 +1,10 @@
 package mangadex
 
 import (
+	"context"
+
 	"github.com/darylhjd/mangodex"
+	"github.com/metafates/mangal/network"
 	"github.com/metafates/mangal/source"
 )
 
@@ -40,3 +43,9 @@ func New() *Mangadex {
 
 	return dex
 }
+
+// withSource attaches the name of the source to the context of API requests for the rate limiter.
+// Mangodex uses the default transport, which is the configured one
+func withSource(ctx context.Context) context.Context {
+	return network.WithSource(ctx, Name)
+}
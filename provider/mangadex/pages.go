@@ -1,43 +1,45 @@
 package mangadex
 
 import (
-	"bytes"
+	"context"
 	"errors"
-	"github.com/metafates/mangal/source"
+	"fmt"
+	"net/http"
 	"path/filepath"
+	"strings"
+
+	"github.com/darylhjd/mangodex"
+	"github.com/metafates/mangal/source"
 )
 
 func (m *Mangadex) PagesOf(chapter *source.Chapter) ([]*source.Page, error) {
-	downloader, err := m.client.AtHome.NewMDHomeClient(chapter.ID, "data", false)
-	if err != nil {
+	return m.PagesOfContext(context.Background(), chapter)
+}
+
+// PagesOfContext is like PagesOf but cancels requests when the context is done.
+// Only the MangaDex@Home server is requested, pages are downloaded by the downloader,
+// so that they are retried, rate limited and staged like pages of other sources
+func (m *Mangadex) PagesOfContext(ctx context.Context, chapter *source.Chapter) ([]*source.Page, error) {
+	var server mangodex.MDHomeServerResponse
+
+	address := fmt.Sprintf("%s/"+mangodex.GetMDHomeURLPath, mangodex.BaseAPI, chapter.ID)
+	if err := m.client.RequestAndDecode(withSource(ctx), http.MethodGet, address, nil, &server); err != nil {
 		return nil, err
 	}
 
-	if len(downloader.Pages) == 0 {
+	if len(server.Chapter.Data) == 0 {
 		return nil, errors.New("there were no pages for this chapter")
 	}
 
-	var pages = make([]*source.Page, len(downloader.Pages))
+	var pages = make([]*source.Page, len(server.Chapter.Data))
 
-	for i, name := range downloader.Pages {
-		image, err := downloader.GetChapterPage(name)
-		if err != nil {
-			return nil, err
-		}
-
-		if len(image) == 0 {
-			return nil, errors.New("image is empty")
-		}
-
-		page := source.Page{
+	for i, name := range server.Chapter.Data {
+		pages[i] = &source.Page{
+			URL:       strings.Join([]string{server.BaseURL, "data", server.Chapter.Hash, name}, "/"),
 			Index:     uint16(i),
 			Chapter:   chapter,
 			Extension: filepath.Ext(name),
-			Contents:  bytes.NewBuffer(image),
-			Size:      uint64(len(image)),
 		}
-
-		pages[i] = &page
 	}
 
 	chapter.Pages = pages
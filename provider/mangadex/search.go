@@ -1,8 +1,8 @@
 package mangadex
 
 import (
+	"context"
 	"fmt"
-	"log"
 	"net/url"
 	"strconv"
 
@@ -13,6 +13,11 @@ import (
 )
 
 func (m *Mangadex) Search(query string) ([]*source.Manga, error) {
+	return m.SearchContext(context.Background(), query)
+}
+
+// SearchContext is like Search but cancels requests when the context is done
+func (m *Mangadex) SearchContext(ctx context.Context, query string) ([]*source.Manga, error) {
 	if cached, ok := m.cache.mangas.Get(query).Get(); ok {
 		for _, manga := range cached {
 			manga.Source = m
@@ -38,9 +43,8 @@ func (m *Mangadex) Search(query string) ([]*source.Manga, error) {
 	params.Set("order[followedCount]", "desc")
 	params.Set("title", query)
 
-	mangaList, err := m.client.Manga.GetMangaList(params)
+	mangaList, err := m.client.Manga.GetMangaListContext(withSource(ctx), params)
 	if err != nil {
-		log.Fatalln(err)
 		return nil, err
 	}
 
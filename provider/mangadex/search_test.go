@@ -1,10 +1,16 @@
 package mangadex
 
 import (
-	. "github.com/smartystreets/goconvey/convey"
+	"context"
+	"errors"
 	"testing"
+
+	"github.com/metafates/mangal/source"
+	. "github.com/smartystreets/goconvey/convey"
 )
 
+var _ source.ContextSource = (*Mangadex)(nil)
+
 var mangadex = New()
 
 func TestMangadex_Search(t *testing.T) {
@@ -29,3 +35,17 @@ func TestMangadex_Search(t *testing.T) {
 		})
 	})
 }
+
+func TestMangadex_SearchContext(t *testing.T) {
+	Convey("Given a mangadex instance", t, func() {
+		Convey("When searching with a cancelled context", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, err := mangadex.SearchContext(ctx, "cancelled search")
+			Convey("Then the context error should be returned", func() {
+				So(errors.Is(err, context.Canceled), ShouldBeTrue)
+			})
+		})
+	})
+}
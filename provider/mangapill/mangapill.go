@@ -69,5 +69,9 @@ var Config = &generic.Configuration{
 		URL: func(selection *goquery.Selection) string {
 			return selection.AttrOr("data-src", "")
 		},
+		// images are served by cdn that only accepts requests from the site
+		Headers: func(*goquery.Selection) map[string]string {
+			return map[string]string{"Referer": "https://mangapill.com/"}
+		},
 	},
 }
@@ -1,6 +1,7 @@
 package mangaplus
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
@@ -12,6 +13,10 @@ import (
 )
 
 func (m *Mangaplus) ChaptersOf(manga *source.Manga) ([]*source.Chapter, error) {
+	return m.ChaptersOfContext(context.Background(), manga)
+}
+
+func (m *Mangaplus) ChaptersOfContext(ctx context.Context, manga *source.Manga) ([]*source.Chapter, error) {
 	var (
 		chapters []*source.Chapter
 	)
@@ -25,7 +30,7 @@ func (m *Mangaplus) ChaptersOf(manga *source.Manga) ([]*source.Chapter, error) {
 			return cached, nil
 		}
 
-		title_detail_view, err := m.GetAppTitleDetails(manga.ID)
+		title_detail_view, err := m.GetAppTitleDetails(ctx, manga.ID)
 
 		_ = title_detail_view
 		if err != nil {
@@ -110,7 +115,7 @@ func (m *Mangaplus) ChaptersOf(manga *source.Manga) ([]*source.Chapter, error) {
 			return cached, nil
 		}
 
-		title_detail_view, err := m.GetWebTitleDetails(manga.ID)
+		title_detail_view, err := m.GetWebTitleDetails(ctx, manga.ID)
 
 		if err != nil {
 			log.Error(err)
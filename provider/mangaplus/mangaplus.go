@@ -1,6 +1,7 @@
 package mangaplus
 
 import (
+	"context"
 	"crypto/md5"
 	"crypto/rand"
 	"encoding/hex"
@@ -93,8 +94,8 @@ func (m *Mangaplus) GetAppLang(l mangaplus_resp_app.Lang) string {
 	return "en"
 }
 
-func (m *Mangaplus) GetWebViewer(chapter_id string) (*mangaplus_resp_web.MangaViewer, error) {
-	req, err := http.NewRequest(http.MethodGet, m.webapi_url+"/manga_viewer", nil)
+func (m *Mangaplus) GetWebViewer(ctx context.Context, chapter_id string) (*mangaplus_resp_web.MangaViewer, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.webapi_url+"/manga_viewer", nil)
 	if err != nil {
 		log.Error(err)
 		return nil, err
@@ -112,7 +113,7 @@ func (m *Mangaplus) GetWebViewer(chapter_id string) (*mangaplus_resp_web.MangaVi
 
 	req.URL.RawQuery = params.Encode()
 
-	resp, err := network.Client.Do(req)
+	resp, err := do(req)
 	if err != nil {
 		log.Error(err)
 		return nil, err
@@ -149,8 +150,8 @@ func (m *Mangaplus) GetWebViewer(chapter_id string) (*mangaplus_resp_web.MangaVi
 	return resp_data.Success.MangaViewer, nil
 }
 
-func (m *Mangaplus) GetWebTitleDetails(title_id string) (*mangaplus_resp_web.TitleDetailView, error) {
-	req, err := http.NewRequest(http.MethodGet, m.webapi_url+"/title_detailV3", nil)
+func (m *Mangaplus) GetWebTitleDetails(ctx context.Context, title_id string) (*mangaplus_resp_web.TitleDetailView, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.webapi_url+"/title_detailV3", nil)
 	if err != nil {
 		log.Error(err)
 		return nil, err
@@ -166,7 +167,7 @@ func (m *Mangaplus) GetWebTitleDetails(title_id string) (*mangaplus_resp_web.Tit
 
 	req.URL.RawQuery = params.Encode()
 
-	resp, err := network.Client.Do(req)
+	resp, err := do(req)
 	if err != nil {
 		log.Error(err)
 		return nil, err
@@ -219,7 +220,7 @@ func (m *Mangaplus) GetWebAllTitlesView() (*mangaplus_resp_web.AllTitlesViewV2,
 
 	req.URL.RawQuery = params.Encode()
 
-	resp, err := network.Client.Do(req)
+	resp, err := do(req)
 	if err != nil {
 		log.Error(err)
 		return nil, err
@@ -285,7 +286,7 @@ func (m *Mangaplus) Generate_App_Secret() error {
 
 	req.URL.RawQuery = params.Encode()
 
-	resp, err := network.Client.Do(req)
+	resp, err := do(req)
 	if err != nil {
 		log.Error(err)
 		return err
@@ -336,8 +337,8 @@ func (m *Mangaplus) Generate_App_Secret() error {
 	}
 }
 
-func (m *Mangaplus) GetAppViewer(chapter_id string) (*mangaplus_resp_app.MangaViewer, error) {
-	req, err := http.NewRequest(http.MethodGet, m.appapi_url+"/manga_viewer", nil)
+func (m *Mangaplus) GetAppViewer(ctx context.Context, chapter_id string) (*mangaplus_resp_app.MangaViewer, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.appapi_url+"/manga_viewer", nil)
 	if err != nil {
 		log.Error(err)
 		return nil, err
@@ -359,7 +360,7 @@ func (m *Mangaplus) GetAppViewer(chapter_id string) (*mangaplus_resp_app.MangaVi
 
 	req.URL.RawQuery = params.Encode()
 
-	resp, err := network.Client.Do(req)
+	resp, err := do(req)
 	if err != nil {
 		log.Error(err)
 		return nil, err
@@ -402,8 +403,8 @@ func (m *Mangaplus) GetAppViewer(chapter_id string) (*mangaplus_resp_app.MangaVi
 	return resp_data.Success.MangaViewer, nil
 }
 
-func (m *Mangaplus) GetAppTitleDetails(title_id string) (*mangaplus_resp_app.TitleDetailView, error) {
-	req, err := http.NewRequest(http.MethodGet, m.appapi_url+"/title_detailV3", nil)
+func (m *Mangaplus) GetAppTitleDetails(ctx context.Context, title_id string) (*mangaplus_resp_app.TitleDetailView, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.appapi_url+"/title_detailV3", nil)
 	if err != nil {
 		log.Error(err)
 		return nil, err
@@ -423,7 +424,7 @@ func (m *Mangaplus) GetAppTitleDetails(title_id string) (*mangaplus_resp_app.Tit
 
 	req.URL.RawQuery = params.Encode()
 
-	resp, err := network.Client.Do(req)
+	resp, err := do(req)
 	if err != nil {
 		log.Error(err)
 		return nil, err
@@ -492,6 +493,11 @@ func Escape_Path(path string) string {
 	return path
 }
 
+// do sends the request with the name of the source attached for the rate limiter
+func do(req *http.Request) (*http.Response, error) {
+	return network.Client.Do(req.WithContext(network.WithSource(req.Context(), Name)))
+}
+
 func (*Mangaplus) Name() string {
 	return Name
 }
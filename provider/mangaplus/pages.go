@@ -1,6 +1,7 @@
 package mangaplus
 
 import (
+	"context"
 	"path/filepath"
 	"strings"
 
@@ -9,12 +10,16 @@ import (
 )
 
 func (m *Mangaplus) PagesOf(chapter *source.Chapter) ([]*source.Page, error) {
+	return m.PagesOfContext(context.Background(), chapter)
+}
+
+func (m *Mangaplus) PagesOfContext(ctx context.Context, chapter *source.Chapter) ([]*source.Page, error) {
 	var (
 		pages []*source.Page
 	)
 
 	if m.use_app_api {
-		viewer, err := m.GetAppViewer(chapter.ID)
+		viewer, err := m.GetAppViewer(ctx, chapter.ID)
 		if err != nil {
 			log.Error(err)
 			return nil, err
@@ -37,7 +42,7 @@ func (m *Mangaplus) PagesOf(chapter *source.Chapter) ([]*source.Page, error) {
 			}
 		}
 	} else {
-		viewer, err := m.GetWebViewer(chapter.ID)
+		viewer, err := m.GetWebViewer(ctx, chapter.ID)
 		if err != nil {
 			log.Error(err)
 			return nil, err
@@ -1,6 +1,7 @@
 package mangaplus
 
 import (
+	"context"
 	"strconv"
 
 	"github.com/metafates/mangal/log"
@@ -8,6 +9,10 @@ import (
 )
 
 func (m *Mangaplus) Search(query string) ([]*source.Manga, error) {
+	return m.SearchContext(context.Background(), query)
+}
+
+func (m *Mangaplus) SearchContext(ctx context.Context, query string) ([]*source.Manga, error) {
 	var (
 		mangas []*source.Manga
 	)
@@ -21,7 +26,7 @@ func (m *Mangaplus) Search(query string) ([]*source.Manga, error) {
 			return cached, nil
 		}
 
-		title_detail_view, err := m.GetAppTitleDetails(query)
+		title_detail_view, err := m.GetAppTitleDetails(ctx, query)
 
 		if err != nil {
 			log.Error(err)
@@ -50,7 +55,7 @@ func (m *Mangaplus) Search(query string) ([]*source.Manga, error) {
 			return cached, nil
 		}
 
-		title_detail_view, err := m.GetWebTitleDetails(query)
+		title_detail_view, err := m.GetWebTitleDetails(ctx, query)
 
 		if err != nil {
 			log.Error(err)
@@ -1,6 +1,7 @@
 package onepiecetube
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -69,6 +70,10 @@ type OnePieceTubeChapterJson struct {
 }
 
 func (o *Onepiecetube) ChaptersOf(manga *source.Manga) ([]*source.Chapter, error) {
+	return o.ChaptersOfContext(context.Background(), manga)
+}
+
+func (o *Onepiecetube) ChaptersOfContext(ctx context.Context, manga *source.Manga) ([]*source.Chapter, error) {
 	if cached, ok := o.cache.chapters.Get(manga.URL).Get(); ok {
 		for _, chapter := range cached {
 			chapter.Manga = manga
@@ -81,7 +86,7 @@ func (o *Onepiecetube) ChaptersOf(manga *source.Manga) ([]*source.Chapter, error
 		chapters []*source.Chapter
 	)
 
-	req, err := http.NewRequest(http.MethodGet, manga.URL, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, manga.URL, nil)
 	if err != nil {
 		log.Error(err)
 		return nil, err
@@ -1,6 +1,7 @@
 package onepiecetube
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"html"
@@ -50,11 +51,15 @@ type OnePieceTubePageJson struct {
 }
 
 func (o *Onepiecetube) PagesOf(chapter *source.Chapter) ([]*source.Page, error) {
+	return o.PagesOfContext(context.Background(), chapter)
+}
+
+func (o *Onepiecetube) PagesOfContext(ctx context.Context, chapter *source.Chapter) ([]*source.Page, error) {
 	var (
 		pages []*source.Page
 	)
 
-	req, err := http.NewRequest(http.MethodGet, chapter.URL, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, chapter.URL, nil)
 	if err != nil {
 		log.Error(err)
 		return nil, err
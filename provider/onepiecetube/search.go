@@ -1,6 +1,8 @@
 package onepiecetube
 
 import (
+	"context"
+
 	"github.com/metafates/mangal/source"
 )
 
@@ -20,3 +22,7 @@ func (o *Onepiecetube) Search(query string) ([]*source.Manga, error) {
 
 	return mangas, nil
 }
+
+func (o *Onepiecetube) SearchContext(_ context.Context, query string) ([]*source.Manga, error) {
+	return o.Search(query)
+}
@@ -1,11 +1,13 @@
 package provider
 
 import (
-	"os"
+	"fmt"
 	"path/filepath"
 
+	"github.com/metafates/mangal/config"
 	"github.com/metafates/mangal/filesystem"
 	"github.com/metafates/mangal/provider/custom"
+	"github.com/metafates/mangal/provider/generic"
 	"github.com/metafates/mangal/source"
 	"github.com/metafates/mangal/util"
 	"github.com/metafates/mangal/where"
@@ -17,6 +19,8 @@ type Provider struct {
 	Name         string
 	UsesHeadless bool
 	IsCustom     bool
+	// Manifest of the custom source, nil for builtin ones
+	Manifest     *custom.Manifest
 	CreateSource func() (source.Source, error)
 }
 
@@ -35,40 +39,108 @@ func Customs() []*Provider {
 		return make([]*Provider, 0)
 	}
 
-	paths := lo.FilterMap(files, func(f os.FileInfo, _ int) (string, bool) {
-		if filepath.Ext(f.Name()) == CustomProviderExtension {
-			return filepath.Join(where.Sources(), f.Name()), true
+	providers := make([]*Provider, 0, len(files))
+
+	for _, file := range files {
+		path := filepath.Join(where.Sources(), file.Name())
+
+		switch ext := filepath.Ext(path); {
+		case ext == CustomProviderExtension:
+			providers = append(providers, luaProvider(path))
+		case lo.Contains(generic.DefinitionExtensions, ext):
+			providers = append(providers, definitionProvider(path))
 		}
-		return "", false
-	})
-	providers := make([]*Provider, len(paths))
+	}
+
+	return providers
+}
+
+// luaProvider is the provider of the lua source
+func luaProvider(path string) *Provider {
+	manifest, err := custom.ReadManifest(path)
+	if err != nil {
+		manifest = &custom.Manifest{}
+	}
 
-	for i, path := range paths {
-		// Check if source contains line `require("headless")`
-		// if so, set UsesHeadless to true.
+	usesHeadless := manifest.UsesHeadless()
+	if len(manifest.Modules) == 0 {
+		// Sources without the modules declaration are checked for `require("headless")` instead.
 		// This approach is not ideal, but it's the only way to do it without
 		// actually loading the source.
-		usesHeadless, _ := filesystem.Api().FileContainsAnyBytes(path, [][]byte{
+		usesHeadless, _ = filesystem.Api().FileContainsAnyBytes(path, [][]byte{
 			[]byte("require(\"headless\")"),
 			[]byte("require('headless')"),
 			[]byte("require(headless)"),
 			[]byte("require'headless'"),
 		})
+	}
 
-		name := util.FileStem(path)
-		path := path
-		providers[i] = &Provider{
-			ID:           custom.IDfromName(name),
-			UsesHeadless: usesHeadless,
-			IsCustom:     true,
-			Name:         name,
-			CreateSource: func() (source.Source, error) {
-				return custom.LoadSource(path, true)
-			},
+	name := util.FileStem(path)
+	return &Provider{
+		ID:           custom.IDfromName(name),
+		UsesHeadless: usesHeadless,
+		IsCustom:     true,
+		Manifest:     manifest,
+		Name:         name,
+		CreateSource: func() (source.Source, error) {
+			return custom.LoadSource(path, true)
+		},
+	}
+}
+
+// definitionProvider is the provider of the declarative source that uses the generic scraper.
+// Its manifest is made from the info fields of the definition
+func definitionProvider(path string) *Provider {
+	manifest := &custom.Manifest{}
+	if definition, err := generic.ReadDefinition(path); err == nil {
+		manifest = &custom.Manifest{
+			Name:       definition.Name,
+			Version:    definition.Version,
+			Author:     definition.Author,
+			Languages:  []string{definition.Lang},
+			URLs:       []string{definition.BaseURL},
+			MinVersion: definition.Mangal,
 		}
 	}
 
-	return providers
+	name := util.FileStem(path)
+	return &Provider{
+		ID:       custom.IDfromName(name),
+		IsCustom: true,
+		Manifest: manifest,
+		Name:     name,
+		CreateSource: func() (source.Source, error) {
+			if err := manifest.Compatible(); err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+
+			conf, err := generic.LoadDefinition(path)
+			if err != nil {
+				return nil, err
+			}
+
+			return generic.New(conf), nil
+		},
+	}
+}
+
+// RegisterSettings adds settings of the custom sources to the config
+// under the sources.<name> namespace, so that they can be changed with mangal config
+func RegisterSettings() {
+	for _, provider := range Customs() {
+		for _, setting := range provider.Manifest.Settings {
+			description := setting.Description
+			if description == "" {
+				description = fmt.Sprintf("Setting %s of the %s source", setting.Name, provider.Name)
+			}
+
+			config.Register(config.Field{
+				Key:         custom.SettingKey(provider.Name, setting.Name),
+				Value:       setting.Default,
+				Description: description,
+			}, setting.Type == custom.SettingSecret)
+		}
+	}
 }
 
 func Get(name string) (*Provider, bool) {
@@ -86,3 +158,19 @@ func Get(name string) (*Provider, bool) {
 
 	return nil, false
 }
+
+func GetByID(id string) (*Provider, bool) {
+	for _, provider := range Builtins() {
+		if provider.ID == id {
+			return provider, true
+		}
+	}
+
+	for _, provider := range Customs() {
+		if provider.ID == id {
+			return provider, true
+		}
+	}
+
+	return nil, false
+}
@@ -1,7 +1,9 @@
 package provider
 
 import (
+	"github.com/metafates/mangal/filesystem"
 	"github.com/metafates/mangal/provider/manganelo"
+	"github.com/samber/lo"
 	. "github.com/smartystreets/goconvey/convey"
 	"testing"
 )
@@ -21,3 +23,23 @@ func TestGet(t *testing.T) {
 		})
 	})
 }
+
+func TestLuaProvider(t *testing.T) {
+	filesystem.SetMemMapFs()
+
+	Convey("Given a lua source that requires headless without declaring modules", t, func() {
+		lo.Must0(filesystem.Api().WriteFile("legacy.lua", []byte(`local headless = require("headless")`), 0644))
+
+		Convey("Then it should use headless", func() {
+			So(luaProvider("legacy.lua").UsesHeadless, ShouldBeTrue)
+		})
+	})
+
+	Convey("Given a lua source that declares modules without headless", t, func() {
+		lo.Must0(filesystem.Api().WriteFile("declared.lua", []byte("-- @modules http\n-- require(\"headless\")"), 0644))
+
+		Convey("Then the declaration should be trusted", func() {
+			So(luaProvider("declared.lua").UsesHeadless, ShouldBeFalse)
+		})
+	})
+}
@@ -0,0 +1,77 @@
+package queue
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/metafates/mangal/source"
+)
+
+// Status of the queued chapter
+type Status string
+
+const (
+	StatusEnqueued   Status = "enqueued"
+	StatusInProgress Status = "in-progress"
+	StatusFailed     Status = "failed"
+	StatusFinished   Status = "finished"
+)
+
+// Statuses lists all known statuses in the order they are usually reached
+var Statuses = []Status{
+	StatusEnqueued,
+	StatusInProgress,
+	StatusFailed,
+	StatusFinished,
+}
+
+// IsPending reports whether the entry still has to be downloaded.
+// Entries that were in progress when mangal stopped are considered pending too.
+func (s Status) IsPending() bool {
+	return s == StatusEnqueued || s == StatusInProgress
+}
+
+// Entry is a chapter stored in the download queue.
+// It holds enough information to rebuild the chapter using its source.
+type Entry struct {
+	SourceID     string    `json:"source_id"`
+	MangaName    string    `json:"manga_name"`
+	MangaURL     string    `json:"manga_url"`
+	MangaID      string    `json:"manga_id"`
+	ChapterName  string    `json:"chapter_name"`
+	ChapterURL   string    `json:"chapter_url"`
+	ChapterID    string    `json:"chapter_id"`
+	ChapterIndex int       `json:"chapter_index"`
+	Status       Status    `json:"status"`
+	Error        string    `json:"error,omitempty"`
+	Path         string    `json:"path,omitempty"`
+	Attempts     int       `json:"attempts"`
+	Added        time.Time `json:"added"`
+	Updated      time.Time `json:"updated"`
+}
+
+func (e *Entry) encode() string {
+	return fmt.Sprintf("%s (%s)", e.ChapterURL, e.SourceID)
+}
+
+func (e *Entry) String() string {
+	return fmt.Sprintf("%s : %s", e.MangaName, e.ChapterName)
+}
+
+func newEntry(chapter *source.Chapter) *Entry {
+	now := time.Now()
+
+	return &Entry{
+		SourceID:     chapter.Source().ID(),
+		MangaName:    chapter.Manga.Name,
+		MangaURL:     chapter.Manga.URL,
+		MangaID:      chapter.Manga.ID,
+		ChapterName:  chapter.Name,
+		ChapterURL:   chapter.URL,
+		ChapterID:    chapter.ID,
+		ChapterIndex: int(chapter.Index),
+		Status:       StatusEnqueued,
+		Added:        now,
+		Updated:      now,
+	}
+}
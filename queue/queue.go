@@ -0,0 +1,268 @@
+package queue
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/metafates/mangal/filesystem"
+	"github.com/metafates/mangal/source"
+	"github.com/metafates/mangal/where"
+	"github.com/samber/lo"
+)
+
+// maxFinished is the number of finished entries that are kept in the queue,
+// older ones are dropped so that the queue does not grow forever
+const maxFinished = 100
+
+type queueFile struct {
+	Entries []*Entry `json:"entries"`
+}
+
+// mutex guards the queue file, since chapters can be downloaded concurrently
+var mutex sync.Mutex
+
+// read loads the queue from the disk.
+// Missing queue file is treated as an empty queue.
+func read() ([]*Entry, error) {
+	path := where.Queue()
+
+	exists, err := filesystem.Api().Exists(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return make([]*Entry, 0), nil
+	}
+
+	contents, err := filesystem.Api().ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file queueFile
+	if err = json.Unmarshal(contents, &file); err != nil {
+		return nil, err
+	}
+
+	return file.Entries, nil
+}
+
+// write saves the queue to the disk.
+// The file is replaced atomically, so that the queue is never left half-written if mangal is killed.
+func write(entries []*Entry) error {
+	marshalled, err := json.Marshal(&queueFile{Entries: entries})
+	if err != nil {
+		return err
+	}
+
+	return filesystem.WriteFileAtomic(where.Queue(), marshalled, os.ModePerm)
+}
+
+// prune drops the oldest finished entries above maxFinished
+func prune(entries []*Entry) []*Entry {
+	finished := lo.Filter(entries, func(e *Entry, _ int) bool {
+		return e.Status == StatusFinished
+	})
+
+	if len(finished) <= maxFinished {
+		return entries
+	}
+
+	sort.SliceStable(finished, func(i, j int) bool {
+		return finished[i].Updated.After(finished[j].Updated)
+	})
+
+	dropped := finished[maxFinished:]
+	return lo.Reject(entries, func(e *Entry, _ int) bool {
+		return lo.Contains(dropped, e)
+	})
+}
+
+// modify reads the queue, applies the given function and writes the result back.
+// The queue file is locked, so that updates of other mangal processes are not lost
+func modify(f func(entries []*Entry) []*Entry) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	unlock, err := filesystem.Lock(where.Queue())
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	entries, err := read()
+	if err != nil {
+		return err
+	}
+
+	return write(prune(f(entries)))
+}
+
+// Get returns all entries from the queue sorted by the time they were added
+func Get() ([]*Entry, error) {
+	mutex.Lock()
+	entries, err := read()
+	mutex.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Added.Equal(entries[j].Added) {
+			return entries[i].ChapterIndex < entries[j].ChapterIndex
+		}
+
+		return entries[i].Added.Before(entries[j].Added)
+	})
+
+	return entries, nil
+}
+
+// Pending returns entries that are not downloaded yet
+func Pending() ([]*Entry, error) {
+	entries, err := Get()
+	if err != nil {
+		return nil, err
+	}
+
+	return lo.Filter(entries, func(e *Entry, _ int) bool {
+		return e.Status.IsPending()
+	}), nil
+}
+
+// Enqueue adds chapters to the queue.
+// Chapters that are already queued are reset to the enqueued state
+// unless they are being downloaded at the moment.
+func Enqueue(chapters ...*source.Chapter) error {
+	return modify(func(entries []*Entry) []*Entry {
+		for _, chapter := range chapters {
+			entry := newEntry(chapter)
+
+			if existing, ok := find(entries, entry.encode()); ok {
+				if existing.Status != StatusInProgress {
+					existing.Status = StatusEnqueued
+					existing.Error = ""
+					existing.Updated = entry.Updated
+				}
+
+				continue
+			}
+
+			entries = append(entries, entry)
+		}
+
+		return entries
+	})
+}
+
+// MarkInProgress marks the chapter as being downloaded.
+// The chapter is added to the queue if it is not there yet.
+func MarkInProgress(chapter *source.Chapter) error {
+	return setStatus(chapter, func(entry *Entry) {
+		entry.Status = StatusInProgress
+		entry.Error = ""
+		entry.Attempts++
+	})
+}
+
+// MarkFinished marks the chapter as downloaded to the given path
+func MarkFinished(chapter *source.Chapter, path string) error {
+	return setStatus(chapter, func(entry *Entry) {
+		entry.Status = StatusFinished
+		entry.Error = ""
+		entry.Path = path
+	})
+}
+
+// MarkFailed marks the chapter as failed with the given error
+func MarkFailed(chapter *source.Chapter, err error) error {
+	return setStatus(chapter, func(entry *Entry) {
+		entry.Status = StatusFailed
+		if err != nil {
+			entry.Error = err.Error()
+		}
+	})
+}
+
+func setStatus(chapter *source.Chapter, set func(entry *Entry)) error {
+	return modify(func(entries []*Entry) []*Entry {
+		entry := newEntry(chapter)
+
+		existing, ok := find(entries, entry.encode())
+		if !ok {
+			entries = append(entries, entry)
+			existing = entry
+		}
+
+		set(existing)
+		existing.Updated = time.Now()
+		return entries
+	})
+}
+
+// MarkEntryFailed marks the entry as failed with the given error.
+// Used when the chapter could not be rebuilt from the entry.
+func MarkEntryFailed(entry *Entry, err error) error {
+	return modify(func(entries []*Entry) []*Entry {
+		if existing, ok := find(entries, entry.encode()); ok {
+			existing.Status = StatusFailed
+			existing.Error = err.Error()
+			existing.Updated = time.Now()
+		}
+
+		return entries
+	})
+}
+
+// RetryFailed moves failed entries back to the enqueued state.
+// Returns the number of entries that were re-enqueued.
+func RetryFailed() (count int, err error) {
+	err = modify(func(entries []*Entry) []*Entry {
+		for _, entry := range entries {
+			if entry.Status == StatusFailed {
+				entry.Status = StatusEnqueued
+				entry.Error = ""
+				entry.Updated = time.Now()
+				count++
+			}
+		}
+
+		return entries
+	})
+
+	return
+}
+
+// Remove removes the entry from the queue
+func Remove(entry *Entry) error {
+	return modify(func(entries []*Entry) []*Entry {
+		return lo.Reject(entries, func(e *Entry, _ int) bool {
+			return e.encode() == entry.encode()
+		})
+	})
+}
+
+// Clear removes entries with the given statuses from the queue.
+// If no statuses are given, the whole queue is cleared.
+func Clear(statuses ...Status) error {
+	return modify(func(entries []*Entry) []*Entry {
+		if len(statuses) == 0 {
+			return make([]*Entry, 0)
+		}
+
+		return lo.Reject(entries, func(e *Entry, _ int) bool {
+			return lo.Contains(statuses, e.Status)
+		})
+	})
+}
+
+func find(entries []*Entry, encoded string) (*Entry, bool) {
+	return lo.Find(entries, func(e *Entry) bool {
+		return e.encode() == encoded
+	})
+}
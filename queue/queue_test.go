@@ -0,0 +1,168 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/metafates/mangal/filesystem"
+	"github.com/metafates/mangal/source"
+	"github.com/metafates/mangal/where"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type testSource struct{}
+
+func (testSource) Name() string {
+	return "test source"
+}
+
+func (testSource) StdLang() string {
+	return "en"
+}
+
+func (testSource) Search(_ string) ([]*source.Manga, error) {
+	panic("")
+}
+
+func (testSource) ChaptersOf(_ *source.Manga) ([]*source.Chapter, error) {
+	panic("")
+}
+
+func (testSource) PagesOf(_ *source.Chapter) ([]*source.Page, error) {
+	panic("")
+}
+
+func (testSource) ID() string {
+	return "test source"
+}
+
+func init() {
+	filesystem.SetMemMapFs()
+}
+
+func testChapters() []*source.Chapter {
+	manga := &source.Manga{
+		Name:   "manga",
+		URL:    "https://example.com/manga",
+		ID:     "manga",
+		Source: testSource{},
+	}
+
+	for i, name := range []string{"first", "second", "third"} {
+		manga.Chapters = append(manga.Chapters, &source.Chapter{
+			Name:  name,
+			URL:   "https://example.com/manga/" + name,
+			Index: uint16(i + 1),
+			Manga: manga,
+		})
+	}
+
+	return manga.Chapters
+}
+
+func TestQueue(t *testing.T) {
+	Convey("Given an empty queue and some chapters", t, func() {
+		So(Clear(), ShouldBeNil)
+		chapters := testChapters()
+
+		Convey("When chapters are enqueued", func() {
+			So(Enqueue(chapters...), ShouldBeNil)
+
+			Convey("Then all of them should be pending", func() {
+				pending, err := Pending()
+				So(err, ShouldBeNil)
+				So(len(pending), ShouldEqual, len(chapters))
+				So(pending[0].ChapterName, ShouldEqual, chapters[0].Name)
+				So(pending[0].SourceID, ShouldEqual, testSource{}.ID())
+				So(pending[0].MangaURL, ShouldEqual, chapters[0].Manga.URL)
+			})
+
+			Convey("And enqueued again", func() {
+				So(Enqueue(chapters...), ShouldBeNil)
+
+				Convey("Then they should not be duplicated", func() {
+					entries, err := Get()
+					So(err, ShouldBeNil)
+					So(len(entries), ShouldEqual, len(chapters))
+				})
+			})
+
+			Convey("And their downloads progress", func() {
+				So(MarkInProgress(chapters[0]), ShouldBeNil)
+				So(MarkFinished(chapters[0], "path"), ShouldBeNil)
+				So(MarkInProgress(chapters[1]), ShouldBeNil)
+				So(MarkFailed(chapters[1], errors.New("oops")), ShouldBeNil)
+				So(MarkInProgress(chapters[2]), ShouldBeNil)
+
+				Convey("Then interrupted chapter should be pending", func() {
+					pending, err := Pending()
+					So(err, ShouldBeNil)
+					So(len(pending), ShouldEqual, 1)
+					So(pending[0].ChapterName, ShouldEqual, chapters[2].Name)
+					So(pending[0].Status, ShouldEqual, StatusInProgress)
+				})
+
+				Convey("Then failed chapters could be retried", func() {
+					count, err := RetryFailed()
+					So(err, ShouldBeNil)
+					So(count, ShouldEqual, 1)
+
+					pending, err := Pending()
+					So(err, ShouldBeNil)
+					So(len(pending), ShouldEqual, 2)
+				})
+
+				Convey("Then finished chapters could be cleared", func() {
+					So(Clear(StatusFinished), ShouldBeNil)
+
+					entries, err := Get()
+					So(err, ShouldBeNil)
+					So(len(entries), ShouldEqual, 2)
+
+					for _, entry := range entries {
+						So(entry.Status, ShouldNotEqual, StatusFinished)
+					}
+				})
+			})
+		})
+	})
+}
+
+func TestQueuePrune(t *testing.T) {
+	Convey("Given more finished chapters than the queue keeps", t, func() {
+		So(Clear(), ShouldBeNil)
+
+		manga := testChapters()[0].Manga
+		for i := 0; i <= maxFinished; i++ {
+			chapter := &source.Chapter{
+				Name:  fmt.Sprintf("chapter %d", i),
+				URL:   fmt.Sprintf("https://example.com/manga/%d", i),
+				Index: uint16(i + 1),
+				Manga: manga,
+			}
+
+			So(MarkFinished(chapter, "path"), ShouldBeNil)
+		}
+
+		Convey("When the queue is read", func() {
+			entries, err := Get()
+			So(err, ShouldBeNil)
+
+			Convey("Then the oldest finished chapter should be dropped", func() {
+				So(len(entries), ShouldEqual, maxFinished)
+				So(entries[0].ChapterName, ShouldEqual, "chapter 1")
+			})
+
+			Convey("Then no temporary files should be left", func() {
+				files, err := filesystem.Api().ReadDir(filepath.Dir(where.Queue()))
+				So(err, ShouldBeNil)
+
+				for _, file := range files {
+					So(file.Name(), ShouldNotEndWith, ".tmp")
+				}
+			})
+		})
+	})
+}
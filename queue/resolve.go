@@ -0,0 +1,93 @@
+package queue
+
+import (
+	"fmt"
+
+	"github.com/metafates/mangal/provider"
+	"github.com/metafates/mangal/source"
+	"github.com/samber/lo"
+)
+
+// Resolver rebuilds chapters from the queue entries.
+// Sources and manga chapters are loaded once and shared between entries.
+type Resolver struct {
+	sources map[string]source.Source
+	mangas  map[string]*source.Manga
+}
+
+// NewResolver creates a new Resolver
+func NewResolver() *Resolver {
+	return &Resolver{
+		sources: make(map[string]source.Source),
+		mangas:  make(map[string]*source.Manga),
+	}
+}
+
+func (r *Resolver) source(id string) (source.Source, error) {
+	if src, ok := r.sources[id]; ok {
+		return src, nil
+	}
+
+	p, ok := provider.GetByID(id)
+	if !ok {
+		return nil, fmt.Errorf("source not found: %s", id)
+	}
+
+	src, err := p.CreateSource()
+	if err != nil {
+		return nil, err
+	}
+
+	r.sources[id] = src
+	return src, nil
+}
+
+func (r *Resolver) manga(entry *Entry) (*source.Manga, error) {
+	key := fmt.Sprintf("%s (%s)", entry.MangaURL, entry.SourceID)
+	if manga, ok := r.mangas[key]; ok {
+		return manga, nil
+	}
+
+	src, err := r.source(entry.SourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	manga := &source.Manga{
+		Name:     entry.MangaName,
+		URL:      entry.MangaURL,
+		ID:       entry.MangaID,
+		Source:   src,
+		Chapters: make([]*source.Chapter, 0),
+	}
+
+	chapters, err := src.ChaptersOf(manga)
+	if err != nil {
+		return nil, err
+	}
+
+	manga.Chapters = chapters
+	r.mangas[key] = manga
+	return manga, nil
+}
+
+// Resolve returns the chapter that the entry refers to.
+// Chapter is fetched from its source so that it is identical to the one
+// that would be downloaded from the TUI or the inline mode.
+func (r *Resolver) Resolve(entry *Entry) (*source.Chapter, error) {
+	manga, err := r.manga(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	chapter, ok := lo.Find(manga.Chapters, func(c *source.Chapter) bool {
+		return c.URL == entry.ChapterURL
+	})
+
+	if !ok {
+		return nil, fmt.Errorf("chapter %s is no longer available in %s", entry.ChapterName, entry.SourceID)
+	}
+
+	chapter.Manga = manga
+	return chapter, nil
+}
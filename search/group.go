@@ -0,0 +1,51 @@
+package search
+
+import (
+	levenshtein "github.com/ka-weihe/fast-levenshtein"
+	"github.com/metafates/mangal/source"
+	"github.com/metafates/mangal/util"
+)
+
+// Group is a set of mangas with similar names, possibly from different sources
+type Group struct {
+	// Name of the first manga in the group
+	Name string
+	// Mangas of the group in order of the sources
+	Mangas []*source.Manga
+
+	normalized string
+}
+
+// Sources returns names of the sources that carry the manga, without duplicates
+func (g *Group) Sources() []string {
+	var (
+		names = make([]string, 0, len(g.Mangas))
+		seen  = make(map[string]struct{})
+	)
+
+	for _, manga := range g.Mangas {
+		if manga.Source == nil {
+			continue
+		}
+
+		name := manga.Source.Name()
+		if _, ok := seen[name]; ok {
+			continue
+		}
+
+		seen[name] = struct{}{}
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// similar reports whether the normalized names are likely to be the same title.
+// Names are similar if no more than a tenth of the characters differ.
+func similar(a, b string) bool {
+	if a == b {
+		return true
+	}
+
+	return levenshtein.Distance(a, b)*10 <= util.Max(len(a), len(b))
+}
@@ -0,0 +1,149 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/metafates/mangal/key"
+	"github.com/metafates/mangal/log"
+	"github.com/metafates/mangal/source"
+	"github.com/metafates/mangal/util"
+	"github.com/spf13/viper"
+)
+
+// Result of the search across multiple sources
+type Result struct {
+	// Groups of mangas with similar names in order of the sources
+	Groups []*Group
+	// Errors of the failed sources by their names
+	Errors map[string]error
+
+	sources int
+}
+
+// Mangas returns mangas of all groups.
+// Mangas of the same group are placed next to each other.
+func (r *Result) Mangas() []*source.Manga {
+	mangas := make([]*source.Manga, 0, len(r.Groups))
+	for _, group := range r.Groups {
+		mangas = append(mangas, group.Mangas...)
+	}
+
+	return mangas
+}
+
+// GroupOf returns the group that contains the manga
+func (r *Result) GroupOf(manga *source.Manga) (*Group, bool) {
+	for _, group := range r.Groups {
+		for _, m := range group.Mangas {
+			if m == manga {
+				return group, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// Err returns an error if every source has failed
+func (r *Result) Err() error {
+	if len(r.Errors) == 0 || len(r.Errors) < r.sources {
+		return nil
+	}
+
+	if len(r.Errors) == 1 {
+		for _, err := range r.Errors {
+			return err
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("all sources have failed:")
+	for name, err := range r.Errors {
+		sb.WriteString(fmt.Sprintf("\n%s: %s", name, err))
+	}
+
+	return errors.New(sb.String())
+}
+
+// Search searches for the query among all sources concurrently.
+// Each source is limited by the timeout from the config.
+// An error of a single source does not fail the whole search, it is stored in the result instead.
+func Search(ctx context.Context, sources []source.Source, query string) *Result {
+	var (
+		found   = make([][]*source.Manga, len(sources))
+		errs    = make([]error, len(sources))
+		timeout = time.Duration(viper.GetInt(key.SearchSourceTimeout)) * time.Second
+		wg      sync.WaitGroup
+	)
+
+	wg.Add(len(sources))
+	for i, src := range sources {
+		go func(i int, src source.Source) {
+			defer wg.Done()
+
+			srcCtx := ctx
+			if timeout > 0 {
+				var cancel context.CancelFunc
+				srcCtx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+
+			mangas, err := source.WithContext(src).SearchContext(srcCtx, query)
+			if err != nil {
+				if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+					err = fmt.Errorf("timed out after %s", timeout)
+				}
+
+				log.Errorf("search for %s failed on source %s: %s", query, src.Name(), err)
+				errs[i] = err
+				return
+			}
+
+			log.Infof("found %s from source %s", util.Quantify(len(mangas), "manga", "mangas"), src.Name())
+			found[i] = mangas
+		}(i, src)
+	}
+
+	wg.Wait()
+
+	result := &Result{
+		Errors:  make(map[string]error),
+		sources: len(sources),
+	}
+
+	for i, src := range sources {
+		if errs[i] != nil {
+			result.Errors[src.Name()] = errs[i]
+			continue
+		}
+
+		for _, manga := range found[i] {
+			result.add(manga)
+		}
+	}
+
+	return result
+}
+
+// add puts the manga into the group with a similar name or creates a new one
+func (r *Result) add(manga *source.Manga) {
+	name := util.NormalizeName(manga.Name)
+
+	for _, group := range r.Groups {
+		if similar(group.normalized, name) {
+			group.Mangas = append(group.Mangas, manga)
+			return
+		}
+	}
+
+	r.Groups = append(r.Groups, &Group{
+		Name:       manga.Name,
+		Mangas:     []*source.Manga{manga},
+		normalized: name,
+	})
+}
@@ -0,0 +1,99 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/metafates/mangal/key"
+	"github.com/metafates/mangal/source"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/spf13/viper"
+)
+
+type testSource struct {
+	name   string
+	titles []string
+	err    error
+	delay  time.Duration
+}
+
+func (t *testSource) Name() string    { return t.name }
+func (t *testSource) ID() string      { return t.name }
+func (t *testSource) StdLang() string { return "en" }
+
+func (t *testSource) Search(string) ([]*source.Manga, error) {
+	time.Sleep(t.delay)
+
+	if t.err != nil {
+		return nil, t.err
+	}
+
+	mangas := make([]*source.Manga, len(t.titles))
+	for i, title := range t.titles {
+		mangas[i] = &source.Manga{Name: title, Source: t}
+	}
+
+	return mangas, nil
+}
+
+func (t *testSource) ChaptersOf(*source.Manga) ([]*source.Chapter, error) {
+	return nil, nil
+}
+
+func (t *testSource) PagesOf(*source.Chapter) ([]*source.Page, error) {
+	return nil, nil
+}
+
+func TestSearch(t *testing.T) {
+	viper.Set(key.SearchSourceTimeout, 1)
+
+	Convey("Given multiple sources", t, func() {
+		sources := []source.Source{
+			&testSource{name: "first", titles: []string{"One Piece", "Naruto"}},
+			&testSource{name: "second", titles: []string{" one piece", "Boruto"}},
+			&testSource{name: "third", titles: []string{"One Piece!"}},
+			&testSource{name: "broken", err: errors.New("broken")},
+			&testSource{name: "slow", titles: []string{"Naruto"}, delay: 3 * time.Second},
+		}
+
+		Convey("When searching among them", func() {
+			start := time.Now()
+			result := Search(context.Background(), sources, "one piece")
+
+			Convey("Then failed and slow sources should not fail the search", func() {
+				So(time.Since(start), ShouldBeLessThan, 3*time.Second)
+				So(result.Errors, ShouldContainKey, "slow")
+				So(result.Errors, ShouldContainKey, "broken")
+				So(result.Err(), ShouldBeNil)
+
+				Convey("And similar titles should be grouped next to each other", func() {
+					So(result.Groups, ShouldHaveLength, 3)
+					So(result.Groups[0].Name, ShouldEqual, "One Piece")
+					So(result.Groups[0].Sources(), ShouldResemble, []string{"first", "second", "third"})
+					So(result.Groups[1].Name, ShouldEqual, "Naruto")
+					So(result.Groups[2].Name, ShouldEqual, "Boruto")
+					So(result.Mangas(), ShouldHaveLength, 5)
+					So(result.Mangas()[2].Name, ShouldEqual, "One Piece!")
+				})
+			})
+		})
+	})
+
+	Convey("Given sources that all fail", t, func() {
+		sources := []source.Source{
+			&testSource{name: "first", err: errors.New("first")},
+			&testSource{name: "second", err: errors.New("second")},
+		}
+
+		Convey("When searching among them", func() {
+			result := Search(context.Background(), sources, "query")
+
+			Convey("Then the search should fail", func() {
+				So(result.Err(), ShouldNotBeNil)
+				So(result.Groups, ShouldBeEmpty)
+			})
+		})
+	})
+}
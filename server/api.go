@@ -0,0 +1,289 @@
+package server
+
+import (
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"github.com/metafates/mangal/constant"
+	"github.com/metafates/mangal/filesystem"
+	"github.com/metafates/mangal/library"
+	"github.com/samber/lo"
+)
+
+// seriesResponse is the series with identifiers and urls of the API
+type seriesResponse struct {
+	*library.Series
+	ID            string             `json:"id"`
+	Cover         string             `json:"cover,omitempty"`
+	ChaptersCount int                `json:"chapters_count"`
+	Chapters      []*chapterResponse `json:"chapters,omitempty"`
+}
+
+// chapterResponse is the chapter with identifiers and urls of the API
+type chapterResponse struct {
+	*library.Chapter
+	ID       string `json:"id"`
+	Readable bool   `json:"readable"`
+	PagesURL string `json:"pages_url,omitempty"`
+	FileURL  string `json:"file_url,omitempty"`
+}
+
+// serveAPI routes requests of the JSON API:
+//
+//	GET    /api
+//	GET    /api/series
+//	GET    /api/series/{series}
+//	GET    /api/series/{series}/cover
+//	GET    /api/series/{series}/chapters/{chapter}
+//	DELETE /api/series/{series}/chapters/{chapter}
+//	GET    /api/series/{series}/chapters/{chapter}/pages
+//	GET    /api/series/{series}/chapters/{chapter}/pages/{index}
+//	GET    /api/series/{series}/chapters/{chapter}/file
+//	POST   /api/scan
+//
+// Routes of the inline mode are under /api/inline, see serveInline
+func (s *Server) serveAPI(w http.ResponseWriter, r *http.Request, segments []string) {
+	switch {
+	case len(segments) == 0:
+		if allowMethods(w, r, http.MethodGet) {
+			s.serveInfo(w)
+		}
+	case len(segments) == 1 && segments[0] == "scan":
+		if allowMethods(w, r, http.MethodPost) && s.allowWrite(w) {
+			if err := s.scan(); err != nil {
+				writeError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+
+			s.serveInfo(w)
+		}
+	case segments[0] == "series":
+		s.serveSeries(w, r, segments[1:])
+	case segments[0] == "inline":
+		s.serveInline(w, r, segments[1:])
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (s *Server) serveInfo(w http.ResponseWriter) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"name":      constant.Mangal,
+		"version":   constant.Version,
+		"read_only": s.options.ReadOnly,
+		"scanned":   s.index.Scanned,
+		"series":    len(s.index.Series),
+		"chapters":  s.index.Chapters(),
+	})
+}
+
+func (s *Server) serveSeries(w http.ResponseWriter, r *http.Request, segments []string) {
+	if len(segments) == 0 {
+		if !allowMethods(w, r, http.MethodGet) {
+			return
+		}
+
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+
+		writeJSON(w, http.StatusOK, lo.Map(s.index.Series, func(series *library.Series, _ int) *seriesResponse {
+			return s.newSeriesResponse(series, false)
+		}))
+		return
+	}
+
+	series, ok := s.findSeries(segments[0])
+	if !ok {
+		writeError(w, http.StatusNotFound, "series not found")
+		return
+	}
+
+	switch {
+	case len(segments) == 1:
+		if allowMethods(w, r, http.MethodGet) {
+			writeJSON(w, http.StatusOK, s.newSeriesResponse(series, true))
+		}
+	case len(segments) == 2 && segments[1] == "cover":
+		if !allowMethods(w, r, http.MethodGet) {
+			return
+		}
+
+		path, ok := s.cover(series)
+		if !ok {
+			writeError(w, http.StatusNotFound, "cover not found")
+			return
+		}
+
+		s.serveFile(w, r, path)
+	case len(segments) >= 3 && segments[1] == "chapters":
+		chapter, ok := lo.Find(series.Chapters, func(c *library.Chapter) bool {
+			return id(c.Path) == segments[2]
+		})
+		if !ok {
+			writeError(w, http.StatusNotFound, "chapter not found")
+			return
+		}
+
+		s.serveChapter(w, r, series, chapter, segments[3:])
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (s *Server) serveChapter(w http.ResponseWriter, r *http.Request, series *library.Series, chapter *library.Chapter, segments []string) {
+	switch {
+	case len(segments) == 0:
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			writeJSON(w, http.StatusOK, s.newChapterResponse(series, chapter))
+		case http.MethodDelete:
+			if s.allowWrite(w) {
+				s.deleteChapter(w, chapter)
+			}
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	case len(segments) == 1 && segments[0] == "pages":
+		if !allowMethods(w, r, http.MethodGet) {
+			return
+		}
+
+		names, err := s.pageNames(chapter)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if err == errNotReadable {
+				status = http.StatusUnsupportedMediaType
+			}
+
+			writeError(w, status, err.Error())
+			return
+		}
+
+		base := chapterURL(series, chapter) + "/pages/"
+		pages := make([]map[string]any, len(names))
+		for i, name := range names {
+			pages[i] = map[string]any{
+				"index": i,
+				"name":  filepath.Base(name),
+				"url":   base + strconv.Itoa(i),
+			}
+		}
+
+		writeJSON(w, http.StatusOK, pages)
+	case len(segments) == 2 && segments[0] == "pages":
+		if allowMethods(w, r, http.MethodGet) {
+			s.servePage(w, r, chapter, segments[1])
+		}
+	case len(segments) == 1 && segments[0] == "file":
+		if !allowMethods(w, r, http.MethodGet) {
+			return
+		}
+
+		if chapter.Format == constant.FormatPlain {
+			writeError(w, http.StatusUnsupportedMediaType, "plain chapters are directories, request the pages instead")
+			return
+		}
+
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filepath.Base(chapter.Path)+`"`)
+		s.serveFile(w, r, filepath.Join(s.options.Root, chapter.Path))
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// deleteChapter removes the chapter from the disk and rescans the library
+func (s *Server) deleteChapter(w http.ResponseWriter, chapter *library.Chapter) {
+	if err := filesystem.Api().RemoveAll(filepath.Join(s.options.Root, chapter.Path)); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// the index is replaced rather than modified, so that handlers holding the old one are not affected
+	if err := s.scan(); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) findSeries(seriesID string) (*library.Series, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return lo.Find(s.index.Series, func(series *library.Series) bool {
+		return id(series.Path) == seriesID
+	})
+}
+
+func (s *Server) newSeriesResponse(series *library.Series, withChapters bool) *seriesResponse {
+	response := &seriesResponse{
+		Series:        series,
+		ID:            id(series.Path),
+		ChaptersCount: len(series.Chapters),
+	}
+
+	if _, ok := s.cover(series); ok {
+		response.Cover = seriesURL(series) + "/cover"
+	}
+
+	if withChapters {
+		response.Chapters = lo.Map(series.Chapters, func(chapter *library.Chapter, _ int) *chapterResponse {
+			return s.newChapterResponse(series, chapter)
+		})
+	}
+
+	return response
+}
+
+func (s *Server) newChapterResponse(series *library.Series, chapter *library.Chapter) *chapterResponse {
+	response := &chapterResponse{
+		Chapter:  chapter,
+		ID:       id(chapter.Path),
+		Readable: readable(chapter),
+	}
+
+	url := chapterURL(series, chapter)
+	if response.Readable {
+		response.PagesURL = url + "/pages"
+	}
+
+	if chapter.Format != constant.FormatPlain {
+		response.FileURL = url + "/file"
+	}
+
+	return response
+}
+
+func seriesURL(series *library.Series) string {
+	return "/api/series/" + id(series.Path)
+}
+
+func chapterURL(series *library.Series, chapter *library.Chapter) string {
+	return seriesURL(series) + "/chapters/" + id(chapter.Path)
+}
+
+// allowMethods writes an error and returns false if the request method is not one of the given.
+// HEAD is allowed wherever GET is
+func allowMethods(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	if lo.Contains(methods, r.Method) || (r.Method == http.MethodHead && lo.Contains(methods, http.MethodGet)) {
+		return true
+	}
+
+	writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	return false
+}
+
+// allowWrite writes an error and returns false if the server is read-only
+func (s *Server) allowWrite(w http.ResponseWriter) bool {
+	if s.options.ReadOnly {
+		writeError(w, http.StatusForbidden, "server is read-only")
+		return false
+	}
+
+	return true
+}
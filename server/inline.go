@@ -0,0 +1,178 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/metafates/mangal/inline"
+	"github.com/metafates/mangal/key"
+	"github.com/metafates/mangal/provider"
+	"github.com/metafates/mangal/source"
+	"github.com/samber/lo"
+	"github.com/samber/mo"
+	"github.com/spf13/viper"
+)
+
+// inlineMode is what the inline request returns
+type inlineMode string
+
+const (
+	inlineSearch   inlineMode = "search"
+	inlineChapters inlineMode = "chapters"
+	inlinePages    inlineMode = "pages"
+)
+
+// serveInline routes requests of the inline API.
+// Sources are created once and reused by all requests
+//
+//	GET    /api/inline/search?query=...
+//	GET    /api/inline/chapters?query=...&manga=first&chapters=all
+//	GET    /api/inline/pages?query=...&manga=first&chapters=0-2
+//	GET    /api/inline/jobs
+//	POST   /api/inline/jobs
+//	GET    /api/inline/jobs/{job}
+//	DELETE /api/inline/jobs/{job}
+func (s *Server) serveInline(w http.ResponseWriter, r *http.Request, segments []string) {
+	switch {
+	case len(segments) == 1 && lo.Contains([]inlineMode{inlineSearch, inlineChapters, inlinePages}, inlineMode(segments[0])):
+		if allowMethods(w, r, http.MethodGet) {
+			s.serveInlineRun(w, r, inlineMode(segments[0]))
+		}
+	case len(segments) >= 1 && segments[0] == "jobs":
+		s.serveJobs(w, r, segments[1:])
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// serveInlineRun runs the inline mode with the json output.
+// Query parameters are the same as flags of the inline command
+func (s *Server) serveInlineRun(w http.ResponseWriter, r *http.Request, mode inlineMode) {
+	params := r.URL.Query()
+
+	query := params.Get("query")
+	if query == "" {
+		writeError(w, http.StatusBadRequest, "query is required")
+		return
+	}
+
+	sources, err := s.sources(splitParam(params.Get("sources")))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	options := &inline.Options{
+		Context:             r.Context(),
+		Sources:             sources,
+		Json:                true,
+		Query:               query,
+		PopulatePages:       mode == inlinePages,
+		IncludeAnilistManga: parseBool(params.Get("include_anilist")),
+		MangaPicker:         mo.None[inline.MangaPicker](),
+		ChaptersFilter:      mo.None[inline.ChaptersFilter](),
+	}
+
+	if timeout := params.Get("timeout"); timeout != "" {
+		if options.Timeout, err = time.ParseDuration(timeout); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid timeout %q", timeout))
+			return
+		}
+	}
+
+	if manga := params.Get("manga"); manga != "" {
+		picker, err := inline.ParseMangaPicker(query, manga)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		options.MangaPicker = mo.Some(picker)
+	}
+
+	if mode != inlineSearch {
+		filter, err := inline.ParseChaptersFilter(lo.Ternary(params.Has("chapters"), params.Get("chapters"), "all"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		options.ChaptersFilter = mo.Some(filter)
+	}
+
+	var buf bytes.Buffer
+	options.Out = &buf
+
+	if err = inline.Run(options); err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// sources returns the sources with the given names, default sources are used if names are empty.
+// Sources are created on the first use and cached
+func (s *Server) sources(names []string) ([]source.Source, error) {
+	if len(names) == 0 {
+		names = lo.Filter(viper.GetStringSlice(key.DownloaderDefaultSources), func(name string, _ int) bool {
+			return name != ""
+		})
+	}
+
+	if len(names) == 0 {
+		return nil, errors.New("source not set")
+	}
+
+	s.sourcesMutex.Lock()
+	defer s.sourcesMutex.Unlock()
+
+	if s.sourcesCache == nil {
+		s.sourcesCache = make(map[string]source.Source)
+	}
+
+	sources := make([]source.Source, len(names))
+	for i, name := range names {
+		if src, ok := s.sourcesCache[name]; ok {
+			sources[i] = src
+			continue
+		}
+
+		p, ok := provider.Get(name)
+		if !ok {
+			return nil, fmt.Errorf("source not found: %s", name)
+		}
+
+		src, err := p.CreateSource()
+		if err != nil {
+			return nil, err
+		}
+
+		s.sourcesCache[name] = src
+		sources[i] = src
+	}
+
+	return sources, nil
+}
+
+// splitParam splits the comma separated parameter
+func splitParam(param string) []string {
+	return lo.Filter(lo.Map(strings.Split(param, ","), func(value string, _ int) string {
+		return strings.TrimSpace(value)
+	}), func(value string, _ int) bool {
+		return value != ""
+	})
+}
+
+// parseBool parses the boolean parameter, empty parameter is false
+func parseBool(param string) bool {
+	value, _ := strconv.ParseBool(param)
+	return value
+}
@@ -0,0 +1,191 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/metafates/mangal/inline"
+	"github.com/metafates/mangal/source"
+	"github.com/samber/lo"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type testSource struct{}
+
+func (testSource) Name() string    { return "test" }
+func (testSource) ID() string      { return "test" }
+func (testSource) StdLang() string { return "en" }
+
+func (t testSource) Search(query string) ([]*source.Manga, error) {
+	return []*source.Manga{{Name: query, URL: "https://example.com/" + query, Source: t}}, nil
+}
+
+func (testSource) ChaptersOf(manga *source.Manga) ([]*source.Chapter, error) {
+	var chapters []*source.Chapter
+	for i, name := range []string{"first", "second"} {
+		chapters = append(chapters, &source.Chapter{Name: name, Index: uint16(i + 1), Manga: manga})
+	}
+
+	return chapters, nil
+}
+
+func (testSource) PagesOf(chapter *source.Chapter) ([]*source.Page, error) {
+	chapter.Pages = []*source.Page{{URL: "https://example.com/1.jpg", Index: 1, Chapter: chapter}}
+	return chapter.Pages, nil
+}
+
+func newInlineServer(readOnly bool) *Server {
+	s := lo.Must(New(Options{Root: filepath.Join("server", "empty"), ReadOnly: readOnly}))
+	s.sourcesCache = map[string]source.Source{"test": testSource{}}
+	return s
+}
+
+// post sends the job request
+func post(handler http.Handler, body string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/api/inline/jobs", strings.NewReader(body)))
+	return recorder
+}
+
+func TestInline(t *testing.T) {
+	Convey("Given a server with a source", t, func() {
+		s := newInlineServer(true)
+
+		Convey("When the manga is searched", func() {
+			response := request(s, http.MethodGet, "/api/inline/search?query=berserk&sources=test")
+			So(response.Code, ShouldEqual, http.StatusOK)
+
+			Convey("Then the output should be the same as of the inline mode", func() {
+				output := decode[inline.Output](response)
+				So(output.Query, ShouldEqual, "berserk")
+				So(output.Result, ShouldHaveLength, 1)
+				So(output.Result[0].Source, ShouldEqual, "test")
+				So(output.Result[0].Mangal.Chapters, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When chapters are requested", func() {
+			response := request(s, http.MethodGet, "/api/inline/chapters?query=berserk&sources=test&manga=first&chapters=last")
+			So(response.Code, ShouldEqual, http.StatusOK)
+
+			Convey("Then they should be filtered", func() {
+				chapters := decode[inline.Output](response).Result[0].Mangal.Chapters
+				So(chapters, ShouldHaveLength, 1)
+				So(chapters[0].Name, ShouldEqual, "second")
+				So(chapters[0].Pages, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When pages are requested", func() {
+			response := request(s, http.MethodGet, "/api/inline/pages?query=berserk&sources=test&manga=first")
+			So(response.Code, ShouldEqual, http.StatusOK)
+
+			Convey("Then chapters should have pages", func() {
+				chapters := decode[inline.Output](response).Result[0].Mangal.Chapters
+				So(chapters, ShouldHaveLength, 2)
+				So(chapters[0].Pages, ShouldHaveLength, 1)
+			})
+		})
+
+		Convey("When the request is invalid", func() {
+			Convey("Then it should be rejected", func() {
+				So(request(s, http.MethodGet, "/api/inline/search").Code, ShouldEqual, http.StatusBadRequest)
+				So(request(s, http.MethodGet, "/api/inline/search?query=a&sources=unknown").Code, ShouldEqual, http.StatusBadRequest)
+				So(request(s, http.MethodGet, "/api/inline/chapters?query=a&sources=test&manga=middle").Code, ShouldEqual, http.StatusBadRequest)
+			})
+		})
+
+		Convey("When a job is created in the read-only mode", func() {
+			response := post(s, `{"query":"berserk","sources":["test"],"manga":"first"}`)
+
+			Convey("Then it should be forbidden", func() {
+				So(response.Code, ShouldEqual, http.StatusForbidden)
+			})
+		})
+	})
+}
+
+func TestJobs(t *testing.T) {
+	Convey("Given a writable server with a source", t, func() {
+		s := newInlineServer(false)
+
+		Convey("When a job is created while the queue is full", func() {
+			// no runner and no buffer, so nothing can be queued
+			s.jobs.once.Do(func() {
+				s.jobs.pending = make(chan *downloadJob)
+			})
+
+			response := post(s, `{"query":"berserk","sources":["test"],"manga":"first"}`)
+
+			Convey("Then it should be rejected and not listed", func() {
+				So(response.Code, ShouldEqual, http.StatusServiceUnavailable)
+				So(decode[[]downloadJob](request(s, http.MethodGet, "/api/inline/jobs")), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When a job without a manga is created", func() {
+			response := post(s, `{"query":"berserk","sources":["test"]}`)
+
+			Convey("Then it should be rejected", func() {
+				So(response.Code, ShouldEqual, http.StatusBadRequest)
+			})
+		})
+
+		Convey("When a job with no matching chapters is created", func() {
+			response := post(s, `{"query":"berserk","sources":["test"],"manga":"first","chapters":"@nothing@"}`)
+			So(response.Code, ShouldEqual, http.StatusAccepted)
+
+			created := decode[downloadJob](response)
+			So(created.Status, ShouldEqual, jobQueued)
+			So(response.Header().Get("Location"), ShouldEqual, "/api/inline/jobs/"+created.ID)
+
+			var polled downloadJob
+			for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+				polled = decode[downloadJob](request(s, http.MethodGet, "/api/inline/jobs/"+created.ID))
+				if polled.Status.IsDone() {
+					break
+				}
+			}
+
+			Convey("Then it should finish without downloads", func() {
+				So(polled.Status, ShouldEqual, jobFinished)
+				So(polled.Total, ShouldEqual, 0)
+				So(polled.Paths, ShouldBeEmpty)
+				So(string(polled.Output), ShouldContainSubstring, `"query":"berserk"`)
+			})
+
+			Convey("Then it should be listed", func() {
+				jobs := decode[[]downloadJob](request(s, http.MethodGet, "/api/inline/jobs"))
+				So(jobs, ShouldHaveLength, 1)
+				So(jobs[0].ID, ShouldEqual, created.ID)
+			})
+
+			Convey("Then it should be removed when deleted", func() {
+				So(request(s, http.MethodDelete, "/api/inline/jobs/"+created.ID).Code, ShouldEqual, http.StatusNoContent)
+				So(request(s, http.MethodGet, "/api/inline/jobs/"+created.ID).Code, ShouldEqual, http.StatusNotFound)
+			})
+		})
+
+		Convey("When a job is created while many jobs are done", func() {
+			for i := 0; i < maxDoneJobs+5; i++ {
+				s.jobs.entries = append(s.jobs.entries, &downloadJob{ID: "done-" + strconv.Itoa(i), Status: jobFinished})
+			}
+
+			response := post(s, `{"query":"berserk","sources":["test"],"manga":"first","chapters":"@nothing@"}`)
+			So(response.Code, ShouldEqual, http.StatusAccepted)
+			created := decode[downloadJob](response)
+
+			Convey("Then the oldest done jobs should be forgotten", func() {
+				So(request(s, http.MethodGet, "/api/inline/jobs/done-4").Code, ShouldEqual, http.StatusNotFound)
+				So(request(s, http.MethodGet, "/api/inline/jobs/done-5").Code, ShouldEqual, http.StatusOK)
+				So(request(s, http.MethodGet, "/api/inline/jobs/"+created.ID).Code, ShouldEqual, http.StatusOK)
+				So(len(decode[[]downloadJob](request(s, http.MethodGet, "/api/inline/jobs"))), ShouldBeLessThanOrEqualTo, maxDoneJobs+1)
+			})
+		})
+	})
+}
@@ -0,0 +1,406 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/metafates/mangal/downloader"
+	"github.com/metafates/mangal/inline"
+	"github.com/metafates/mangal/key"
+	"github.com/metafates/mangal/log"
+	"github.com/metafates/mangal/queue"
+	"github.com/metafates/mangal/search"
+	"github.com/metafates/mangal/source"
+	"github.com/samber/lo"
+	"github.com/spf13/viper"
+)
+
+// jobStatus is the state of the download job
+type jobStatus string
+
+const (
+	jobQueued    jobStatus = "queued"
+	jobRunning   jobStatus = "running"
+	jobFinished  jobStatus = "finished"
+	jobFailed    jobStatus = "failed"
+	jobCancelled jobStatus = "cancelled"
+)
+
+// maxDoneJobs is the number of done jobs that are listed,
+// older ones are forgotten so that the list does not grow forever
+const maxDoneJobs = 100
+
+// IsDone reports whether the job will not change anymore
+func (s jobStatus) IsDone() bool {
+	return s == jobFinished || s == jobFailed || s == jobCancelled
+}
+
+// jobRequest is the body of the request that creates the job.
+// Fields are the same as flags of the inline command
+type jobRequest struct {
+	Query    string   `json:"query"`
+	Sources  []string `json:"sources,omitempty"`
+	Manga    string   `json:"manga"`
+	Chapters string   `json:"chapters,omitempty"`
+}
+
+// downloadJob downloads chapters in the background
+type downloadJob struct {
+	ID      string     `json:"id"`
+	Status  jobStatus  `json:"status"`
+	Request jobRequest `json:"request"`
+	// Output is the manga with the chapters to download, the same as the inline json output
+	Output json.RawMessage `json:"output,omitempty"`
+	// Progress is the last message of the downloader
+	Progress string `json:"progress,omitempty"`
+	// Total is the number of chapters to download
+	Total int `json:"total"`
+	// Done is the number of chapters that were processed, failed ones included
+	Done int `json:"done"`
+	// Paths of the saved files
+	Paths  []string `json:"paths"`
+	Errors []string `json:"errors,omitempty"`
+	// Error is the reason the job has failed
+	Error    string     `json:"error,omitempty"`
+	Created  time.Time  `json:"created"`
+	Started  *time.Time `json:"started,omitempty"`
+	Finished *time.Time `json:"finished,omitempty"`
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// jobs runs download jobs one by one, so that sources are not flooded with requests
+type jobs struct {
+	mutex   sync.Mutex
+	entries []*downloadJob
+	lastID  int
+	pending chan *downloadJob
+	once    sync.Once
+}
+
+// snapshot returns a copy of the job that is safe to encode
+func (j *jobs) snapshot(job *downloadJob) downloadJob {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	copied := *job
+	copied.Paths = append([]string{}, job.Paths...)
+	copied.Errors = append([]string(nil), job.Errors...)
+	return copied
+}
+
+// prune forgets the oldest done jobs above maxDoneJobs.
+// Mutex must be held
+func (j *jobs) prune() {
+	done := lo.Filter(j.entries, func(job *downloadJob, _ int) bool {
+		return job.Status.IsDone()
+	})
+
+	if len(done) <= maxDoneJobs {
+		return
+	}
+
+	// entries are in the order of creation
+	j.entries = lo.Without(j.entries, done[:len(done)-maxDoneJobs]...)
+}
+
+func (j *jobs) find(id string) (*downloadJob, bool) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	return lo.Find(j.entries, func(job *downloadJob) bool {
+		return job.ID == id
+	})
+}
+
+func (j *jobs) update(job *downloadJob, f func(job *downloadJob)) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	f(job)
+}
+
+func (s *Server) serveJobs(w http.ResponseWriter, r *http.Request, segments []string) {
+	switch len(segments) {
+	case 0:
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			s.jobs.mutex.Lock()
+			entries := append([]*downloadJob(nil), s.jobs.entries...)
+			s.jobs.mutex.Unlock()
+
+			writeJSON(w, http.StatusOK, lo.Map(entries, func(job *downloadJob, _ int) downloadJob {
+				return s.jobs.snapshot(job)
+			}))
+		case http.MethodPost:
+			if s.allowWrite(w) {
+				s.createJob(w, r)
+			}
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	case 1:
+		job, ok := s.jobs.find(segments[0])
+		if !ok {
+			writeError(w, http.StatusNotFound, "job not found")
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			writeJSON(w, http.StatusOK, s.jobs.snapshot(job))
+		case http.MethodDelete:
+			if s.allowWrite(w) {
+				s.cancelJob(w, job)
+			}
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// createJob validates the request and queues the job
+func (s *Server) createJob(w http.ResponseWriter, r *http.Request) {
+	var request jobRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request: "+err.Error())
+		return
+	}
+
+	if request.Chapters == "" {
+		request.Chapters = "all"
+	}
+
+	switch {
+	case request.Query == "":
+		writeError(w, http.StatusBadRequest, "query is required")
+		return
+	case request.Manga == "":
+		writeError(w, http.StatusBadRequest, "manga is required")
+		return
+	}
+
+	if _, err := inline.ParseMangaPicker(request.Query, request.Manga); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if _, err := inline.ParseChaptersFilter(request.Chapters); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if _, err := s.sources(request.Sources); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &downloadJob{
+		Status:  jobQueued,
+		Request: request,
+		Paths:   make([]string, 0),
+		Created: time.Now(),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+
+	s.jobs.once.Do(func() {
+		s.jobs.pending = make(chan *downloadJob, 100)
+		go s.runJobs()
+	})
+
+	// the job is listed only if it was queued, rejected ones are forgotten
+	s.jobs.mutex.Lock()
+	select {
+	case s.jobs.pending <- job:
+		s.jobs.lastID++
+		job.ID = strconv.Itoa(s.jobs.lastID)
+		s.jobs.entries = append(s.jobs.entries, job)
+		s.jobs.prune()
+		s.jobs.mutex.Unlock()
+	default:
+		s.jobs.mutex.Unlock()
+		cancel()
+		writeError(w, http.StatusServiceUnavailable, "too many queued jobs")
+		return
+	}
+
+	w.Header().Set("Location", "/api/inline/jobs/"+job.ID)
+	writeJSON(w, http.StatusAccepted, s.jobs.snapshot(job))
+}
+
+// cancelJob cancels the queued or running job.
+// Jobs that are done are removed from the list instead
+func (s *Server) cancelJob(w http.ResponseWriter, job *downloadJob) {
+	s.jobs.mutex.Lock()
+	done := job.Status.IsDone()
+	if done {
+		s.jobs.entries = lo.Without(s.jobs.entries, job)
+	}
+	s.jobs.mutex.Unlock()
+
+	if done {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	// downloads of the running chapter are aborted too
+	job.cancel()
+
+	s.jobs.update(job, func(job *downloadJob) {
+		if job.Status == jobQueued {
+			now := time.Now()
+			job.Status = jobCancelled
+			job.Finished = &now
+		}
+	})
+
+	writeJSON(w, http.StatusAccepted, s.jobs.snapshot(job))
+}
+
+// runJobs runs the queued jobs one by one
+func (s *Server) runJobs() {
+	for job := range s.jobs.pending {
+		if job.ctx.Err() != nil {
+			continue
+		}
+
+		s.runJob(job)
+	}
+}
+
+func (s *Server) runJob(job *downloadJob) {
+	now := time.Now()
+	s.jobs.update(job, func(job *downloadJob) {
+		job.Status = jobRunning
+		job.Started = &now
+	})
+
+	err := s.download(job)
+
+	s.jobs.update(job, func(job *downloadJob) {
+		now := time.Now()
+		job.Finished = &now
+
+		switch {
+		case job.ctx.Err() != nil:
+			job.Status = jobCancelled
+		case err != nil:
+			job.Status = jobFailed
+			job.Error = err.Error()
+		default:
+			job.Status = jobFinished
+		}
+	})
+
+	job.cancel()
+}
+
+// download picks the manga and chapters the same way as the inline mode and downloads them
+func (s *Server) download(job *downloadJob) error {
+	request := job.Request
+
+	sources, err := s.sources(request.Sources)
+	if err != nil {
+		return err
+	}
+
+	result := search.Search(job.ctx, sources, request.Query)
+	if err = result.Err(); err != nil {
+		return err
+	}
+
+	picker := lo.Must(inline.ParseMangaPicker(request.Query, request.Manga))
+	manga := picker(result.Mangas())
+	if manga == nil {
+		return errors.New("manga not found")
+	}
+
+	chapters, err := source.WithContext(manga.Source).ChaptersOfContext(job.ctx, manga)
+	if err != nil {
+		return err
+	}
+
+	filter := lo.Must(inline.ParseChaptersFilter(request.Chapters))
+	if chapters, err = filter(chapters); err != nil {
+		return err
+	}
+
+	// output is encoded before the download, since the downloader populates the manga
+	manga.Chapters = chapters
+	output, err := json.Marshal(inline.NewOutput(request.Query, []*source.Manga{manga}, result, false))
+	if err != nil {
+		return err
+	}
+
+	s.jobs.update(job, func(job *downloadJob) {
+		job.Output = output
+		job.Total = len(chapters)
+	})
+
+	if err = queue.Enqueue(chapters...); err != nil {
+		log.Warn(err)
+	}
+
+	progress := func(message string) {
+		s.jobs.update(job, func(job *downloadJob) {
+			job.Progress = message
+		})
+	}
+
+	if viper.GetBool(key.FormatsBundleVolumes) {
+		for _, volume := range downloader.Volumes(chapters) {
+			if err = s.downloadStep(job, len(volume.Chapters), func() (string, error) {
+				return downloader.DownloadVolumeContext(job.ctx, volume, progress)
+			}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	for _, chapter := range chapters {
+		if err = s.downloadStep(job, 1, func() (string, error) {
+			return downloader.DownloadContext(job.ctx, chapter, progress)
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// downloadStep downloads chapters unless the job is cancelled and records the result.
+// Errors are returned only if the downloader should stop on them
+func (s *Server) downloadStep(job *downloadJob, chapters int, download func() (string, error)) error {
+	if err := job.ctx.Err(); err != nil {
+		return err
+	}
+
+	path, err := download()
+
+	s.jobs.update(job, func(job *downloadJob) {
+		job.Done += chapters
+		if err != nil {
+			job.Errors = append(job.Errors, err.Error())
+		} else {
+			job.Paths = append(job.Paths, path)
+		}
+	})
+
+	if err != nil && viper.GetBool(key.DownloaderStopOnError) {
+		return err
+	}
+
+	return nil
+}
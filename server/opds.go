@@ -0,0 +1,373 @@
+package server
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image"
+	"image/jpeg"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/metafates/mangal/constant"
+	"github.com/metafates/mangal/filesystem"
+	"github.com/metafates/mangal/imaging"
+	"github.com/metafates/mangal/library"
+	"github.com/metafates/mangal/log"
+	"github.com/metafates/mangal/util"
+	"github.com/samber/lo"
+)
+
+// https://specs.opds.io/opds-1.2 and https://github.com/anansi-project/opds-pse
+const (
+	opdsNavigation  = "application/atom+xml;profile=opds-catalog;kind=navigation"
+	opdsAcquisition = "application/atom+xml;profile=opds-catalog;kind=acquisition"
+
+	relAcquisition = "http://opds-spec.org/acquisition"
+	relImage       = "http://opds-spec.org/image"
+	relThumbnail   = "http://opds-spec.org/image/thumbnail"
+	relStream      = "http://vaemendis.net/opds-pse/stream"
+)
+
+// thumbnailHeight is the height of the cover thumbnails in pixels
+const thumbnailHeight = 300
+
+// otherSource is the name of the navigation feed for series without a source directory
+const otherSource = "Other"
+
+// acquisitionTypes are media types of the formats that can be downloaded from the catalog
+var acquisitionTypes = map[string]string{
+	constant.FormatCBZ:  "application/vnd.comicbook+zip",
+	constant.FormatZIP:  "application/zip",
+	constant.FormatPDF:  "application/pdf",
+	constant.FormatEPUB: "application/epub+zip",
+}
+
+type opdsFeed struct {
+	XMLName   xml.Name    `xml:"feed"`
+	Xmlns     string      `xml:"xmlns,attr"`
+	XmlnsOPDS string      `xml:"xmlns:opds,attr"`
+	XmlnsPSE  string      `xml:"xmlns:pse,attr"`
+	XmlnsDC   string      `xml:"xmlns:dc,attr"`
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Updated   time.Time   `xml:"updated"`
+	Author    opdsAuthor  `xml:"author"`
+	Links     []opdsLink  `xml:"link"`
+	Entries   []opdsEntry `xml:"entry"`
+}
+
+type opdsEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Updated    time.Time      `xml:"updated"`
+	Authors    []opdsAuthor   `xml:"author"`
+	Categories []opdsCategory `xml:"category"`
+	Language   string         `xml:"dc:language,omitempty"`
+	Issued     string         `xml:"dc:issued,omitempty"`
+	Content    *opdsContent   `xml:"content"`
+	Links      []opdsLink     `xml:"link"`
+}
+
+type opdsAuthor struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri,omitempty"`
+}
+
+type opdsCategory struct {
+	Term  string `xml:"term,attr"`
+	Label string `xml:"label,attr"`
+}
+
+type opdsContent struct {
+	Type string `xml:"type,attr"`
+	Text string `xml:",chardata"`
+}
+
+type opdsLink struct {
+	Rel   string `xml:"rel,attr"`
+	Href  string `xml:"href,attr"`
+	Type  string `xml:"type,attr,omitempty"`
+	Title string `xml:"title,attr,omitempty"`
+	Count int    `xml:"pse:count,attr,omitempty"`
+}
+
+// serveOPDS routes requests of the OPDS catalog:
+//
+//	GET /opds
+//	GET /opds/series
+//	GET /opds/sources/{source}
+//	GET /opds/series/{series}
+//	GET /opds/series/{series}/thumbnail
+//
+// Chapters and pages link to the JSON API, so that they are served the same way
+func (s *Server) serveOPDS(w http.ResponseWriter, r *http.Request, segments []string) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+
+	s.mutex.RLock()
+	index := s.index
+	s.mutex.RUnlock()
+
+	switch {
+	case len(segments) == 0:
+		writeFeed(w, s.rootFeed(index))
+	case len(segments) == 1 && segments[0] == "series":
+		writeFeed(w, s.seriesListFeed("series", "All series", index.Series, index.Scanned))
+	case len(segments) == 2 && segments[0] == "sources":
+		series := lo.Filter(index.Series, func(series *library.Series, _ int) bool {
+			return id(sourceName(series)) == segments[1]
+		})
+		if len(series) == 0 {
+			writeError(w, http.StatusNotFound, "source not found")
+			return
+		}
+
+		writeFeed(w, s.seriesListFeed("sources/"+segments[1], sourceName(series[0]), series, index.Scanned))
+	case len(segments) >= 2 && segments[0] == "series":
+		series, ok := lo.Find(index.Series, func(series *library.Series) bool {
+			return id(series.Path) == segments[1]
+		})
+		if !ok {
+			writeError(w, http.StatusNotFound, "series not found")
+			return
+		}
+
+		switch {
+		case len(segments) == 2:
+			writeFeed(w, s.seriesFeed(series))
+		case len(segments) == 3 && segments[2] == "thumbnail":
+			s.serveThumbnail(w, r, series)
+		default:
+			writeError(w, http.StatusNotFound, "not found")
+		}
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// rootFeed is the navigation feed with all sources
+func (s *Server) rootFeed(index *library.Index) *opdsFeed {
+	feed := newFeed("urn:mangal:root", constant.Mangal, index.Scanned, "/opds", opdsNavigation)
+
+	feed.Entries = append(feed.Entries, opdsEntry{
+		Title:   "All series",
+		ID:      "urn:mangal:series",
+		Updated: index.Scanned,
+		Content: &opdsContent{Type: "text", Text: strconv.Itoa(len(index.Series)) + " series"},
+		Links:   []opdsLink{{Rel: "subsection", Href: "/opds/series", Type: opdsNavigation}},
+	})
+
+	sources := lo.GroupBy(index.Series, sourceName)
+	names := lo.Keys(sources)
+	sort.Strings(names)
+
+	for _, name := range names {
+		series := sources[name]
+		sourceID := id(name)
+
+		feed.Entries = append(feed.Entries, opdsEntry{
+			Title:   name,
+			ID:      "urn:mangal:source:" + sourceID,
+			Updated: lo.MaxBy(series, func(a, b *library.Series) bool { return a.Updated.After(b.Updated) }).Updated,
+			Content: &opdsContent{Type: "text", Text: strconv.Itoa(len(series)) + " series"},
+			Links:   []opdsLink{{Rel: "subsection", Href: "/opds/sources/" + sourceID, Type: opdsNavigation}},
+		})
+	}
+
+	return feed
+}
+
+// seriesListFeed is the navigation feed with the given series, path is relative to the catalog root
+func (s *Server) seriesListFeed(path, title string, series []*library.Series, updated time.Time) *opdsFeed {
+	feed := newFeed("urn:mangal:"+strings.ReplaceAll(path, "/", ":"), title, updated, "/opds/"+path, opdsNavigation)
+	feed.Links = append(feed.Links, opdsLink{Rel: "up", Href: "/opds", Type: opdsNavigation})
+
+	for _, series := range series {
+		entry := s.seriesEntry(series)
+		entry.Links = append(entry.Links, opdsLink{Rel: "subsection", Href: "/opds/series/" + id(series.Path), Type: opdsAcquisition})
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	return feed
+}
+
+// seriesFeed is the acquisition feed with the chapters of the series
+func (s *Server) seriesFeed(series *library.Series) *opdsFeed {
+	seriesID := id(series.Path)
+
+	feed := newFeed("urn:mangal:series:"+seriesID, series.Name, series.Updated, "/opds/series/"+seriesID, opdsAcquisition)
+	feed.Links = append(feed.Links, opdsLink{Rel: "up", Href: "/opds/sources/" + id(sourceName(series)), Type: opdsNavigation})
+
+	seriesEntry := s.seriesEntry(series)
+
+	for _, chapter := range series.Chapters {
+		entry := opdsEntry{
+			Title:      chapter.Name,
+			ID:         "urn:mangal:chapter:" + id(chapter.Path),
+			Updated:    chapter.Modified,
+			Authors:    seriesEntry.Authors,
+			Categories: seriesEntry.Categories,
+			Language:   chapter.LanguageISO,
+			Issued:     seriesEntry.Issued,
+			Content:    seriesEntry.Content,
+			Links:      append([]opdsLink(nil), seriesEntry.Links...),
+		}
+
+		if chapter.Volume != "" {
+			entry.Title = chapter.Volume + " / " + chapter.Name
+		}
+
+		if chapter.Summary != "" {
+			entry.Content = &opdsContent{Type: "text", Text: chapter.Summary}
+		}
+
+		url := chapterURL(series, chapter)
+
+		if mediaType, ok := acquisitionTypes[chapter.Format]; ok {
+			entry.Links = append(entry.Links, opdsLink{
+				Rel:   relAcquisition,
+				Href:  url + "/file",
+				Type:  mediaType,
+				Title: chapter.Format,
+			})
+		}
+
+		// page streaming extension lets readers fetch pages one by one instead of the whole archive
+		if readable(chapter) && chapter.Pages > 0 {
+			entry.Links = append(entry.Links, opdsLink{
+				Rel:   relStream,
+				Href:  url + "/pages/{pageNumber}",
+				Type:  "image/jpeg",
+				Count: chapter.Pages,
+			})
+		}
+
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	return feed
+}
+
+// seriesEntry returns the entry with the metadata and the cover of the series
+func (s *Server) seriesEntry(series *library.Series) opdsEntry {
+	entry := opdsEntry{
+		Title:    series.Name,
+		ID:       "urn:mangal:series:" + id(series.Path),
+		Updated:  series.Updated,
+		Language: series.Language,
+	}
+
+	for _, author := range series.Authors {
+		entry.Authors = append(entry.Authors, opdsAuthor{Name: author})
+	}
+
+	for _, genre := range series.Genres {
+		entry.Categories = append(entry.Categories, opdsCategory{Term: genre, Label: genre})
+	}
+
+	if series.Year > 0 {
+		entry.Issued = strconv.Itoa(series.Year)
+	}
+
+	if series.Summary != "" {
+		entry.Content = &opdsContent{Type: "text", Text: series.Summary}
+	}
+
+	if _, ok := s.cover(series); ok {
+		entry.Links = append(entry.Links,
+			opdsLink{Rel: relImage, Href: seriesURL(series) + "/cover"},
+			opdsLink{Rel: relThumbnail, Href: "/opds/series/" + id(series.Path) + "/thumbnail", Type: "image/jpeg"},
+		)
+	}
+
+	return entry
+}
+
+// serveThumbnail serves the downscaled cover of the series.
+// The original cover is served if it can't be decoded
+func (s *Server) serveThumbnail(w http.ResponseWriter, r *http.Request, series *library.Series) {
+	path, ok := s.cover(series)
+	if !ok {
+		writeError(w, http.StatusNotFound, "cover not found")
+		return
+	}
+
+	contents, err := filesystem.Api().ReadFile(path)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(contents))
+	if err != nil {
+		log.Warnf("could not decode the cover %s: %s", path, err)
+		s.serveFile(w, r, path)
+		return
+	}
+
+	if bounds := img.Bounds(); bounds.Dy() > thumbnailHeight {
+		width := util.Max(1, bounds.Dx()*thumbnailHeight/bounds.Dy())
+		img = imaging.Resize(img, width, thumbnailHeight)
+	}
+
+	var buf bytes.Buffer
+	if err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85}); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	setImageHeaders(w, "thumbnail.jpg")
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	_, _ = w.Write(buf.Bytes())
+}
+
+func newFeed(feedID, title string, updated time.Time, self, kind string) *opdsFeed {
+	return &opdsFeed{
+		Xmlns:     "http://www.w3.org/2005/Atom",
+		XmlnsOPDS: "http://opds-spec.org/2010/catalog",
+		XmlnsPSE:  "http://vaemendis.net/opds-pse/ns",
+		XmlnsDC:   "http://purl.org/dc/terms/",
+		ID:        feedID,
+		Title:     title,
+		Updated:   updated,
+		Author:    opdsAuthor{Name: constant.Mangal, URI: "https://github.com/metafates/mangal"},
+		Links: []opdsLink{
+			{Rel: "self", Href: self, Type: kind},
+			{Rel: "start", Href: "/opds", Type: opdsNavigation},
+		},
+	}
+}
+
+func writeFeed(w http.ResponseWriter, feed *opdsFeed) {
+	kind := opdsNavigation
+	if feed.Links[0].Type == opdsAcquisition {
+		kind = opdsAcquisition
+	}
+
+	w.Header().Set("Content-Type", kind+";charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	_, _ = w.Write([]byte(xml.Header))
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(feed); err != nil {
+		log.Warn(err)
+	}
+}
+
+// sourceName returns the name of the source directory of the series
+func sourceName(series *library.Series) string {
+	switch {
+	case series.Source == "":
+		return otherSource
+	case series.Language != "":
+		return series.Source + " [" + series.Language + "]"
+	default:
+		return series.Source
+	}
+}
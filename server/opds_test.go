@@ -0,0 +1,111 @@
+package server
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/metafates/mangal/key"
+	"github.com/metafates/mangal/source"
+	"github.com/samber/lo"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/spf13/viper"
+)
+
+func TestOPDS(t *testing.T) {
+	viper.Set(key.DownloaderCreateSourceDir, true)
+
+	root := filepath.Join("server", "opds")
+	berserk := filepath.Join(root, "Mangadex", "Berserk")
+	naruto := filepath.Join(root, "Manganelo [ru]", "Naruto")
+
+	var cover bytes.Buffer
+	lo.Must0(png.Encode(&cover, image.NewGray(image.Rect(0, 0, 100, 600))))
+
+	writeFile(filepath.Join(berserk, "series.json"), []byte(`{"metadata":{"name":"Berserk","year":1989,"description_text":"Guts"}}`))
+	writeFile(filepath.Join(berserk, "cover.png"), cover.Bytes())
+	writeFile(filepath.Join(berserk, "[0001] Chapter 1.cbz"), cbz(map[string]string{
+		"1.jpg":         "first",
+		"2.jpg":         "second",
+		"ComicInfo.xml": string(lo.Must(xml.Marshal(&source.ComicInfo{Title: "The Black Swordsman", Writer: "Kentaro Miura", Genre: "Action"}))),
+	}))
+	writeFile(filepath.Join(berserk, "[0002] Chapter 2.pdf"), []byte("pdf"))
+	writeFile(filepath.Join(naruto, "[0001] Chapter 1", "1.jpg"), []byte("plain"))
+
+	berserkID := id(filepath.Join("Mangadex", "Berserk"))
+
+	Convey("Given a server with the OPDS catalog", t, func() {
+		s, err := New(Options{Root: root, ReadOnly: true})
+		So(err, ShouldBeNil)
+
+		Convey("When the root feed is requested", func() {
+			response := request(s, http.MethodGet, "/opds")
+			So(response.Code, ShouldEqual, http.StatusOK)
+			So(response.Header().Get("Content-Type"), ShouldContainSubstring, "kind=navigation")
+
+			var feed opdsFeed
+			So(xml.Unmarshal(response.Body.Bytes(), &feed), ShouldBeNil)
+
+			Convey("Then it should list all sources", func() {
+				titles := lo.Map(feed.Entries, func(entry opdsEntry, _ int) string { return entry.Title })
+				So(titles, ShouldResemble, []string{"All series", "Mangadex", "Manganelo [ru]"})
+			})
+
+			Convey("Then sources should link to their series", func() {
+				source := request(s, http.MethodGet, feed.Entries[1].Links[0].Href)
+				So(source.Code, ShouldEqual, http.StatusOK)
+				So(source.Body.String(), ShouldContainSubstring, "<title>Berserk</title>")
+				So(source.Body.String(), ShouldNotContainSubstring, "Naruto")
+			})
+		})
+
+		Convey("When the series feed is requested", func() {
+			response := request(s, http.MethodGet, "/opds/series/"+berserkID)
+			So(response.Code, ShouldEqual, http.StatusOK)
+			So(response.Header().Get("Content-Type"), ShouldContainSubstring, "kind=acquisition")
+
+			body := response.Body.String()
+
+			Convey("Then chapters should have acquisition links", func() {
+				So(body, ShouldContainSubstring, `type="application/vnd.comicbook+zip"`)
+				So(body, ShouldContainSubstring, `type="application/pdf"`)
+			})
+
+			Convey("Then cbz chapters should have page streaming links", func() {
+				So(body, ShouldContainSubstring, `rel="http://vaemendis.net/opds-pse/stream"`)
+				So(body, ShouldContainSubstring, `pse:count="2"`)
+				So(body, ShouldContainSubstring, "/pages/{pageNumber}")
+			})
+
+			Convey("Then metadata should be taken from series.json and ComicInfo.xml", func() {
+				So(body, ShouldContainSubstring, "<title>The Black Swordsman</title>")
+				So(body, ShouldContainSubstring, "<name>Kentaro Miura</name>")
+				So(body, ShouldContainSubstring, `term="Action"`)
+				So(body, ShouldContainSubstring, "<dc:issued>1989</dc:issued>")
+			})
+		})
+
+		Convey("When the thumbnail is requested", func() {
+			response := request(s, http.MethodGet, "/opds/series/"+berserkID+"/thumbnail")
+			So(response.Code, ShouldEqual, http.StatusOK)
+
+			Convey("Then the cover should be downscaled", func() {
+				thumbnail, err := jpeg.Decode(response.Body)
+				So(err, ShouldBeNil)
+				So(thumbnail.Bounds().Dy(), ShouldEqual, thumbnailHeight)
+				So(thumbnail.Bounds().Dx(), ShouldEqual, 50)
+			})
+		})
+
+		Convey("When an unknown source is requested", func() {
+			Convey("Then it should not be found", func() {
+				So(request(s, http.MethodGet, "/opds/sources/unknown").Code, ShouldEqual, http.StatusNotFound)
+			})
+		})
+	})
+}
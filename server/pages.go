@@ -0,0 +1,226 @@
+package server
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/metafates/mangal/constant"
+	"github.com/metafates/mangal/filesystem"
+	"github.com/metafates/mangal/library"
+	"github.com/metafates/mangal/util"
+)
+
+// errNotReadable is returned for chapters which pages can't be read, such as pdf
+var errNotReadable = errors.New("pages of this format can't be read, download the file instead")
+
+// readable reports whether pages of the chapter can be served
+func readable(chapter *library.Chapter) bool {
+	switch chapter.Format {
+	case constant.FormatCBZ, constant.FormatZIP, constant.FormatPlain:
+		return true
+	default:
+		return false
+	}
+}
+
+// archive is an opened cbz or zip chapter
+type archive struct {
+	file   io.Closer
+	images []*zip.File
+}
+
+func (a *archive) Close() error {
+	return a.file.Close()
+}
+
+// openArchive opens the chapter archive, images are sorted by name
+func openArchive(path string) (*archive, error) {
+	file, err := filesystem.Api().Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	reader, err := zip.NewReader(file, stat.Size())
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	var images []*zip.File
+	for _, f := range reader.File {
+		if !f.FileInfo().IsDir() && library.IsImage(f.Name) {
+			images = append(images, f)
+		}
+	}
+
+	sort.Slice(images, func(i, j int) bool {
+		return images[i].Name < images[j].Name
+	})
+
+	return &archive{file: file, images: images}, nil
+}
+
+// plainImages returns names of the images in the chapter directory sorted by name
+func plainImages(path string) ([]string, error) {
+	entries, err := filesystem.Api().ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var images []string
+	for _, entry := range entries {
+		if !entry.IsDir() && library.IsImage(entry.Name()) {
+			images = append(images, entry.Name())
+		}
+	}
+
+	sort.Strings(images)
+	return images, nil
+}
+
+// pageNames returns names of the chapter pages in the reading order
+func (s *Server) pageNames(chapter *library.Chapter) ([]string, error) {
+	path := filepath.Join(s.options.Root, chapter.Path)
+
+	switch chapter.Format {
+	case constant.FormatCBZ, constant.FormatZIP:
+		a, err := openArchive(path)
+		if err != nil {
+			return nil, err
+		}
+
+		defer util.Ignore(a.Close)
+
+		names := make([]string, len(a.images))
+		for i, image := range a.images {
+			names[i] = image.Name
+		}
+
+		return names, nil
+	case constant.FormatPlain:
+		return plainImages(path)
+	default:
+		return nil, errNotReadable
+	}
+}
+
+// servePage streams the page with the index from the chapter.
+// Pages of archives are decompressed on the fly
+func (s *Server) servePage(w http.ResponseWriter, r *http.Request, chapter *library.Chapter, value string) {
+	index, err := strconv.Atoi(value)
+	if err != nil || index < 0 {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid page %q", value))
+		return
+	}
+
+	path := filepath.Join(s.options.Root, chapter.Path)
+
+	switch chapter.Format {
+	case constant.FormatCBZ, constant.FormatZIP:
+		a, err := openArchive(path)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		defer util.Ignore(a.Close)
+
+		if index >= len(a.images) {
+			writeError(w, http.StatusNotFound, "page not found")
+			return
+		}
+
+		image := a.images[index]
+		reader, err := image.Open()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		defer util.Ignore(reader.Close)
+
+		setImageHeaders(w, image.Name)
+		w.Header().Set("Content-Length", strconv.FormatUint(image.UncompressedSize64, 10))
+		w.WriteHeader(http.StatusOK)
+
+		if r.Method != http.MethodHead {
+			_, _ = io.Copy(w, reader)
+		}
+	case constant.FormatPlain:
+		images, err := plainImages(path)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		if index >= len(images) {
+			writeError(w, http.StatusNotFound, "page not found")
+			return
+		}
+
+		s.serveFile(w, r, filepath.Join(path, images[index]))
+	default:
+		writeError(w, http.StatusUnsupportedMediaType, errNotReadable.Error())
+	}
+}
+
+// serveFile serves the file with support of range and conditional requests
+func (s *Server) serveFile(w http.ResponseWriter, r *http.Request, path string) {
+	file, err := filesystem.Api().Open(path)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "file not found")
+		return
+	}
+
+	defer util.Ignore(file.Close)
+
+	stat, err := file.Stat()
+	if err != nil || stat.IsDir() {
+		writeError(w, http.StatusNotFound, "file not found")
+		return
+	}
+
+	setImageHeaders(w, path)
+	http.ServeContent(w, r, stat.Name(), stat.ModTime(), file)
+}
+
+func setImageHeaders(w http.ResponseWriter, name string) {
+	if contentType := mime.TypeByExtension(strings.ToLower(filepath.Ext(name))); contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+
+	// downloaded pages rarely change, so the browser doesn't have to request them again while reading
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+}
+
+// cover returns the path of the series cover, if it was downloaded
+func (s *Server) cover(series *library.Series) (string, bool) {
+	dir := filepath.Join(s.options.Root, series.Path)
+
+	entries, err := filesystem.Api().ReadDir(dir)
+	if err != nil {
+		return "", false
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() && util.FileStem(entry.Name()) == "cover" && library.IsImage(entry.Name()) {
+			return filepath.Join(dir, entry.Name()), true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,139 @@
+// Package server serves the downloaded manga over http
+// with a minimal web reader, a JSON API and an OPDS catalog.
+// It also runs the inline mode and download jobs over http.
+package server
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/metafates/mangal/constant"
+	"github.com/metafates/mangal/library"
+	"github.com/metafates/mangal/log"
+	"github.com/metafates/mangal/source"
+)
+
+//go:embed reader.html
+var readerPage []byte
+
+// Options of the server
+type Options struct {
+	// Root is the downloads directory
+	Root string
+	// ReadOnly disables requests that change the library or download chapters
+	ReadOnly bool
+	// Username and Password enable the basic authentication if the username is set
+	Username, Password string
+}
+
+// Server is the http handler of the library
+type Server struct {
+	options Options
+
+	mutex sync.RWMutex
+	index *library.Index
+
+	sourcesMutex sync.Mutex
+	sourcesCache map[string]source.Source
+
+	jobs jobs
+}
+
+// New scans the downloads directory and returns the server
+func New(options Options) (*Server, error) {
+	s := &Server{options: options}
+
+	if err := s.scan(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// scan indexes the downloads directory and saves the index, so that library command can use it
+func (s *Server) scan() error {
+	index, err := library.Scan(s.options.Root)
+	if err != nil {
+		return err
+	}
+
+	if err = library.Save(index); err != nil {
+		log.Warn(err)
+	}
+
+	s.mutex.Lock()
+	s.index = index
+	s.mutex.Unlock()
+
+	return nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="`+constant.Mangal+`", charset="UTF-8"`)
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	path := strings.Trim(r.URL.Path, "/")
+
+	switch {
+	case path == "":
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(readerPage)
+	case path == "api" || strings.HasPrefix(path, "api/"):
+		s.serveAPI(w, r, strings.Split(strings.TrimPrefix(path, "api"), "/")[1:])
+	case path == "opds" || strings.HasPrefix(path, "opds/"):
+		s.serveOPDS(w, r, strings.Split(strings.TrimPrefix(path, "opds"), "/")[1:])
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// authorized checks the basic authentication, if it's enabled
+func (s *Server) authorized(r *http.Request) bool {
+	if s.options.Username == "" {
+		return true
+	}
+
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+
+	usernameMatch := subtle.ConstantTimeCompare([]byte(username), []byte(s.options.Username)) == 1
+	passwordMatch := subtle.ConstantTimeCompare([]byte(password), []byte(s.options.Password)) == 1
+
+	return usernameMatch && passwordMatch
+}
+
+// id is the identifier of the series or chapter in urls.
+// It's derived from the path, so that it stays the same after rescans
+func id(path string) string {
+	sum := sha256.Sum256([]byte(path))
+	return hex.EncodeToString(sum[:6])
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		log.Warn(err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
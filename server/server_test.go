@@ -0,0 +1,183 @@
+package server
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/metafates/mangal/filesystem"
+	"github.com/samber/lo"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func init() {
+	filesystem.SetMemMapFs()
+}
+
+func writeFile(path string, contents []byte) {
+	lo.Must0(filesystem.Api().MkdirAll(filepath.Dir(path), os.ModePerm))
+	lo.Must0(filesystem.Api().WriteFile(path, contents, os.ModePerm))
+}
+
+func cbz(pages map[string]string) []byte {
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+
+	for name, contents := range pages {
+		w := lo.Must(writer.Create(name))
+		_, _ = w.Write([]byte(contents))
+	}
+
+	lo.Must0(writer.Close())
+	return buf.Bytes()
+}
+
+func request(handler http.Handler, method, path string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(method, path, nil))
+	return recorder
+}
+
+func decode[T any](recorder *httptest.ResponseRecorder) T {
+	var value T
+	lo.Must0(json.Unmarshal(recorder.Body.Bytes(), &value))
+	return value
+}
+
+func TestServer(t *testing.T) {
+	root := filepath.Join("server", "downloads")
+	berserk := filepath.Join(root, "Mangadex", "Berserk")
+
+	writeFile(filepath.Join(berserk, "series.json"), []byte(`{"metadata":{"name":"Berserk"}}`))
+	writeFile(filepath.Join(berserk, "cover.png"), []byte("cover"))
+	writeFile(filepath.Join(berserk, "[0001] Chapter 1.cbz"), cbz(map[string]string{
+		"2.jpg":         "second",
+		"1.jpg":         "first",
+		"ComicInfo.xml": "<ComicInfo></ComicInfo>",
+	}))
+	writeFile(filepath.Join(berserk, "[0002] Chapter 2", "1.png"), []byte("plain"))
+	writeFile(filepath.Join(berserk, "[0003] Chapter 3.pdf"), []byte("pdf"))
+
+	Convey("Given a server over the downloads directory", t, func() {
+		s, err := New(Options{Root: root, ReadOnly: true})
+		So(err, ShouldBeNil)
+
+		Convey("When the series are listed", func() {
+			response := request(s, http.MethodGet, "/api/series")
+			So(response.Code, ShouldEqual, http.StatusOK)
+
+			series := decode[[]map[string]any](response)
+
+			Convey("Then the series should have the cover and no chapters", func() {
+				So(series, ShouldHaveLength, 1)
+				So(series[0]["name"], ShouldEqual, "Berserk")
+				So(series[0]["chapters_count"], ShouldEqual, 3)
+				So(series[0]["cover"], ShouldEqual, "/api/series/"+id(filepath.Join("Mangadex", "Berserk"))+"/cover")
+				So(series[0], ShouldNotContainKey, "chapters")
+			})
+		})
+
+		Convey("When the series is requested", func() {
+			seriesID := id(filepath.Join("Mangadex", "Berserk"))
+			response := request(s, http.MethodGet, "/api/series/"+seriesID)
+			So(response.Code, ShouldEqual, http.StatusOK)
+
+			chapters := decode[seriesResponse](response).Chapters
+			So(chapters, ShouldHaveLength, 3)
+
+			Convey("Then pdf chapters should be downloadable, but not readable", func() {
+				So(chapters[2].Readable, ShouldBeFalse)
+				So(chapters[2].PagesURL, ShouldBeEmpty)
+				So(request(s, http.MethodGet, chapters[2].FileURL).Body.String(), ShouldEqual, "pdf")
+			})
+
+			Convey("Then cbz pages should be streamed in order", func() {
+				pages := decode[[]map[string]any](request(s, http.MethodGet, chapters[0].PagesURL))
+				So(pages, ShouldHaveLength, 2)
+				So(pages[0]["name"], ShouldEqual, "1.jpg")
+
+				page := request(s, http.MethodGet, pages[1]["url"].(string))
+				So(page.Code, ShouldEqual, http.StatusOK)
+				So(page.Body.String(), ShouldEqual, "second")
+				So(page.Header().Get("Content-Type"), ShouldEqual, "image/jpeg")
+			})
+
+			Convey("Then plain pages should be served", func() {
+				page := request(s, http.MethodGet, chapters[1].PagesURL+"/0")
+				So(page.Code, ShouldEqual, http.StatusOK)
+				So(page.Body.String(), ShouldEqual, "plain")
+			})
+
+			Convey("Then pages out of range should not be found", func() {
+				So(request(s, http.MethodGet, chapters[0].PagesURL+"/2").Code, ShouldEqual, http.StatusNotFound)
+				So(request(s, http.MethodGet, chapters[0].PagesURL+"/x").Code, ShouldEqual, http.StatusBadRequest)
+			})
+
+			Convey("Then chapters should not be deleted in the read-only mode", func() {
+				So(request(s, http.MethodDelete, strings.TrimSuffix(chapters[2].FileURL, "/file")).Code, ShouldEqual, http.StatusForbidden)
+				So(request(s, http.MethodPost, "/api/scan").Code, ShouldEqual, http.StatusForbidden)
+			})
+		})
+
+		Convey("When the reader page is requested", func() {
+			response := request(s, http.MethodGet, "/")
+
+			Convey("Then it should be served", func() {
+				So(response.Code, ShouldEqual, http.StatusOK)
+				So(response.Header().Get("Content-Type"), ShouldStartWith, "text/html")
+			})
+		})
+
+		Convey("When unknown paths are requested", func() {
+			Convey("Then they should not be found", func() {
+				So(request(s, http.MethodGet, "/api/series/unknown").Code, ShouldEqual, http.StatusNotFound)
+				So(request(s, http.MethodGet, "/unknown").Code, ShouldEqual, http.StatusNotFound)
+			})
+		})
+	})
+}
+
+func TestAuthentication(t *testing.T) {
+	Convey("Given a server with the basic authentication", t, func() {
+		s, err := New(Options{Root: filepath.Join("server", "empty"), Username: "user", Password: "secret"})
+		So(err, ShouldBeNil)
+
+		Convey("When the credentials are missing", func() {
+			response := request(s, http.MethodGet, "/api")
+
+			Convey("Then the request should be unauthorized", func() {
+				So(response.Code, ShouldEqual, http.StatusUnauthorized)
+				So(response.Header().Get("WWW-Authenticate"), ShouldStartWith, "Basic")
+			})
+		})
+
+		Convey("When the credentials are wrong", func() {
+			r := httptest.NewRequest(http.MethodGet, "/api", nil)
+			r.SetBasicAuth("user", "wrong")
+			response := httptest.NewRecorder()
+			s.ServeHTTP(response, r)
+
+			Convey("Then the request should be unauthorized", func() {
+				So(response.Code, ShouldEqual, http.StatusUnauthorized)
+			})
+		})
+
+		Convey("When the credentials are correct", func() {
+			r := httptest.NewRequest(http.MethodGet, "/api", nil)
+			r.SetBasicAuth("user", "secret")
+			response := httptest.NewRecorder()
+			s.ServeHTTP(response, r)
+
+			Convey("Then the request should be served", func() {
+				So(response.Code, ShouldEqual, http.StatusOK)
+				So(decode[map[string]any](response)["read_only"], ShouldBeFalse)
+			})
+		})
+	})
+}
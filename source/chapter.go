@@ -1,13 +1,15 @@
 package source
 
 import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/dustin/go-humanize"
@@ -16,6 +18,7 @@ import (
 	"github.com/metafates/mangal/key"
 	"github.com/metafates/mangal/style"
 	"github.com/metafates/mangal/util"
+	"github.com/metafates/mangal/where"
 	"github.com/samber/mo"
 	"github.com/spf13/viper"
 )
@@ -34,6 +37,10 @@ type Chapter struct {
 	ID string `json:"id" jsonschema:"description=ID of the chapter in the source"`
 	// Volume which the chapter belongs to.
 	Volume string `json:"volume" jsonschema:"description=Volume which the chapter belongs to"`
+	// Date when the chapter was uploaded.
+	Date date `json:"date" jsonschema:"description=Date when the chapter was uploaded"`
+	// Scanlators are the groups that translated the chapter.
+	Scanlators []string `json:"scanlators" jsonschema:"description=Scanlation groups that translated the chapter"`
 	// Manga that the chapter belongs to.
 	Manga *Manga `json:"-"`
 	// Pages of the chapter.
@@ -57,7 +64,14 @@ func (c *Chapter) CleanPages() {
 
 // DownloadPages downloads the Pages contents of the Chapter.
 // Pages needs to be set before calling this function.
-func (c *Chapter) DownloadPages(temp bool, progress func(string)) (err error) {
+// Pages are downloaded by a bounded pool of workers, see pageWorkers.
+func (c *Chapter) DownloadPages(temp bool, progress func(string)) error {
+	return c.DownloadPagesContext(context.Background(), temp, progress)
+}
+
+// DownloadPagesContext is the same as DownloadPages but the downloads
+// are aborted as soon as the context is done.
+func (c *Chapter) DownloadPagesContext(ctx context.Context, temp bool, progress func(string)) (err error) {
 	c.size = 0
 	status := func() string {
 		return fmt.Sprintf(
@@ -68,35 +82,28 @@ func (c *Chapter) DownloadPages(temp bool, progress func(string)) (err error) {
 	}
 
 	progress(status())
-	wg := sync.WaitGroup{}
-	wg.Add(len(c.Pages))
 
-	for _, page := range c.Pages {
+	for i, page := range c.Pages {
 		if page == nil {
-			return fmt.Errorf("page #%d is empty, aborting download", page.Index)
+			return fmt.Errorf("page #%d is empty, aborting download", i)
 		}
+	}
 
-		d := func(page *Page) {
-			defer wg.Done()
-
-			// if at any point, an error is encountered, stop downloading other pages
-			if err != nil {
-				return
-			}
+	var src Source
+	if c.Manga != nil {
+		src = c.Manga.Source
+	}
 
-			err = page.Download()
+	err = downloadPages(
+		ctx,
+		c.Pages,
+		pageWorkers(src),
+		viper.GetInt(key.DownloaderPageWorkersPerHost),
+		func(page *Page) {
 			c.size += page.Size
 			progress(status())
-		}
-
-		if viper.GetBool(key.DownloaderAsync) {
-			go d(page)
-		} else {
-			d(page)
-		}
-	}
-
-	wg.Wait()
+		},
+	)
 
 	if err != nil {
 		c.isDownloaded = mo.Some(false)
@@ -160,7 +167,7 @@ func (c *Chapter) Filename() (filename string) {
 
 	// plain format assumes that chapter is a directory with images
 	// rather than a single file. So no need to add extension to it
-	if f := viper.GetString(key.FormatsUse); f != constant.FormatPlain {
+	if f := c.Manga.SaveFormat(); f != constant.FormatPlain {
 		return filename + "." + f
 	}
 
@@ -201,6 +208,23 @@ func (c *Chapter) Path(temp bool) (path string, err error) {
 	return c.path(manga, c.Volume != "" && viper.GetBool(key.DownloaderCreateVolumeDir))
 }
 
+// stagingPath is the directory where downloaded pages are kept until the chapter is saved
+func (c *Chapter) stagingPath() string {
+	var sourceID string
+	if c.Manga != nil && c.Manga.Source != nil {
+		sourceID = c.Source().ID()
+	}
+
+	hash := sha1.Sum([]byte(sourceID + c.URL))
+	return filepath.Join(where.Staging(), hex.EncodeToString(hash[:]))
+}
+
+// ClearStaged removes pages staged during the download.
+// Should be called once the chapter is saved.
+func (c *Chapter) ClearStaged() error {
+	return filesystem.Api().RemoveAll(c.stagingPath())
+}
+
 func (c *Chapter) Source() Source {
 	return c.Manga.Source
 }
@@ -217,6 +241,10 @@ func (c *Chapter) ComicInfo() *ComicInfo {
 			day = t.Day()
 			month = int(t.Month())
 			year = t.Year()
+		} else if c.Date.Year != 0 {
+			day = c.Date.Day
+			month = c.Date.Month
+			year = c.Date.Year
 		} else {
 			day = c.Manga.Metadata.StartDate.Day
 			month = c.Manga.Metadata.StartDate.Month
@@ -224,6 +252,12 @@ func (c *Chapter) ComicInfo() *ComicInfo {
 		}
 	} // empty dates will be omitted
 
+	// scanlators of the chapter are more precise than the translators of the manga
+	translators := c.Manga.Metadata.Staff.Translation
+	if len(c.Scanlators) > 0 {
+		translators = c.Scanlators
+	}
+
 	chapter_num := strconv.FormatUint(uint64(c.Index), 10)
 	if c.Number != "" {
 		chapter_num = c.Number
@@ -233,27 +267,29 @@ func (c *Chapter) ComicInfo() *ComicInfo {
 		XmlnsXsd: "http://www.w3.org/2001/XMLSchema",
 		XmlnsXsi: "http://www.w3.org/2001/XMLSchema-instance",
 
-		Title:       c.Name,
-		Series:      c.Manga.Name,
-		Number:      chapter_num,
-		Web:         c.URL,
-		Genre:       strings.Join(c.Manga.Metadata.Genres, ","),
-		PageCount:   len(c.Pages),
-		Summary:     c.Manga.Metadata.Summary,
-		Count:       c.Manga.Metadata.Chapters,
-		Characters:  strings.Join(c.Manga.Metadata.Characters, ","),
-		Year:        year,
-		Month:       month,
-		Day:         day,
-		Writer:      strings.Join(c.Manga.Metadata.Staff.Story, ","),
-		Penciller:   strings.Join(c.Manga.Metadata.Staff.Art, ","),
-		Letterer:    strings.Join(c.Manga.Metadata.Staff.Lettering, ","),
-		Translator:  strings.Join(c.Manga.Metadata.Staff.Translation, ","),
-		Tags:        strings.Join(c.Manga.Metadata.Tags, ","),
-		Notes:       "Downloaded with Mangal. https://github.com/metafates/mangal",
-		LanguageISO: c.Manga.Metadata.LanguageISO,
-		Manga:       "YesAndRightToLeft",
-		OrigTitle:   c.Name,
-		OrigIndex:   int(c.Index),
+		Title:           c.Name,
+		Series:          c.Manga.Name,
+		Number:          chapter_num,
+		Volume:          volumeNumber(c.Volume),
+		Web:             c.URL,
+		Genre:           strings.Join(c.Manga.Metadata.Genres, ","),
+		PageCount:       len(c.Pages),
+		Summary:         c.Manga.Metadata.Summary,
+		Count:           c.Manga.Metadata.Chapters,
+		Characters:      strings.Join(c.Manga.Metadata.Characters, ","),
+		Year:            year,
+		Month:           month,
+		Day:             day,
+		Writer:          strings.Join(c.Manga.Metadata.Staff.Story, ","),
+		Penciller:       strings.Join(c.Manga.Metadata.Staff.Art, ","),
+		Letterer:        strings.Join(c.Manga.Metadata.Staff.Lettering, ","),
+		Translator:      strings.Join(translators, ","),
+		Tags:            strings.Join(c.Manga.Metadata.Tags, ","),
+		ScanInformation: strings.Join(c.Scanlators, ","),
+		Notes:           "Downloaded with Mangal. https://github.com/metafates/mangal",
+		LanguageISO:     c.Manga.Metadata.LanguageISO,
+		Manga:           "YesAndRightToLeft",
+		OrigTitle:       c.Name,
+		OrigIndex:       int(c.Index),
 	}
 }
@@ -1,14 +1,22 @@
 package source
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dustin/go-humanize"
 	"github.com/metafates/mangal/constant"
 	"github.com/metafates/mangal/filesystem"
 	"github.com/metafates/mangal/key"
 	"github.com/metafates/mangal/util"
 	. "github.com/smartystreets/goconvey/convey"
 	"github.com/spf13/viper"
-	"testing"
 )
 
 func init() {
@@ -50,5 +58,153 @@ func TestChapter_ComicInfoXML(t *testing.T) {
 				So(xml, ShouldNotBeEmpty)
 			})
 		})
+
+		Convey("When the chapter has an upload date and scanlators", func() {
+			viper.Set(key.MetadataComicInfoXMLAddDate, true)
+			viper.Set(key.MetadataComicInfoXMLAlternativeDate, false)
+
+			chapter := testChapter
+			chapter.Scanlators = []string{"Group A", "Group B"}
+			chapter.Date.Year, chapter.Date.Month, chapter.Date.Day = 2020, 5, 17
+
+			comicInfo := chapter.ComicInfo()
+
+			Convey("Then they should be used in ComicInfo", func() {
+				So(comicInfo.Year, ShouldEqual, 2020)
+				So(comicInfo.Month, ShouldEqual, 5)
+				So(comicInfo.Day, ShouldEqual, 17)
+				So(comicInfo.Translator, ShouldEqual, "Group A,Group B")
+				So(comicInfo.ScanInformation, ShouldEqual, "Group A,Group B")
+			})
+		})
+	})
+}
+
+func testChapterWithPages(t *testing.T, url string, count int) *Chapter {
+	t.Helper()
+
+	chapter := &Chapter{
+		Name:  "pool chapter",
+		URL:   url + "/chapter",
+		Manga: &testManga,
+	}
+
+	for i := 0; i < count; i++ {
+		chapter.Pages = append(chapter.Pages, &Page{
+			URL:       fmt.Sprintf("%s/%d.jpg", url, i),
+			Index:     uint16(i),
+			Extension: ".jpg",
+			Chapter:   chapter,
+		})
+	}
+
+	t.Cleanup(func() { _ = chapter.ClearStaged() })
+	return chapter
+}
+
+func TestChapter_DownloadPages(t *testing.T) {
+	viper.Set(key.DownloaderAsync, true)
+	viper.Set(key.DownloaderPageWorkers, 16)
+	viper.Set(key.DownloaderPageWorkersPerHost, 4)
+	viper.Set(key.DownloaderPageRetries, 0)
+
+	Convey("Given a server with many pages", t, func() {
+		var active, maxActive int32
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			current := atomic.AddInt32(&active, 1)
+			defer atomic.AddInt32(&active, -1)
+
+			for {
+				max := atomic.LoadInt32(&maxActive)
+				if current <= max || atomic.CompareAndSwapInt32(&maxActive, max, current) {
+					break
+				}
+			}
+
+			time.Sleep(5 * time.Millisecond)
+			_, _ = w.Write([]byte("page"))
+		}))
+		defer server.Close()
+
+		chapter := testChapterWithPages(t, server.URL, 40)
+
+		Convey("When pages are downloaded", func() {
+			var calls int
+			err := chapter.DownloadPages(true, func(string) { calls++ })
+
+			Convey("Then all pages should be downloaded", func() {
+				So(err, ShouldBeNil)
+				So(chapter.SizeHuman(), ShouldEqual, humanize.Bytes(uint64(len("page")*40)))
+
+				for _, page := range chapter.Pages {
+					So(page.Contents.String(), ShouldEqual, "page")
+				}
+			})
+
+			Convey("Then per host limit should be respected", func() {
+				So(atomic.LoadInt32(&maxActive), ShouldBeLessThanOrEqualTo, 4)
+			})
+
+			Convey("Then progress should be reported for each page", func() {
+				So(calls, ShouldEqual, 41)
+			})
+		})
+	})
+
+	Convey("Given a server where one page is missing", t, func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/0.jpg" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			// other pages hang until the request is cancelled
+			select {
+			case <-r.Context().Done():
+			case <-time.After(10 * time.Second):
+				_, _ = w.Write([]byte("page"))
+			}
+		}))
+		defer server.Close()
+
+		chapter := testChapterWithPages(t, server.URL, 10)
+
+		Convey("When pages are downloaded", func() {
+			start := time.Now()
+			err := chapter.DownloadPages(true, func(string) {})
+
+			Convey("Then the error should be returned and in-flight requests cancelled", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "404")
+				So(time.Since(start), ShouldBeLessThan, 5*time.Second)
+			})
+		})
+	})
+
+	Convey("Given a server where pages hang", t, func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(10 * time.Second):
+				_, _ = w.Write([]byte("page"))
+			}
+		}))
+		defer server.Close()
+
+		chapter := testChapterWithPages(t, server.URL, 10)
+
+		Convey("When the download is cancelled", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(50*time.Millisecond, cancel)
+
+			start := time.Now()
+			err := chapter.DownloadPagesContext(ctx, true, func(string) {})
+
+			Convey("Then in-flight requests should be aborted", func() {
+				So(errors.Is(err, context.Canceled), ShouldBeTrue)
+				So(time.Since(start), ShouldBeLessThan, 5*time.Second)
+			})
+		})
 	})
 }
@@ -8,26 +8,38 @@ type ComicInfo struct {
 	XmlnsXsd string   `xml:"xmlns:xsd,attr"`
 
 	// General
-	Title       string `xml:"Title,omitempty"`
-	Series      string `xml:"Series,omitempty"`
-	Number      string `xml:"Number,omitempty"`
-	Web         string `xml:"Web,omitempty"`
-	Genre       string `xml:"Genre,omitempty"`
-	PageCount   int    `xml:"PageCount,omitempty"`
-	Summary     string `xml:"Summary,omitempty"`
-	Count       int    `xml:"Count,omitempty"`
-	Characters  string `xml:"Characters,omitempty"`
-	Year        int    `xml:"Year,omitempty"`
-	Month       int    `xml:"Month,omitempty"`
-	Day         int    `xml:"Day,omitempty"`
-	Writer      string `xml:"Writer,omitempty"`
-	Penciller   string `xml:"Penciller,omitempty"`
-	Letterer    string `xml:"Letterer,omitempty"`
-	Translator  string `xml:"Translator,omitempty"`
-	Tags        string `xml:"Tags,omitempty"`
-	Notes       string `xml:"Notes,omitempty"`
-	LanguageISO string `xml:"LanguageISO,omitempty"`
-	Manga       string `xml:"Manga,omitempty"`
-	OrigTitle   string `xml:"OrigTitle,omitempty"`
-	OrigIndex   int    `xml:"OrigIndex,omitempty"`
+	Title           string `xml:"Title,omitempty"`
+	Series          string `xml:"Series,omitempty"`
+	Number          string `xml:"Number,omitempty"`
+	Volume          int    `xml:"Volume,omitempty"`
+	Web             string `xml:"Web,omitempty"`
+	Genre           string `xml:"Genre,omitempty"`
+	PageCount       int    `xml:"PageCount,omitempty"`
+	Summary         string `xml:"Summary,omitempty"`
+	Count           int    `xml:"Count,omitempty"`
+	Characters      string `xml:"Characters,omitempty"`
+	Year            int    `xml:"Year,omitempty"`
+	Month           int    `xml:"Month,omitempty"`
+	Day             int    `xml:"Day,omitempty"`
+	Writer          string `xml:"Writer,omitempty"`
+	Penciller       string `xml:"Penciller,omitempty"`
+	Letterer        string `xml:"Letterer,omitempty"`
+	Translator      string `xml:"Translator,omitempty"`
+	Tags            string `xml:"Tags,omitempty"`
+	ScanInformation string `xml:"ScanInformation,omitempty"`
+	Notes           string `xml:"Notes,omitempty"`
+	LanguageISO     string `xml:"LanguageISO,omitempty"`
+	Manga           string `xml:"Manga,omitempty"`
+	OrigTitle       string `xml:"OrigTitle,omitempty"`
+	OrigIndex       int    `xml:"OrigIndex,omitempty"`
+
+	Pages []ComicInfoPage `xml:"Pages>Page,omitempty"`
+}
+
+// ComicInfoPage is an entry of the pages table
+type ComicInfoPage struct {
+	Image     int    `xml:"Image,attr"`
+	Type      string `xml:"Type,attr,omitempty"`
+	ImageSize int64  `xml:"ImageSize,attr,omitempty"`
+	Bookmark  string `xml:"Bookmark,attr,omitempty"`
 }
@@ -0,0 +1,72 @@
+package source
+
+import "context"
+
+// ContextSource is a Source that supports cancellation and deadlines.
+type ContextSource interface {
+	Source
+	SearchContext(ctx context.Context, query string) ([]*Manga, error)
+	ChaptersOfContext(ctx context.Context, manga *Manga) ([]*Chapter, error)
+	PagesOfContext(ctx context.Context, chapter *Chapter) ([]*Page, error)
+}
+
+// WithContext returns a context-aware variant of the source.
+// Sources implementing ContextSource are returned as is.
+// Calls to other sources are not interrupted, but the result
+// is discarded and the context error is returned as soon as the context is done.
+func WithContext(src Source) ContextSource {
+	if ctxSrc, ok := src.(ContextSource); ok {
+		return ctxSrc
+	}
+
+	return &contextAdapter{src}
+}
+
+type contextAdapter struct {
+	Source
+}
+
+func (a *contextAdapter) SearchContext(ctx context.Context, query string) ([]*Manga, error) {
+	return await(ctx, func() ([]*Manga, error) {
+		return a.Search(query)
+	})
+}
+
+func (a *contextAdapter) ChaptersOfContext(ctx context.Context, manga *Manga) ([]*Chapter, error) {
+	return await(ctx, func() ([]*Chapter, error) {
+		return a.ChaptersOf(manga)
+	})
+}
+
+func (a *contextAdapter) PagesOfContext(ctx context.Context, chapter *Chapter) ([]*Page, error) {
+	return await(ctx, func() ([]*Page, error) {
+		return a.PagesOf(chapter)
+	})
+}
+
+// await runs the function in the background and waits for it to finish or for the context to be done
+func await[T any](ctx context.Context, f func() (T, error)) (T, error) {
+	var zero T
+
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+
+	type result struct {
+		value T
+		err   error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		value, err := f()
+		done <- result{value, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	case res := <-done:
+		return res.value, res.err
+	}
+}
@@ -0,0 +1,65 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// slowSource is a source that blocks on search until released
+type slowSource struct {
+	testSource
+	release chan struct{}
+}
+
+func (s slowSource) Search(string) ([]*Manga, error) {
+	<-s.release
+	return []*Manga{&testManga}, nil
+}
+
+func TestWithContext(t *testing.T) {
+	Convey("Given a source without context support", t, func() {
+		src := slowSource{release: make(chan struct{})}
+		defer close(src.release)
+
+		ctxSrc := WithContext(src)
+
+		Convey("When search is cancelled by a deadline", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			mangas, err := ctxSrc.SearchContext(ctx, "test")
+
+			Convey("Then it should return the context error", func() {
+				So(errors.Is(err, context.DeadlineExceeded), ShouldBeTrue)
+				So(mangas, ShouldBeNil)
+			})
+		})
+
+		Convey("When search finishes before the deadline", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			go func() { src.release <- struct{}{} }()
+			mangas, err := ctxSrc.SearchContext(ctx, "test")
+
+			Convey("Then it should return the result", func() {
+				So(err, ShouldBeNil)
+				So(mangas, ShouldHaveLength, 1)
+			})
+		})
+	})
+
+	Convey("Given a source with context support", t, func() {
+		src := WithContext(testSource{})
+
+		Convey("When it is wrapped again", func() {
+			Convey("Then the same source should be returned", func() {
+				So(WithContext(src), ShouldEqual, src)
+			})
+		})
+	})
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/metafates/mangal/filesystem"
 	"github.com/metafates/mangal/key"
 	"github.com/metafates/mangal/log"
+	"github.com/metafates/mangal/network"
 	"github.com/metafates/mangal/util"
 	"github.com/metafates/mangal/where"
 	"github.com/samber/lo"
@@ -41,7 +42,9 @@ type Manga struct {
 	// Source that the manga belongs to.
 	Source Source `json:"-"`
 	// Anilist is the closest anilist match
-	Anilist  mo.Option[*anilist.Manga] `json:"-"`
+	Anilist mo.Option[*anilist.Manga] `json:"-"`
+	// Format the chapters are saved in, formats.use is used if empty
+	Format   string `json:"-"`
 	Metadata struct {
 		// Genres of the manga
 		Genres []string `json:"genres" jsonschema:"description=Genres of the manga"`
@@ -95,6 +98,15 @@ type Manga struct {
 	coverDownloaded bool
 }
 
+// SaveFormat is the format the chapters of the manga are saved in
+func (m *Manga) SaveFormat() string {
+	if m != nil && m.Format != "" {
+		return m.Format
+	}
+
+	return viper.GetString(key.FormatsUse)
+}
+
 func (m *Manga) String() string {
 	return m.Name
 }
@@ -145,6 +157,19 @@ func (m *Manga) Path(temp bool) (path string, err error) {
 	return
 }
 
+// RightToLeft reports whether the manga is read from right to left.
+// Manhwa and manhua are read from left to right, everything else is assumed to be a manga.
+func (m *Manga) RightToLeft() bool {
+	if manga, ok := m.Anilist.Get(); ok && manga != nil {
+		switch manga.Country {
+		case "KR", "CN", "TW":
+			return false
+		}
+	}
+
+	return true
+}
+
 func (m *Manga) GetCover() (string, error) {
 	var covers = []string{
 		m.Metadata.Cover.ExtraLarge,
@@ -196,7 +221,7 @@ func (m *Manga) DownloadCover(overwrite bool, path string, progress func(string)
 		}
 	}
 
-	resp, err := http.Get(cover)
+	resp, err := network.Client.Get(cover)
 	if err != nil {
 		log.Error(err)
 		return err
@@ -242,6 +267,7 @@ func (m *Manga) BindWithAnilist() error {
 	return nil
 }
 
+// PopulateMetadata binds the manga with anilist and fills the metadata the source didn't set
 func (m *Manga) PopulateMetadata(progress func(string)) error {
 	if m.populated {
 		return nil
@@ -260,9 +286,28 @@ func (m *Manga) PopulateMetadata(progress func(string)) error {
 		return fmt.Errorf("manga '%s' not found on Anilist", m.Name)
 	}
 
-	m.Metadata.Genres = manga.Genres
+	m.mergeAnilist(manga)
+	return nil
+}
+
+// mergeAnilist fills the metadata from anilist.
+// Fields that were set by the source are kept, only empty ones are filled
+func (m *Manga) mergeAnilist(manga *anilist.Manga) {
+	fill := func(field *[]string, value []string) {
+		if len(*field) == 0 {
+			*field = value
+		}
+	}
+
+	fillString := func(field *string, value string) {
+		if *field == "" {
+			*field = value
+		}
+	}
+
+	fill(&m.Metadata.Genres, manga.Genres)
 	// replace <br> with newlines and remove other html tags
-	m.Metadata.Summary = regexp.
+	fillString(&m.Metadata.Summary, regexp.
 		MustCompile("<.*?>").
 		ReplaceAllString(
 			strings.
@@ -272,13 +317,13 @@ func (m *Manga) PopulateMetadata(progress func(string)) error {
 					"\n",
 				),
 			"",
-		)
+		))
 
 	var characters = make([]string, len(manga.Characters.Nodes))
 	for i, character := range manga.Characters.Nodes {
 		characters[i] = character.Name.Full
 	}
-	m.Metadata.Characters = characters
+	fill(&m.Metadata.Characters, characters)
 
 	var tags = make([]string, 0)
 	for _, tag := range manga.Tags {
@@ -286,43 +331,51 @@ func (m *Manga) PopulateMetadata(progress func(string)) error {
 			tags = append(tags, tag.Name)
 		}
 	}
-	m.Metadata.Tags = tags
+	fill(&m.Metadata.Tags, tags)
 
-	m.Metadata.Cover.ExtraLarge = manga.CoverImage.ExtraLarge
-	m.Metadata.Cover.Large = manga.CoverImage.Large
-	m.Metadata.Cover.Medium = manga.CoverImage.Medium
-	m.Metadata.Cover.Color = manga.CoverImage.Color
+	fillString(&m.Metadata.Cover.ExtraLarge, manga.CoverImage.ExtraLarge)
+	fillString(&m.Metadata.Cover.Large, manga.CoverImage.Large)
+	fillString(&m.Metadata.Cover.Medium, manga.CoverImage.Medium)
+	fillString(&m.Metadata.Cover.Color, manga.CoverImage.Color)
 
-	m.Metadata.BannerImage = manga.BannerImage
+	fillString(&m.Metadata.BannerImage, manga.BannerImage)
 
-	m.Metadata.StartDate = date(manga.StartDate)
-	m.Metadata.EndDate = date(manga.EndDate)
+	if m.Metadata.StartDate.Year == 0 {
+		m.Metadata.StartDate = date(manga.StartDate)
+	}
 
-	m.Metadata.Status = strings.ReplaceAll(manga.Status, "_", " ")
-	m.Metadata.Synonyms = manga.Synonyms
+	if m.Metadata.EndDate.Year == 0 {
+		m.Metadata.EndDate = date(manga.EndDate)
+	}
 
-	m.Metadata.Staff.Story = make([]string, 0)
-	m.Metadata.Staff.Art = make([]string, 0)
-	m.Metadata.Staff.Translation = make([]string, 0)
-	m.Metadata.Staff.Lettering = make([]string, 0)
+	fillString(&m.Metadata.Status, strings.ReplaceAll(manga.Status, "_", " "))
+	fill(&m.Metadata.Synonyms, manga.Synonyms)
 
-	m.Metadata.Chapters = manga.Chapters
+	if m.Metadata.Chapters == 0 {
+		m.Metadata.Chapters = manga.Chapters
+	}
 
+	var story, art, translation, lettering = make([]string, 0), make([]string, 0), make([]string, 0), make([]string, 0)
 	for _, staff := range manga.Staff.Edges {
 		role := strings.ToLower(staff.Role)
 		switch {
 		case strings.Contains(role, "story"):
-			m.Metadata.Staff.Story = append(m.Metadata.Staff.Story, staff.Node.Name.Full)
+			story = append(story, staff.Node.Name.Full)
 		case strings.Contains(role, "art"):
-			m.Metadata.Staff.Art = append(m.Metadata.Staff.Art, staff.Node.Name.Full)
+			art = append(art, staff.Node.Name.Full)
 		case strings.Contains(role, "translator"):
-			m.Metadata.Staff.Translation = append(m.Metadata.Staff.Translation, staff.Node.Name.Full)
+			translation = append(translation, staff.Node.Name.Full)
 		case strings.Contains(role, "lettering"):
-			m.Metadata.Staff.Lettering = append(m.Metadata.Staff.Lettering, staff.Node.Name.Full)
+			lettering = append(lettering, staff.Node.Name.Full)
 		}
 	}
 
-	// Anilist & Myanimelist + external
+	fill(&m.Metadata.Staff.Story, story)
+	fill(&m.Metadata.Staff.Art, art)
+	fill(&m.Metadata.Staff.Translation, translation)
+	fill(&m.Metadata.Staff.Lettering, lettering)
+
+	// Anilist & Myanimelist + external, urls of the source go first
 	urls := make([]string, 2+len(manga.External))
 	urls[0] = manga.SiteURL
 	for i, e := range manga.External {
@@ -334,22 +387,30 @@ func (m *Manga) PopulateMetadata(progress func(string)) error {
 	})
 
 	urls = append(urls, fmt.Sprintf("https://myanimelist.net/manga/%d", manga.IDMal))
-	m.Metadata.URLs = urls
-
-	return nil
+	m.Metadata.URLs = lo.Uniq(append(m.Metadata.URLs, urls...))
 }
 
 func (m *Manga) SeriesJSON() *SeriesJSON {
 	var status string
-	switch m.Metadata.Status {
-	case "FINISHED":
+	switch strings.ReplaceAll(m.Metadata.Status, " ", "_") {
+	case "FINISHED", "CANCELLED":
 		status = "Ended"
-	case "RELEASING":
+	case "RELEASING", "HIATUS":
 		status = "Continuing"
 	default:
 		status = "Unknown"
 	}
 
+	// fallback to the earliest chapter if the start date is unknown
+	year := m.Metadata.StartDate.Year
+	if year == 0 {
+		for _, chapter := range m.Chapters {
+			if chapter.Date.Year != 0 && (year == 0 || chapter.Date.Year < year) {
+				year = chapter.Date.Year
+			}
+		}
+	}
+
 	var publisher string
 	if len(m.Metadata.Staff.Story) > 0 {
 		publisher = m.Metadata.Staff.Story[0]
@@ -361,7 +422,7 @@ func (m *Manga) SeriesJSON() *SeriesJSON {
 	seriesJSON.Metadata.DescriptionFormatted = m.Metadata.Summary
 	seriesJSON.Metadata.DescriptionText = m.Metadata.Summary
 	seriesJSON.Metadata.Status = status
-	seriesJSON.Metadata.Year = m.Metadata.StartDate.Year
+	seriesJSON.Metadata.Year = year
 	seriesJSON.Metadata.ComicImage = m.Metadata.Cover.ExtraLarge
 	seriesJSON.Metadata.Publisher = publisher
 	seriesJSON.Metadata.BookType = "Print"
@@ -2,16 +2,22 @@ package source
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	_ "image/gif"
 	"io"
 	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
 
 	"github.com/metafates/mangal/constant"
+	"github.com/metafates/mangal/filesystem"
 	"github.com/metafates/mangal/log"
 	"github.com/metafates/mangal/network"
 	"github.com/metafates/mangal/util"
+	"github.com/samber/lo"
 )
 
 // Page represents a page in a chapter
@@ -24,6 +30,10 @@ type Page struct {
 	Extension string `json:"extension" jsonschema:"description=Extension of the page image."`
 	// Mangaplus decryption key
 	MangaPlusKey string `json:"mangapluskey" jsonschema:"description=MangaPlus decryption key for the page image."`
+	// Headers to send with the image request. They override the default Referer and User-Agent.
+	Headers map[string]string `json:"headers,omitempty" jsonschema:"description=Headers to send with the image request."`
+	// Cookies to send with the image request.
+	Cookies map[string]string `json:"cookies,omitempty" jsonschema:"description=Cookies to send with the image request."`
 	// Size of the page in bytes
 	Size uint64 `json:"-"`
 	// Contents of the page
@@ -32,8 +42,8 @@ type Page struct {
 	Chapter *Chapter `json:"-"`
 }
 
-func (p *Page) request() (*http.Request, error) {
-	req, err := http.NewRequest(http.MethodGet, p.URL, nil)
+func (p *Page) request(ctx context.Context) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.URL, nil)
 	if err != nil {
 		log.Error(err)
 		return nil, err
@@ -41,41 +51,85 @@ func (p *Page) request() (*http.Request, error) {
 
 	req.Header.Set("Referer", p.Chapter.URL)
 	req.Header.Set("User-Agent", constant.UserAgent)
+
+	for name, value := range p.Headers {
+		req.Header.Set(name, value)
+	}
+
+	// sorted, so that the cookie header is always the same
+	names := lo.Keys(p.Cookies)
+	sort.Strings(names)
+
+	for _, name := range names {
+		req.AddCookie(&http.Cookie{Name: name, Value: p.Cookies[name]})
+	}
+
 	return req, nil
 }
 
 // Download Page contents.
+// Transient errors are retried with exponential backoff.
+// Downloaded page is staged on the disk, so that it won't be
+// downloaded again if the chapter download is interrupted.
 func (p *Page) Download() error {
+	return p.DownloadContext(context.Background())
+}
+
+// DownloadContext is the same as Download but the download
+// is aborted as soon as the context is done.
+func (p *Page) DownloadContext(ctx context.Context) error {
 	if p.URL == "" {
 		log.Warnf("Page #%d has no URL", p.Index)
 		return nil
 	}
 
+	if p.loadStaged() {
+		log.Tracef("Page #%d loaded from staging", p.Index)
+		return nil
+	}
+
 	log.Tracef("Downloading page #%d (%s)", p.Index, p.URL)
 
-	req, err := p.request()
+	if p.Chapter != nil && p.Chapter.Manga != nil && p.Chapter.Manga.Source != nil {
+		ctx = network.WithSource(ctx, p.Source().Name())
+	}
+
+	err := withRetries(ctx, fmt.Sprintf("Page #%d download", p.Index), func() error {
+		return p.download(ctx)
+	})
 	if err != nil {
+		log.Error(err)
 		return err
 	}
 
-	resp, err := network.Client.Do(req)
+	if err = p.stage(); err != nil {
+		log.Warn(err)
+	}
+
+	log.Tracef("Page #%d downloaded", p.Index)
+	return nil
+}
+
+// download makes a single attempt to download the page
+func (p *Page) download(ctx context.Context) error {
+	req, err := p.request(ctx)
 	if err != nil {
-		log.Error(err)
 		return err
 	}
 
+	resp, err := network.Client.Do(req)
+	if err != nil {
+		return &requestError{err: err}
+	}
+
 	defer util.Ignore(resp.Body.Close)
 
 	if resp.StatusCode != http.StatusOK {
-		err = errors.New("http error: " + resp.Status)
-		log.Error(err)
-		return err
+		return &httpError{StatusCode: resp.StatusCode, Status: resp.Status}
 	}
 
 	if resp.ContentLength == 0 {
-		err = errors.New("http error: nothing was returned")
-		log.Error(err)
-		return err
+		return errEmptyResponse
 	}
 
 	var (
@@ -104,14 +158,39 @@ func (p *Page) Download() error {
 	p.Contents = bytes.NewBuffer(buf)
 	p.Size = uint64(util.Max(contentLength, 0))
 
+	return nil
+}
+
+// stagedPath is the path where the page is staged until the chapter is saved
+func (p *Page) stagedPath() string {
+	return filepath.Join(p.Chapter.stagingPath(), p.Filename())
+}
+
+// stage writes the page contents to the staging directory
+func (p *Page) stage() error {
 	if p.Contents == nil {
-		err = errors.New("no data downloaded")
-		log.Error(err)
+		return nil
+	}
+
+	path := p.stagedPath()
+	if err := filesystem.Api().MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
 		return err
 	}
 
-	log.Tracef("Page #%d downloaded", p.Index)
-	return nil
+	return filesystem.Api().WriteFile(path, p.Contents.Bytes(), os.ModePerm)
+}
+
+// loadStaged loads the page contents from the staging directory.
+// Returns false if the page wasn't staged before.
+func (p *Page) loadStaged() bool {
+	contents, err := filesystem.Api().ReadFile(p.stagedPath())
+	if err != nil || len(contents) == 0 {
+		return false
+	}
+
+	p.Contents = bytes.NewBuffer(contents)
+	p.Size = uint64(len(contents))
+	return true
 }
 
 // Close closes the page contents.
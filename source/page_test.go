@@ -1 +1,146 @@
 package source
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/metafates/mangal/key"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/spf13/viper"
+)
+
+// flakyServer responds with the given status for the first failures requests
+func flakyServer(failures int32, status int) (*httptest.Server, *int32) {
+	var requests int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) <= failures {
+			w.WriteHeader(status)
+			return
+		}
+
+		_, _ = w.Write([]byte("image"))
+	}))
+
+	return server, &requests
+}
+
+func testPage(url string) *Page {
+	chapter := &Chapter{
+		Name:  "retry chapter",
+		URL:   url + "/chapter",
+		Manga: &testManga,
+	}
+
+	return &Page{
+		URL:       url + "/page.jpg",
+		Index:     1,
+		Extension: ".jpg",
+		Chapter:   chapter,
+	}
+}
+
+func TestPage_Download(t *testing.T) {
+	viper.Set(key.DownloaderPageRetries, 3)
+	viper.Set(key.DownloaderPageRetryDelay, 1)
+
+	Convey("Given a server that fails with transient errors", t, func() {
+		server, requests := flakyServer(2, http.StatusServiceUnavailable)
+		defer server.Close()
+
+		page := testPage(server.URL)
+		defer func() { _ = page.Chapter.ClearStaged() }()
+
+		Convey("When page is downloaded", func() {
+			err := page.Download()
+
+			Convey("Then it should be retried until it succeeds", func() {
+				So(err, ShouldBeNil)
+				So(atomic.LoadInt32(requests), ShouldEqual, 3)
+				So(page.Contents.String(), ShouldEqual, "image")
+			})
+
+			Convey("And downloaded again", func() {
+				page.Contents = nil
+				err := page.Download()
+
+				Convey("Then it should be loaded from the staging", func() {
+					So(err, ShouldBeNil)
+					So(atomic.LoadInt32(requests), ShouldEqual, 3)
+					So(page.Contents.String(), ShouldEqual, "image")
+				})
+			})
+		})
+	})
+
+	Convey("Given a server that is rate limiting", t, func() {
+		server, requests := flakyServer(10, http.StatusTooManyRequests)
+		defer server.Close()
+
+		page := testPage(server.URL)
+
+		Convey("When page is downloaded", func() {
+			err := page.Download()
+
+			Convey("Then it should give up after configured retries", func() {
+				So(err, ShouldNotBeNil)
+				So(atomic.LoadInt32(requests), ShouldEqual, 4)
+			})
+		})
+	})
+
+	Convey("Given a server that responds with not found", t, func() {
+		server, requests := flakyServer(10, http.StatusNotFound)
+		defer server.Close()
+
+		page := testPage(server.URL)
+
+		Convey("When page is downloaded", func() {
+			err := page.Download()
+
+			Convey("Then it should not be retried", func() {
+				So(err, ShouldNotBeNil)
+				So(atomic.LoadInt32(requests), ShouldEqual, 1)
+			})
+		})
+	})
+}
+
+func TestPage_Headers(t *testing.T) {
+	Convey("Given a page with headers and cookies", t, func() {
+		var received *http.Request
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = r
+			_, _ = w.Write([]byte("image"))
+		}))
+		defer server.Close()
+
+		page := testPage(server.URL)
+		page.Headers = map[string]string{
+			"Referer":  "https://example.com/",
+			"X-Custom": "value",
+		}
+		page.Cookies = map[string]string{
+			"session": "secret",
+			"age":     "18",
+		}
+		defer func() { _ = page.Chapter.ClearStaged() }()
+
+		Convey("When page is downloaded", func() {
+			So(page.Download(), ShouldBeNil)
+
+			Convey("Then they should be sent with the request", func() {
+				So(received.Header.Get("Referer"), ShouldEqual, "https://example.com/")
+				So(received.Header.Get("X-Custom"), ShouldEqual, "value")
+				So(received.Header.Get("Cookie"), ShouldEqual, "age=18; session=secret")
+			})
+
+			Convey("Then the default user agent should be kept", func() {
+				So(received.Header.Get("User-Agent"), ShouldNotBeEmpty)
+			})
+		})
+	})
+}
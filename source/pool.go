@@ -0,0 +1,161 @@
+package source
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/metafates/mangal/key"
+	"github.com/metafates/mangal/log"
+	"github.com/spf13/viper"
+)
+
+// hostLimiter limits the number of concurrent requests to a single host
+type hostLimiter struct {
+	limit int
+	mutex sync.Mutex
+	hosts map[string]chan struct{}
+}
+
+func newHostLimiter(limit int) *hostLimiter {
+	return &hostLimiter{
+		limit: limit,
+		hosts: make(map[string]chan struct{}),
+	}
+}
+
+// acquire blocks until there is a free slot for the host of the given address.
+// Returned function must be called to release the slot.
+func (h *hostLimiter) acquire(ctx context.Context, address string) (release func(), err error) {
+	if h.limit <= 0 {
+		return func() {}, nil
+	}
+
+	var host string
+	if parsed, err := url.Parse(address); err == nil {
+		host = parsed.Host
+	}
+
+	h.mutex.Lock()
+	slots, ok := h.hosts[host]
+	if !ok {
+		slots = make(chan struct{}, h.limit)
+		h.hosts[host] = slots
+	}
+	h.mutex.Unlock()
+
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// pageWorkers returns the number of workers to download pages of the given source with.
+// Per source overrides take precedence over the global value.
+func pageWorkers(src Source) int {
+	if !viper.GetBool(key.DownloaderAsync) {
+		return 1
+	}
+
+	workers := viper.GetInt(key.DownloaderPageWorkers)
+
+	if src != nil {
+		for _, override := range viper.GetStringSlice(key.DownloaderSourcePageWorkers) {
+			name, value, found := strings.Cut(override, "=")
+			if !found || strings.TrimSpace(name) != src.Name() {
+				continue
+			}
+
+			n, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				log.Warnf("invalid page workers override %q: %s", override, err)
+				continue
+			}
+
+			workers = n
+		}
+	}
+
+	if workers < 1 {
+		return 1
+	}
+
+	return workers
+}
+
+// downloadPages downloads pages using a bounded pool of workers.
+// The first error cancels all in-flight downloads and is returned.
+// onDone is called after each successfully downloaded page, one call at a time.
+// Downloads are cancelled when the parent context is done too.
+func downloadPages(parent context.Context, pages []*Page, workers, perHost int, onDone func(page *Page)) error {
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	var (
+		limiter = newHostLimiter(perHost)
+		jobs    = make(chan *Page)
+		wg      sync.WaitGroup
+		mutex   sync.Mutex
+		err     error
+	)
+
+	fail := func(e error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if err == nil {
+			err = e
+			cancel()
+		}
+	}
+
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+
+			for page := range jobs {
+				release, e := limiter.acquire(ctx, page.URL)
+				if e != nil {
+					fail(e)
+					continue
+				}
+
+				e = page.DownloadContext(ctx)
+				release()
+
+				if e != nil {
+					fail(e)
+					continue
+				}
+
+				mutex.Lock()
+				onDone(page)
+				mutex.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, page := range pages {
+		select {
+		case jobs <- page:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	// pages that were not fed yet are not downloaded if the parent is done
+	if err == nil {
+		err = parent.Err()
+	}
+
+	return err
+}
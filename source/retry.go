@@ -0,0 +1,94 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/metafates/mangal/key"
+	"github.com/metafates/mangal/log"
+	"github.com/spf13/viper"
+)
+
+// errEmptyResponse is returned when the server responded with no content
+var errEmptyResponse = errors.New("http error: nothing was returned")
+
+// httpError is returned when the server responded with a non-200 status code
+type httpError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *httpError) Error() string {
+	return "http error: " + e.Status
+}
+
+// isTransient reports whether the error is likely to go away on retry.
+// Network errors, short reads, empty responses, 429 and 5xx responses are considered transient.
+func isTransient(err error) bool {
+	var httpErr *httpError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == http.StatusTooManyRequests || httpErr.StatusCode >= 500
+	}
+
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, errEmptyResponse) {
+		return true
+	}
+
+	var requestErr *requestError
+	return errors.As(err, &requestErr)
+}
+
+// requestError wraps errors returned by the http client itself (dns, timeouts, connection resets)
+type requestError struct {
+	err error
+}
+
+func (e *requestError) Error() string {
+	return e.err.Error()
+}
+
+func (e *requestError) Unwrap() error {
+	return e.err
+}
+
+// backoff returns how long to wait before the given retry attempt (starting from 0).
+// Delay doubles with each attempt and a random jitter up to the base delay is added.
+func backoff(attempt int) time.Duration {
+	base := time.Duration(viper.GetInt(key.DownloaderPageRetryDelay)) * time.Millisecond
+	if base <= 0 {
+		return 0
+	}
+
+	delay := base << attempt
+	jitter := time.Duration(rand.Int63n(int64(base)))
+
+	return delay + jitter
+}
+
+// withRetries calls the function until it succeeds, returns a non-transient error,
+// the number of retries from the config is exceeded or the context is done.
+func withRetries(ctx context.Context, name string, f func() error) (err error) {
+	retries := viper.GetInt(key.DownloaderPageRetries)
+
+	for attempt := 0; ; attempt++ {
+		err = f()
+		if err == nil || ctx.Err() != nil || attempt >= retries || !isTransient(err) {
+			return
+		}
+
+		delay := backoff(attempt)
+		log.Warnf("%s failed: %s. Retrying in %s (%d/%d)", name, err, delay, attempt+1, retries)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,283 @@
+package source
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/metafates/mangal/constant"
+	"github.com/metafates/mangal/filesystem"
+	"github.com/metafates/mangal/key"
+	"github.com/metafates/mangal/log"
+	"github.com/metafates/mangal/util"
+	"github.com/metafates/mangal/where"
+	"github.com/samber/lo"
+	"github.com/spf13/viper"
+)
+
+// Volume is a group of chapters that are saved as a single file
+type Volume struct {
+	// Name of the volume, e.g. "Vol. 1" or "Chapters 1-10" for chapters without a volume
+	Name string
+	// Manga that the volume belongs to
+	Manga *Manga
+	// Chapters of the volume sorted by index
+	Chapters []*Chapter
+}
+
+var volumeNumberRegex = regexp.MustCompile(`\d+`)
+
+// volumeNumber extracts the number from the volume name, e.g. 3 from "Vol. 3".
+// Zero is returned if there is no number
+func volumeNumber(name string) int {
+	number, _ := strconv.Atoi(volumeNumberRegex.FindString(name))
+	return number
+}
+
+// Volumes groups chapters by their manga and volume.
+// Chapters without a volume are grouped by chunkSize chapters based on their index,
+// so that the same chapter always ends up in the same group.
+// Volumes are sorted by the index of their first chapter.
+func Volumes(chapters []*Chapter, chunkSize int) []*Volume {
+	if chunkSize < 1 {
+		chunkSize = 1
+	}
+
+	sorted := make([]*Chapter, len(chapters))
+	copy(sorted, chapters)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Index < sorted[j].Index
+	})
+
+	type volumeKey struct {
+		manga *Manga
+		name  string
+	}
+
+	var (
+		volumes []*Volume
+		byKey   = make(map[volumeKey]*Volume)
+	)
+
+	for _, chapter := range sorted {
+		name := chapter.Volume
+		if name == "" {
+			start := util.Max(int(chapter.Index)-1, 0)/chunkSize*chunkSize + 1
+			name = fmt.Sprintf("Chapters %d-%d", start, start+chunkSize-1)
+		}
+
+		key := volumeKey{chapter.Manga, name}
+		volume, ok := byKey[key]
+		if !ok {
+			volume = &Volume{Name: name, Manga: chapter.Manga}
+			byKey[key] = volume
+			volumes = append(volumes, volume)
+		}
+
+		volume.Chapters = append(volume.Chapters, chapter)
+	}
+
+	return volumes
+}
+
+func (v *Volume) String() string {
+	return v.Name
+}
+
+// Source returns the source the volume belongs to
+func (v *Volume) Source() Source {
+	return v.Manga.Source
+}
+
+// Number of the volume, zero if unknown.
+// Chunks of chapters without a volume have no number, even though their name has one
+func (v *Volume) Number() int {
+	if len(v.Chapters) == 0 {
+		return 0
+	}
+
+	return volumeNumber(v.Chapters[0].Volume)
+}
+
+// Filename of the volume with the extension of the current format
+func (v *Volume) Filename() (filename string) {
+	filename = util.SanitizeFilenameWows(v.Name)
+
+	if viper.GetBool(key.DownloaderVolSafeFilename) {
+		filename = util.VolSafeFileName(filename)
+	}
+
+	if f := v.Manga.SaveFormat(); f != constant.FormatPlain {
+		return filename + "." + f
+	}
+
+	return
+}
+
+// Path of the volume file inside the manga directory
+func (v *Volume) Path(temp bool) (path string, err error) {
+	path, err = v.Manga.Path(temp)
+	if err != nil {
+		return
+	}
+
+	return filepath.Join(path, v.Filename()), nil
+}
+
+// IsDownloaded reports whether the volume file exists and contains all chapters of the volume.
+// Volumes that were saved before their chapters were recorded are trusted to be complete
+func (v *Volume) IsDownloaded() bool {
+	exists, _ := filesystem.Api().Exists(v.savedPath())
+	if !exists {
+		return false
+	}
+
+	saved, ok := v.SavedChapters()
+	if !ok {
+		return true
+	}
+
+	return lo.Every(saved, lo.Map(v.Chapters, func(c *Chapter, _ int) string {
+		return c.URL
+	}))
+}
+
+// savedVolumes maps paths of the saved volumes to urls of their chapters.
+// Chapters can join the volume after it was saved, e.g. when a chunk is downloaded partially,
+// so the file alone does not tell whether the volume is complete
+type savedVolumes map[string][]string
+
+// savedVolumesMutex guards the file of the saved volumes
+var savedVolumesMutex sync.Mutex
+
+func readSavedVolumes() (savedVolumes, error) {
+	saved := make(savedVolumes)
+
+	exists, err := filesystem.Api().Exists(where.Volumes())
+	if err != nil || !exists {
+		return saved, err
+	}
+
+	contents, err := filesystem.Api().ReadFile(where.Volumes())
+	if err != nil {
+		return nil, err
+	}
+
+	return saved, json.Unmarshal(contents, &saved)
+}
+
+// savedPath is the path of the volume file without creating the directories
+func (v *Volume) savedPath() string {
+	return filepath.Join(v.Manga.peekPath(), v.Filename())
+}
+
+// SavedChapters returns urls of the chapters the volume file contains.
+// False is returned if the volume was not recorded when it was saved
+func (v *Volume) SavedChapters() ([]string, bool) {
+	savedVolumesMutex.Lock()
+	defer savedVolumesMutex.Unlock()
+
+	saved, err := readSavedVolumes()
+	if err != nil {
+		log.Warn(err)
+		return nil, false
+	}
+
+	chapters, ok := saved[v.savedPath()]
+	return chapters, ok
+}
+
+// MarkSaved records the chapters of the volume.
+// Should be called once the volume file is saved
+func (v *Volume) MarkSaved() error {
+	savedVolumesMutex.Lock()
+	defer savedVolumesMutex.Unlock()
+
+	unlock, err := filesystem.Lock(where.Volumes())
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	saved, err := readSavedVolumes()
+	if err != nil {
+		return err
+	}
+
+	saved[v.savedPath()] = lo.Map(v.Chapters, func(c *Chapter, _ int) string {
+		return c.URL
+	})
+
+	marshalled, err := json.Marshal(saved)
+	if err != nil {
+		return err
+	}
+
+	return filesystem.WriteFileAtomic(where.Volumes(), marshalled, os.ModePerm)
+}
+
+// Pages returns pages of all chapters in order.
+// Pages are copies of the chapter pages with continuous indexes,
+// so that file names do not clash between chapters.
+func (v *Volume) Pages() []*Page {
+	var pages []*Page
+
+	for _, chapter := range v.Chapters {
+		for _, page := range chapter.Pages {
+			copied := *page
+			copied.Index = uint16(len(pages) + 1)
+			pages = append(pages, &copied)
+		}
+	}
+
+	return pages
+}
+
+// ComicInfo returns the ComicInfo of the volume.
+// Pages table marks the first page of each chapter with a bookmark.
+func (v *Volume) ComicInfo() *ComicInfo {
+	comicInfo := v.Chapters[0].ComicInfo()
+	comicInfo.Title = v.Name
+	comicInfo.Number = ""
+	comicInfo.Web = v.Manga.URL
+	comicInfo.Volume = v.Number()
+	comicInfo.OrigTitle = v.Name
+	comicInfo.OrigIndex = 0
+
+	if scanlators := lo.Uniq(lo.FlatMap(v.Chapters, func(c *Chapter, _ int) []string {
+		return c.Scanlators
+	})); len(scanlators) > 0 {
+		comicInfo.Translator = strings.Join(scanlators, ",")
+		comicInfo.ScanInformation = comicInfo.Translator
+	}
+
+	var image int
+	for _, chapter := range v.Chapters {
+		for i, page := range chapter.Pages {
+			entry := ComicInfoPage{
+				Image:     image,
+				ImageSize: int64(page.Size),
+			}
+
+			if i == 0 {
+				entry.Bookmark = chapter.Name
+			}
+
+			if image == 0 {
+				entry.Type = "FrontCover"
+			}
+
+			comicInfo.Pages = append(comicInfo.Pages, entry)
+			image++
+		}
+	}
+
+	comicInfo.PageCount = image
+	return comicInfo
+}
@@ -0,0 +1,73 @@
+package source
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestVolumes(t *testing.T) {
+	Convey("Given chapters with and without volumes", t, func() {
+		chapter := func(index uint16, volume string, pages int) *Chapter {
+			c := &Chapter{Name: "Chapter", Index: index, Volume: volume, Manga: &testManga}
+			for i := 0; i < pages; i++ {
+				c.Pages = append(c.Pages, &Page{Index: uint16(i), Extension: ".jpg", Size: 100, Chapter: c})
+			}
+
+			return c
+		}
+
+		chapters := []*Chapter{
+			chapter(3, "Vol. 2", 1),
+			chapter(1, "Vol. 1", 2),
+			chapter(2, "Vol. 1", 3),
+			chapter(11, "", 1),
+			chapter(12, "", 1),
+			chapter(21, "", 1),
+		}
+
+		Convey("When they are grouped into volumes", func() {
+			volumes := Volumes(chapters, 10)
+
+			Convey("Then chapters should be grouped by volume and by chunks", func() {
+				So(volumes, ShouldHaveLength, 4)
+				So(volumes[0].Name, ShouldEqual, "Vol. 1")
+				So(volumes[0].Chapters, ShouldHaveLength, 2)
+				So(volumes[0].Chapters[0].Index, ShouldEqual, 1)
+				So(volumes[1].Name, ShouldEqual, "Vol. 2")
+				So(volumes[2].Name, ShouldEqual, "Chapters 11-20")
+				So(volumes[2].Chapters, ShouldHaveLength, 2)
+				So(volumes[3].Name, ShouldEqual, "Chapters 21-30")
+			})
+
+			Convey("Then pages should be numbered continuously", func() {
+				pages := volumes[0].Pages()
+				So(pages, ShouldHaveLength, 5)
+				for i, page := range pages {
+					So(page.Index, ShouldEqual, i+1)
+				}
+
+				So(chapters[1].Pages[0].Index, ShouldEqual, 0)
+			})
+
+			Convey("Then ComicInfo should have the volume and the pages table", func() {
+				comicInfo := volumes[0].ComicInfo()
+				So(comicInfo.Volume, ShouldEqual, 1)
+				So(comicInfo.Title, ShouldEqual, "Vol. 1")
+				So(comicInfo.PageCount, ShouldEqual, 5)
+				So(comicInfo.Pages, ShouldHaveLength, 5)
+				So(comicInfo.Pages[0].Type, ShouldEqual, "FrontCover")
+				So(comicInfo.Pages[0].Bookmark, ShouldEqual, "Chapter")
+				So(comicInfo.Pages[1].Bookmark, ShouldBeEmpty)
+				So(comicInfo.Pages[2].Image, ShouldEqual, 2)
+				So(comicInfo.Pages[2].Bookmark, ShouldEqual, "Chapter")
+			})
+
+			Convey("Then ComicInfo of chunks should have no volume", func() {
+				comicInfo := volumes[2].ComicInfo()
+				So(comicInfo.Volume, ShouldEqual, 0)
+				So(comicInfo.Title, ShouldEqual, "Chapters 11-20")
+			})
+		})
+	})
+}
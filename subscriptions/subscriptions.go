@@ -0,0 +1,187 @@
+package subscriptions
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/metafates/mangal/filesystem"
+	"github.com/metafates/mangal/source"
+	"github.com/metafates/mangal/util"
+	"github.com/metafates/mangal/where"
+	"github.com/samber/lo"
+)
+
+// Subscription is a manga that is checked for new chapters by the sync command.
+// It holds enough information to rebuild the manga using its source.
+type Subscription struct {
+	SourceID  string `json:"source_id"`
+	MangaName string `json:"manga_name"`
+	MangaURL  string `json:"manga_url"`
+	MangaID   string `json:"manga_id"`
+	// Format to download new chapters in. Empty means the default format
+	Format     string    `json:"format,omitempty"`
+	Added      time.Time `json:"added"`
+	LastSynced time.Time `json:"last_synced"`
+}
+
+func (s *Subscription) encode() string {
+	return fmt.Sprintf("%s (%s)", s.MangaURL, s.SourceID)
+}
+
+func (s *Subscription) String() string {
+	return s.MangaName
+}
+
+// Manga rebuilds the manga of the subscription for the given source
+func (s *Subscription) Manga(src source.Source) *source.Manga {
+	return &source.Manga{
+		Name:     s.MangaName,
+		URL:      s.MangaURL,
+		ID:       s.MangaID,
+		Source:   src,
+		Format:   s.Format,
+		Chapters: make([]*source.Chapter, 0),
+	}
+}
+
+type subscriptionsFile struct {
+	Subscriptions []*Subscription `json:"subscriptions"`
+}
+
+var mutex sync.Mutex
+
+// read loads subscriptions from the disk.
+// Missing file is treated as no subscriptions.
+func read() ([]*Subscription, error) {
+	path := where.Subscriptions()
+
+	exists, err := filesystem.Api().Exists(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return make([]*Subscription, 0), nil
+	}
+
+	contents, err := filesystem.Api().ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file subscriptionsFile
+	if err = json.Unmarshal(contents, &file); err != nil {
+		return nil, err
+	}
+
+	return file.Subscriptions, nil
+}
+
+// write saves subscriptions to the disk.
+// The file is replaced atomically, so that it is never left half-written.
+func write(subscriptions []*Subscription) error {
+	marshalled, err := json.Marshal(&subscriptionsFile{Subscriptions: subscriptions})
+	if err != nil {
+		return err
+	}
+
+	return filesystem.WriteFileAtomic(where.Subscriptions(), marshalled, os.ModePerm)
+}
+
+// modify reads subscriptions, applies the given function and writes the result back.
+// The file is locked, so that updates of other mangal processes are not lost
+func modify(f func(subscriptions []*Subscription) []*Subscription) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	unlock, err := filesystem.Lock(where.Subscriptions())
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	subscriptions, err := read()
+	if err != nil {
+		return err
+	}
+
+	return write(f(subscriptions))
+}
+
+// Get returns all subscriptions in the order they were added
+func Get() ([]*Subscription, error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	return read()
+}
+
+// Add subscribes to the manga.
+// If the manga is already subscribed to, only its format is updated.
+func Add(manga *source.Manga, format string) (subscription *Subscription, err error) {
+	subscription = &Subscription{
+		SourceID:  manga.Source.ID(),
+		MangaName: manga.Name,
+		MangaURL:  manga.URL,
+		MangaID:   manga.ID,
+		Format:    format,
+		Added:     time.Now(),
+	}
+
+	err = modify(func(subscriptions []*Subscription) []*Subscription {
+		if existing, ok := find(subscriptions, subscription.encode()); ok {
+			existing.Format = format
+			subscription = existing
+			return subscriptions
+		}
+
+		return append(subscriptions, subscription)
+	})
+
+	return
+}
+
+// Remove unsubscribes from manga that match the query.
+// Query is matched against manga names case-insensitively and against manga URLs.
+func Remove(query string) (removed []*Subscription, err error) {
+	err = modify(func(subscriptions []*Subscription) []*Subscription {
+		kept := make([]*Subscription, 0, len(subscriptions))
+
+		for _, subscription := range subscriptions {
+			if subscription.Matches(query) {
+				removed = append(removed, subscription)
+			} else {
+				kept = append(kept, subscription)
+			}
+		}
+
+		return kept
+	})
+
+	return
+}
+
+// Matches reports whether the subscription has the given name or URL
+func (s *Subscription) Matches(query string) bool {
+	return util.NormalizeName(s.MangaName) == util.NormalizeName(query) || s.MangaURL == query
+}
+
+// MarkSynced records the time the subscription was synced
+func MarkSynced(subscription *Subscription) error {
+	return modify(func(subscriptions []*Subscription) []*Subscription {
+		if existing, ok := find(subscriptions, subscription.encode()); ok {
+			existing.LastSynced = time.Now()
+		}
+
+		return subscriptions
+	})
+}
+
+func find(subscriptions []*Subscription, encoded string) (*Subscription, bool) {
+	return lo.Find(subscriptions, func(s *Subscription) bool {
+		return s.encode() == encoded
+	})
+}
@@ -0,0 +1,115 @@
+package subscriptions
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/metafates/mangal/config"
+	"github.com/metafates/mangal/filesystem"
+	"github.com/metafates/mangal/key"
+	"github.com/metafates/mangal/source"
+	"github.com/metafates/mangal/where"
+	"github.com/samber/lo"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	filesystem.SetMemMapFs()
+	lo.Must0(config.Setup())
+}
+
+type testSource struct{}
+
+func (testSource) Name() string                           { return "Test" }
+func (testSource) ID() string                             { return "test" }
+func (testSource) StdLang() string                        { return "en" }
+func (testSource) Search(string) ([]*source.Manga, error) { return nil, nil }
+func (testSource) PagesOf(*source.Chapter) ([]*source.Page, error) {
+	return nil, nil
+}
+
+func (testSource) ChaptersOf(manga *source.Manga) ([]*source.Chapter, error) {
+	return []*source.Chapter{
+		{Name: "Chapter 1", URL: manga.URL + "/1", Index: 1, Manga: manga},
+		{Name: "Chapter 2", URL: manga.URL + "/2", Index: 2, Manga: manga},
+	}, nil
+}
+
+func TestSubscriptions(t *testing.T) {
+	Convey("Given a manga", t, func() {
+		lo.Must0(filesystem.Api().RemoveAll(where.Subscriptions()))
+
+		manga := &source.Manga{Name: "Berserk", URL: "https://example.com/berserk", Source: testSource{}}
+
+		Convey("When it is subscribed to twice", func() {
+			_, err := Add(manga, "")
+			So(err, ShouldBeNil)
+			_, err = Add(manga, "cbz")
+			So(err, ShouldBeNil)
+
+			Convey("Then only one subscription with the latest format should be stored", func() {
+				subscriptions, err := Get()
+				So(err, ShouldBeNil)
+				So(subscriptions, ShouldHaveLength, 1)
+				So(subscriptions[0].SourceID, ShouldEqual, "test")
+				So(subscriptions[0].Format, ShouldEqual, "cbz")
+
+				Convey("And it should be removed by name", func() {
+					removed, err := Remove("berserk")
+					So(err, ShouldBeNil)
+					So(removed, ShouldHaveLength, 1)
+
+					subscriptions, err = Get()
+					So(err, ShouldBeNil)
+					So(subscriptions, ShouldBeEmpty)
+				})
+			})
+		})
+	})
+}
+
+func TestSyncOne(t *testing.T) {
+	viper.Set(key.FormatsUse, "plain")
+	viper.Set(key.DownloaderCreateMangaDir, true)
+	viper.Set(key.DownloaderCreateSourceDir, false)
+
+	Convey("Given a subscription with one chapter already downloaded", t, func() {
+		subscription := &Subscription{
+			SourceID:  "test",
+			MangaName: "Berserk",
+			MangaURL:  "https://example.com/berserk",
+			Format:    "plain",
+		}
+
+		chapters := lo.Must(testSource{}.ChaptersOf(subscription.Manga(testSource{})))
+		path := lo.Must(chapters[0].Path(false))
+		lo.Must0(filesystem.Api().MkdirAll(path, os.ModePerm))
+
+		Convey("When it is synced in dry run", func() {
+			report := syncOne(context.Background(), subscription, testSource{}, &Options{DryRun: true})
+
+			Convey("Then only the new chapter should be reported", func() {
+				So(report.Error, ShouldBeEmpty)
+				So(report.Failed, ShouldBeEmpty)
+				So(report.Fetched, ShouldHaveLength, 1)
+				So(report.Fetched[0].Name, ShouldEqual, "Chapter 2")
+				So(report.Format, ShouldEqual, "plain")
+			})
+		})
+
+		Convey("When it is synced with another format in the config", func() {
+			viper.Set(key.FormatsUse, "cbz")
+			defer viper.Set(key.FormatsUse, "plain")
+
+			report := syncOne(context.Background(), subscription, testSource{}, &Options{DryRun: true})
+
+			Convey("Then the format of the subscription should be used without changing the config", func() {
+				So(report.Fetched, ShouldHaveLength, 1)
+				So(report.Format, ShouldEqual, "plain")
+				So(viper.GetString(key.FormatsUse), ShouldEqual, "cbz")
+			})
+		})
+	})
+}
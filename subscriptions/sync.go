@@ -0,0 +1,259 @@
+package subscriptions
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/metafates/mangal/downloader"
+	"github.com/metafates/mangal/key"
+	"github.com/metafates/mangal/log"
+	"github.com/metafates/mangal/provider"
+	"github.com/metafates/mangal/queue"
+	"github.com/metafates/mangal/source"
+	"github.com/samber/lo"
+	"github.com/spf13/viper"
+)
+
+// Options of the sync
+type Options struct {
+	// DryRun only reports new chapters without downloading them
+	DryRun bool
+	// Timeout for fetching chapters of a single subscription. Zero means no timeout
+	Timeout time.Duration
+	// Progress is called with the status of the sync, may be nil
+	Progress func(string)
+}
+
+func (o *Options) progress(msg string) {
+	if o.Progress != nil {
+		o.Progress(msg)
+	}
+}
+
+// Summary of the sync
+type Summary struct {
+	DryRun        bool      `json:"dry_run"`
+	Fetched       int       `json:"fetched"`
+	Failed        int       `json:"failed"`
+	Subscriptions []*Report `json:"subscriptions"`
+}
+
+// Report is the result of syncing a single subscription
+type Report struct {
+	SourceID string `json:"source_id"`
+	Manga    string `json:"manga"`
+	URL      string `json:"url"`
+	Format   string `json:"format"`
+	// Fetched chapters. In dry run these are the chapters that would be downloaded
+	Fetched []*ChapterReport `json:"fetched"`
+	// Failed chapters
+	Failed []*ChapterReport `json:"failed"`
+	// Error is set if chapters of the manga could not be fetched at all
+	Error string `json:"error,omitempty"`
+}
+
+// ChapterReport is a chapter that was fetched or failed
+type ChapterReport struct {
+	Name  string `json:"name"`
+	URL   string `json:"url"`
+	Path  string `json:"path,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// Sync checks every subscription for chapters that are not downloaded yet and downloads them.
+// Chapters are compared against the disk, so chapters downloaded by other means are skipped too.
+func Sync(ctx context.Context, subscriptions []*Subscription, options *Options) *Summary {
+	summary := &Summary{
+		DryRun:        options.DryRun,
+		Subscriptions: make([]*Report, 0, len(subscriptions)),
+	}
+
+	sources := make(map[string]source.Source)
+
+	for _, subscription := range subscriptions {
+		if ctx.Err() != nil {
+			break
+		}
+
+		src, ok := sources[subscription.SourceID]
+		if !ok {
+			var err error
+			src, err = createSource(subscription.SourceID)
+			if err != nil {
+				summary.add(newReport(subscription, err))
+				continue
+			}
+
+			sources[subscription.SourceID] = src
+		}
+
+		report := syncOne(ctx, subscription, src, options)
+		summary.add(report)
+
+		if report.Error == "" && !options.DryRun {
+			if err := MarkSynced(subscription); err != nil {
+				log.Warn(err)
+			}
+		}
+
+		if len(report.Failed) > 0 && viper.GetBool(key.DownloaderStopOnError) {
+			break
+		}
+	}
+
+	return summary
+}
+
+func (s *Summary) add(report *Report) {
+	s.Subscriptions = append(s.Subscriptions, report)
+	s.Fetched += len(report.Fetched)
+	s.Failed += len(report.Failed)
+}
+
+func createSource(id string) (source.Source, error) {
+	p, ok := provider.GetByID(id)
+	if !ok {
+		return nil, fmt.Errorf("source not found: %s", id)
+	}
+
+	return p.CreateSource()
+}
+
+func newReport(subscription *Subscription, err error) *Report {
+	report := &Report{
+		SourceID: subscription.SourceID,
+		Manga:    subscription.MangaName,
+		URL:      subscription.MangaURL,
+		Format:   subscription.Format,
+		Fetched:  make([]*ChapterReport, 0),
+		Failed:   make([]*ChapterReport, 0),
+	}
+
+	if report.Format == "" {
+		report.Format = viper.GetString(key.FormatsUse)
+	}
+
+	if err != nil {
+		log.Error(err)
+		report.Error = err.Error()
+	}
+
+	return report
+}
+
+// syncOne fetches chapters of the subscribed manga and downloads the new ones
+func syncOne(ctx context.Context, subscription *Subscription, src source.Source, options *Options) *Report {
+	// chapters are saved in the format of the subscription, see Subscription.Manga
+	manga := subscription.Manga(src)
+	options.progress(fmt.Sprintf("Fetching chapters of %s", manga.Name))
+
+	chapters, err := fetchChapters(ctx, src, manga, options.Timeout)
+	if err != nil {
+		return newReport(subscription, err)
+	}
+
+	report := newReport(subscription, nil)
+
+	for _, chapter := range chapters {
+		chapter.Manga = manga
+	}
+	manga.Chapters = chapters
+
+	bundle := viper.GetBool(key.FormatsBundleVolumes)
+
+	// chapters are not saved separately when volumes are bundled,
+	// so compare volumes instead
+	var volumes []*source.Volume
+	if bundle {
+		volumes = lo.Filter(downloader.Volumes(chapters), func(v *source.Volume, _ int) bool {
+			return !v.IsDownloaded()
+		})
+
+		chapters = lo.FlatMap(volumes, func(v *source.Volume, _ int) []*source.Chapter {
+			return v.Chapters
+		})
+	} else {
+		chapters = lo.Filter(chapters, func(c *source.Chapter, _ int) bool {
+			return !c.IsDownloaded()
+		})
+	}
+
+	log.Infof("found %d new chapters of %s", len(chapters), manga.Name)
+
+	if options.DryRun {
+		for _, chapter := range chapters {
+			report.Fetched = append(report.Fetched, &ChapterReport{Name: chapter.Name, URL: chapter.URL})
+		}
+
+		return report
+	}
+
+	if err = queue.Enqueue(chapters...); err != nil {
+		log.Warn(err)
+	}
+
+	if bundle {
+		syncVolumes(ctx, volumes, report, options)
+		return report
+	}
+
+	for _, chapter := range chapters {
+		if ctx.Err() != nil {
+			break
+		}
+
+		path, err := downloader.Download(chapter, func(s string) {
+			options.progress(fmt.Sprintf("%s : %s: %s", manga.Name, chapter.Name, s))
+		})
+
+		if err != nil {
+			report.Failed = append(report.Failed, &ChapterReport{Name: chapter.Name, URL: chapter.URL, Error: err.Error()})
+
+			if viper.GetBool(key.DownloaderStopOnError) {
+				break
+			}
+
+			continue
+		}
+
+		report.Fetched = append(report.Fetched, &ChapterReport{Name: chapter.Name, URL: chapter.URL, Path: path})
+	}
+
+	return report
+}
+
+// syncVolumes downloads volumes and adds their chapters to the report
+func syncVolumes(ctx context.Context, volumes []*source.Volume, report *Report, options *Options) {
+	for _, volume := range volumes {
+		if ctx.Err() != nil {
+			break
+		}
+
+		path, err := downloader.DownloadVolume(volume, func(s string) {
+			options.progress(fmt.Sprintf("%s : %s: %s", volume.Manga.Name, volume.Name, s))
+		})
+
+		for _, chapter := range volume.Chapters {
+			if err != nil {
+				report.Failed = append(report.Failed, &ChapterReport{Name: chapter.Name, URL: chapter.URL, Error: err.Error()})
+			} else {
+				report.Fetched = append(report.Fetched, &ChapterReport{Name: chapter.Name, URL: chapter.URL, Path: path})
+			}
+		}
+
+		if err != nil && viper.GetBool(key.DownloaderStopOnError) {
+			break
+		}
+	}
+}
+
+func fetchChapters(ctx context.Context, src source.Source, manga *source.Manga, timeout time.Duration) ([]*source.Chapter, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	return source.WithContext(src).ChaptersOfContext(ctx, manga)
+}
@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"context"
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
@@ -15,6 +16,7 @@ import (
 	"github.com/metafates/mangal/installer"
 	key2 "github.com/metafates/mangal/key"
 	"github.com/metafates/mangal/provider"
+	"github.com/metafates/mangal/search"
 	"github.com/metafates/mangal/source"
 	"github.com/metafates/mangal/style"
 	"github.com/metafates/mangal/util"
@@ -53,7 +55,7 @@ type statefulBubble struct {
 	scrapersLoadedChannel       chan []*installer.Scraper
 	scraperInstalledChannel     chan *installer.Scraper
 	sourcesLoadedChannel        chan []source.Source
-	foundMangasChannel          chan []*source.Manga
+	foundMangasChannel          chan *search.Result
 	foundChaptersChannel        chan []*source.Chapter
 	fetchedAnilistMangasChannel chan []*anilist.Manga
 	closestAnilistMangaChannel  chan *anilist.Manga
@@ -64,6 +66,10 @@ type statefulBubble struct {
 	progressStatus string
 
 	chaptersToDownload util.Stack[*source.Chapter]
+	// volumesToDownload maps the first chapter of each volume to the volume when volumes are bundled
+	volumesToDownload map[*source.Chapter]*source.Volume
+	// lastDownloadedCount is the number of chapters downloaded by the last download
+	lastDownloadedCount int
 
 	currentDownloadingChapter *source.Chapter
 	lastError                 error
@@ -75,6 +81,9 @@ type statefulBubble struct {
 	succededChapters []*source.Chapter
 
 	searchSuggestion mo.Option[string]
+
+	// cancelRequest cancels the pending search or chapters request
+	cancelRequest context.CancelFunc
 }
 
 func (b *statefulBubble) raiseError(err error) {
@@ -83,6 +92,23 @@ func (b *statefulBubble) raiseError(err error) {
 	b.newState(errorState)
 }
 
+// requestContext cancels the pending request and returns a context for a new one
+func (b *statefulBubble) requestContext() context.Context {
+	b.stopRequest()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b.cancelRequest = cancel
+	return ctx
+}
+
+// stopRequest cancels the pending request, if any
+func (b *statefulBubble) stopRequest() {
+	if b.cancelRequest != nil {
+		b.cancelRequest()
+		b.cancelRequest = nil
+	}
+}
+
 func (b *statefulBubble) setState(s state) {
 	b.state = s
 	b.keymap.setState(s)
@@ -174,7 +200,7 @@ func newBubble() *statefulBubble {
 		scrapersLoadedChannel:       make(chan []*installer.Scraper),
 		scraperInstalledChannel:     make(chan *installer.Scraper),
 		sourcesLoadedChannel:        make(chan []source.Source),
-		foundMangasChannel:          make(chan []*source.Manga),
+		foundMangasChannel:          make(chan *search.Result),
 		foundChaptersChannel:        make(chan []*source.Chapter),
 		fetchedAnilistMangasChannel: make(chan []*anilist.Manga),
 		closestAnilistMangaChannel:  make(chan *anilist.Manga),
@@ -185,6 +211,7 @@ func newBubble() *statefulBubble {
 		selectedProviders:  make(map[*provider.Provider]struct{}),
 		selectedChapters:   make(map[*source.Chapter]struct{}),
 		chaptersToDownload: util.Stack[*source.Chapter]{},
+		volumesToDownload:  make(map[*source.Chapter]*source.Volume),
 
 		failedChapters:   make([]*source.Chapter, 0),
 		succededChapters: make([]*source.Chapter, 0),
@@ -323,11 +350,12 @@ func (b *statefulBubble) loadHistory() (tea.Cmd, error) {
 	}
 
 	chapters := lo.Values(saved)
+	// recently read manga first
 	slices.SortFunc(chapters, func(a, b *history.SavedChapter) bool {
-		if a.MangaName == b.MangaName {
-			return a.Name < b.Name
+		if a.Time.Equal(b.Time) {
+			return a.MangaName < b.MangaName
 		}
-		return a.MangaName < b.MangaName
+		return a.Time.After(b.Time)
 	})
 
 	var items []list.Item
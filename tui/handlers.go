@@ -11,9 +11,11 @@ import (
 	"github.com/metafates/mangal/key"
 	"github.com/metafates/mangal/log"
 	"github.com/metafates/mangal/provider"
+	"github.com/metafates/mangal/search"
 	"github.com/metafates/mangal/source"
 	"github.com/metafates/mangal/style"
 	"github.com/metafates/mangal/util"
+	"github.com/metafates/mangal/viewer"
 	"github.com/spf13/viper"
 	"golang.org/x/exp/slices"
 	"strings"
@@ -142,34 +144,29 @@ func (b *statefulBubble) waitForSourcesLoaded() tea.Cmd {
 }
 
 func (b *statefulBubble) searchManga(query string) tea.Cmd {
+	ctx := b.requestContext()
+
 	return func() tea.Msg {
 		log.Info("searching for " + query)
 		b.progressStatus = fmt.Sprintf("Searching among %s", util.Quantify(len(b.selectedSources), "source", "sources"))
 
-		var mangas = make([]*source.Manga, 0)
-
-		wg := sync.WaitGroup{}
-		wg.Add(len(b.selectedSources))
-		for _, s := range b.selectedSources {
-			go func(s source.Source) {
-				defer wg.Done()
-				sourceMangas, err := s.Search(query)
+		result := search.Search(ctx, b.selectedSources, query)
 
-				if err != nil {
-					log.Error(err)
-					b.errorChannel <- err
-				}
-
-				log.Infof("found %s from source %s", util.Quantify(len(sourceMangas), "manga", "mangas"), s.Name())
-				mangas = append(mangas, sourceMangas...)
-			}(s)
+		// search was cancelled by the user
+		if ctx.Err() != nil {
+			log.Info("search for " + query + " was cancelled")
+			return nil
 		}
 
-		wg.Wait()
+		if err := result.Err(); err != nil {
+			log.Error(err)
+			b.errorChannel <- err
+			return nil
+		}
 
-		log.Infof("found %d mangas from %d sources", len(mangas), len(b.selectedSources))
+		log.Infof("found %s from %d sources", util.Quantify(len(result.Groups), "manga", "mangas"), len(b.selectedSources))
 
-		b.foundMangasChannel <- mangas
+		b.foundMangasChannel <- result
 
 		return nil
 	}
@@ -188,10 +185,14 @@ func (b *statefulBubble) waitForMangas() tea.Cmd {
 }
 
 func (b *statefulBubble) getChapters(manga *source.Manga) tea.Cmd {
+	ctx := b.requestContext()
+
 	return func() tea.Msg {
 		log.Info("getting chapters of " + manga.Name)
-		chapters, err := manga.Source.ChaptersOf(manga)
-		if err != nil {
+		chapters, err := source.WithContext(manga.Source).ChaptersOfContext(ctx, manga)
+		if ctx.Err() != nil {
+			log.Info("getting chapters of " + manga.Name + " was cancelled")
+		} else if err != nil {
 			log.Error(err)
 			b.errorChannel <- err
 		} else {
@@ -216,6 +217,22 @@ func (b *statefulBubble) waitForChapters() tea.Cmd {
 }
 
 func (b *statefulBubble) readChapter(chapter *source.Chapter) tea.Cmd {
+	// terminal reader needs the whole terminal, so the tui is suspended until it's closed
+	if viper.GetBool(key.ReaderTerminal) && !viper.GetBool(key.ReaderReadInBrowser) {
+		b.currentDownloadingChapter = chapter
+		return tea.Exec(viewer.NewCommand(chapter), func(err error) tea.Msg {
+			go func() {
+				if err != nil {
+					b.errorChannel <- err
+				} else {
+					b.chapterReadChannel <- struct{}{}
+				}
+			}()
+
+			return nil
+		})
+	}
+
 	return func() tea.Msg {
 		b.currentDownloadingChapter = chapter
 		err := downloader.Read(chapter, func(s string) {
@@ -247,19 +264,34 @@ func (b *statefulBubble) waitForChapterRead() tea.Cmd {
 func (b *statefulBubble) downloadChapter(chapter *source.Chapter) tea.Cmd {
 	return func() tea.Msg {
 		b.currentDownloadingChapter = chapter
-		_, err := downloader.Download(chapter, func(s string) {
-			b.progressStatus = s
-		})
+
+		var (
+			chapters = []*source.Chapter{chapter}
+			err      error
+		)
+
+		if volume, ok := b.volumesToDownload[chapter]; ok {
+			chapters = volume.Chapters
+			_, err = downloader.DownloadVolume(volume, func(s string) {
+				b.progressStatus = volume.Name + ": " + s
+			})
+		} else {
+			_, err = downloader.Download(chapter, func(s string) {
+				b.progressStatus = s
+			})
+		}
+
+		b.lastDownloadedCount = len(chapters)
 
 		if err != nil {
 			if viper.GetBool(key.DownloaderStopOnError) {
 				b.errorChannel <- err
 			} else {
-				b.failedChapters = append(b.failedChapters, chapter)
+				b.failedChapters = append(b.failedChapters, chapters...)
 				b.chapterDownloadChannel <- struct{}{}
 			}
 		} else {
-			b.succededChapters = append(b.succededChapters, chapter)
+			b.succededChapters = append(b.succededChapters, chapters...)
 			b.chapterDownloadChannel <- struct{}{}
 		}
 
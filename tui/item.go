@@ -10,13 +10,27 @@ import (
 	"github.com/metafates/mangal/icon"
 	"github.com/metafates/mangal/installer"
 	"github.com/metafates/mangal/provider"
+	"github.com/metafates/mangal/search"
 	"github.com/metafates/mangal/source"
 	"github.com/metafates/mangal/style"
+	"github.com/samber/lo"
 )
 
 type listItem struct {
 	internal interface{}
 	marked   bool
+	// group of the similar mangas from other sources
+	group *search.Group
+}
+
+// otherSources returns names of the other sources that have the same manga
+func (t *listItem) otherSources() []string {
+	manga, ok := t.internal.(*source.Manga)
+	if !ok || t.group == nil || manga.Source == nil {
+		return nil
+	}
+
+	return lo.Without(t.group.Sources(), manga.Source.Name())
 }
 
 func (t *listItem) toggleMark() {
@@ -60,6 +74,11 @@ func (t *listItem) Title() (title string) {
 		}
 
 		title = sb.String()
+	case *source.Manga:
+		title = t.FilterValue()
+		if len(t.otherSources()) > 0 {
+			title = fmt.Sprintf("%s %s", title, style.Faint(e.Source.Name()))
+		}
 	default:
 		title = t.FilterValue()
 	}
@@ -78,10 +97,16 @@ func (t *listItem) Description() (description string) {
 		description = e.URL
 	case *source.Manga:
 		description = e.URL
+		if others := t.otherSources(); len(others) > 0 {
+			description = fmt.Sprintf("%s %s", description, style.Faint("also on "+strings.Join(others, ", ")))
+		}
 	case *installer.Scraper:
 		description = e.GithubURL()
 	case *history.SavedChapter:
 		description = fmt.Sprintf("%s : %d / %d", e.Name, e.Index, e.MangaChaptersTotal)
+		if !e.Time.IsZero() {
+			description = fmt.Sprintf("%s %s", description, style.Faint(e.Time.Format("2006-01-02 15:04")))
+		}
 	case *provider.Provider:
 		sb := strings.Builder{}
 		if e.IsCustom {
@@ -8,12 +8,16 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/metafates/mangal/anilist"
 	"github.com/metafates/mangal/color"
+	"github.com/metafates/mangal/downloader"
 	"github.com/metafates/mangal/history"
 	"github.com/metafates/mangal/installer"
 	key2 "github.com/metafates/mangal/key"
+	"github.com/metafates/mangal/log"
 	"github.com/metafates/mangal/open"
 	"github.com/metafates/mangal/provider"
 	"github.com/metafates/mangal/query"
+	"github.com/metafates/mangal/queue"
+	"github.com/metafates/mangal/search"
 	"github.com/metafates/mangal/source"
 	"github.com/metafates/mangal/style"
 	"github.com/metafates/mangal/util"
@@ -92,6 +96,7 @@ func (b *statefulBubble) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				cmd = onListBack(&b.scrapersInstallC)
 			}
 
+			b.stopRequest()
 			b.previousState()
 			b.stopLoading()
 			b.failedChapters = make([]*source.Chapter, 0)
@@ -206,10 +211,16 @@ func (b *statefulBubble) updateLoading(msg tea.Msg) (tea.Model, tea.Cmd) {
 		b.newState(scrapersInstallState)
 		b.scrapersInstallC.NewStatusMessage(fmt.Sprintf("Installed %s", msg.Name))
 		return b, b.stopLoading()
-	case []*source.Manga:
-		items := make([]list.Item, len(msg))
-		for i, m := range msg {
-			items[i] = &listItem{internal: m}
+	case *search.Result:
+		for name, err := range msg.Errors {
+			log.Warnf("source %s has failed: %s", name, err)
+		}
+
+		items := make([]list.Item, 0, len(msg.Groups))
+		for _, group := range msg.Groups {
+			for _, m := range group.Mangas {
+				items = append(items, &listItem{internal: m, group: group})
+			}
 		}
 
 		cmds = append(cmds, b.mangasC.SetItems(items))
@@ -633,14 +644,8 @@ func (b *statefulBubble) updateConfirm(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case key.Matches(msg, b.keymap.quit):
 			return b, tea.Quit
 		case key.Matches(msg, b.keymap.confirm):
-			chapters := lo.Keys(b.selectedChapters)
-			slices.SortFunc(chapters, func(a, b *source.Chapter) bool {
-				return a.Index > b.Index
-			})
+			b.pushDownloads(lo.Keys(b.selectedChapters))
 
-			for _, chapter := range chapters {
-				b.chaptersToDownload.Push(chapter)
-			}
 			b.newState(downloadState)
 			return b, tea.Batch(b.startLoading(), b.downloadChapter(b.chaptersToDownload.Pop()), b.waitForChapterDownload(), b.progressC.SetPercent(0))
 		case key.Matches(msg, b.keymap.back):
@@ -664,12 +669,44 @@ func (b *statefulBubble) updateRead(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return b, cmd
 }
 
+// pushDownloads enqueues chapters and puts them on the download stack.
+// When volumes are bundled, only the first chapter of each volume is put on the stack.
+func (b *statefulBubble) pushDownloads(chapters []*source.Chapter) {
+	b.chaptersToDownload = util.Stack[*source.Chapter]{}
+	b.volumesToDownload = make(map[*source.Chapter]*source.Volume)
+
+	if err := queue.Enqueue(chapters...); err != nil {
+		log.Warn(err)
+	}
+
+	if viper.GetBool(key2.FormatsBundleVolumes) {
+		volumes := downloader.Volumes(chapters)
+		for i := len(volumes) - 1; i >= 0; i-- {
+			first := volumes[i].Chapters[0]
+			b.volumesToDownload[first] = volumes[i]
+			b.chaptersToDownload.Push(first)
+		}
+
+		return
+	}
+
+	sorted := make([]*source.Chapter, len(chapters))
+	copy(sorted, chapters)
+	slices.SortFunc(sorted, func(a, b *source.Chapter) bool {
+		return a.Index > b.Index
+	})
+
+	for _, chapter := range sorted {
+		b.chaptersToDownload.Push(chapter)
+	}
+}
+
 func (b *statefulBubble) updateDownload(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
 	switch msg := msg.(type) {
 	case struct{}:
-		inc := 1 / float64(len(b.selectedChapters))
+		inc := float64(b.lastDownloadedCount) / float64(len(b.selectedChapters))
 
 		if b.chaptersToDownload.Len() == 0 {
 			// a little hack to make the progress render to the end
@@ -715,10 +752,8 @@ func (b *statefulBubble) updateDownloadDone(msg tea.Msg) (tea.Model, tea.Cmd) {
 				break
 			}
 
-			b.chaptersToDownload = util.Stack[*source.Chapter]{}
-			for _, chapter := range b.failedChapters {
-				b.chaptersToDownload.Push(chapter)
-			}
+			b.pushDownloads(b.failedChapters)
+
 			b.failedChapters = make([]*source.Chapter, 0)
 			b.succededChapters = make([]*source.Chapter, 0)
 			b.newState(downloadState)
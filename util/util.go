@@ -216,6 +216,11 @@ func Capitalize(s string) string {
 	return strings.ToUpper(s[:1]) + s[1:]
 }
 
+// NormalizeName returns a normalized manga name for comparison
+func NormalizeName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
 // Delete removes the given path from the filesystem.
 // It can handle both files and directories (recursively).
 func Delete(path string) error {
@@ -0,0 +1,63 @@
+package viewer
+
+// action is what the key does
+type action int
+
+const (
+	actionNone action = iota
+	actionNextPage
+	actionPrevPage
+	actionNextChapter
+	actionPrevChapter
+	actionFirstPage
+	actionLastPage
+	actionRedraw
+	actionQuit
+)
+
+// keys maps the input of the terminal in raw mode to actions.
+// Arrow keys are sent either as CSI or SS3 sequences depending on the terminal mode
+var keys = map[string]action{
+	"l":       actionNextPage,
+	"j":       actionNextPage,
+	" ":       actionNextPage,
+	"\r":      actionNextPage,
+	"\x1b[C":  actionNextPage,
+	"\x1bOC":  actionNextPage,
+	"\x1b[B":  actionNextPage,
+	"\x1bOB":  actionNextPage,
+	"\x1b[6~": actionNextPage,
+
+	"h":       actionPrevPage,
+	"k":       actionPrevPage,
+	"\x7f":    actionPrevPage,
+	"\x1b[D":  actionPrevPage,
+	"\x1bOD":  actionPrevPage,
+	"\x1b[A":  actionPrevPage,
+	"\x1bOA":  actionPrevPage,
+	"\x1b[5~": actionPrevPage,
+
+	"n": actionNextChapter,
+	"]": actionNextChapter,
+	"p": actionPrevChapter,
+	"[": actionPrevChapter,
+
+	"g":      actionFirstPage,
+	"\x1b[H": actionFirstPage,
+	"G":      actionLastPage,
+	"\x1b[F": actionLastPage,
+
+	"r":    actionRedraw,
+	"\x0c": actionRedraw,
+
+	"q":    actionQuit,
+	"\x1b": actionQuit,
+	"\x03": actionQuit,
+}
+
+// keysHelp is shown in the status line
+const keysHelp = "←/→ page  [/] chapter  g/G first/last  q quit"
+
+func parseKey(input []byte) action {
+	return keys[string(input)]
+}
@@ -0,0 +1,10 @@
+//go:build !(aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris)
+
+package viewer
+
+import "os"
+
+// cellSize returns zero, since the size of the cell in pixels is unknown on this platform
+func cellSize(*os.File) (width, height int) {
+	return 0, 0
+}
@@ -0,0 +1,350 @@
+// Package viewer is the built-in reader that shows pages in the terminal
+package viewer
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+
+	"github.com/metafates/mangal/color"
+	"github.com/metafates/mangal/graphics"
+	"github.com/metafates/mangal/history"
+	"github.com/metafates/mangal/imaging"
+	"github.com/metafates/mangal/key"
+	"github.com/metafates/mangal/log"
+	"github.com/metafates/mangal/source"
+	"github.com/metafates/mangal/style"
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+)
+
+// Encoder returns the encoder of the configured protocol.
+// The protocol is detected from the environment if it's set to auto
+func Encoder() (graphics.Encoder, error) {
+	protocol := graphics.Protocol(viper.GetString(key.ReaderTerminalProtocol))
+
+	if protocol == "" || protocol == "auto" {
+		detected, ok := graphics.Detect(os.Getenv)
+		if !ok {
+			return nil, fmt.Errorf("could not detect graphics protocol of the terminal, set %s to kitty, iterm2 or sixel", key.ReaderTerminalProtocol)
+		}
+
+		protocol = detected
+	}
+
+	return graphics.Get(protocol)
+}
+
+// Read shows the chapter in the terminal until the user quits.
+// Other chapters of the manga can be opened from the reader
+func Read(chapter *source.Chapter) error {
+	return run(chapter, os.Stdin, os.Stdout)
+}
+
+// Command runs the reader as an exec command of the TUI,
+// so that the TUI gives the terminal to the reader while it's open
+type Command struct {
+	chapter *source.Chapter
+	stdin   io.Reader
+	stdout  io.Writer
+}
+
+// NewCommand returns the command that reads the chapter
+func NewCommand(chapter *source.Chapter) *Command {
+	return &Command{
+		chapter: chapter,
+		stdin:   os.Stdin,
+		stdout:  os.Stdout,
+	}
+}
+
+func (c *Command) Run() error {
+	return run(c.chapter, c.stdin, c.stdout)
+}
+
+func (c *Command) SetStdin(r io.Reader) {
+	c.stdin = r
+}
+
+func (c *Command) SetStdout(w io.Writer) {
+	c.stdout = w
+}
+
+func (c *Command) SetStderr(io.Writer) {}
+
+type viewer struct {
+	encoder graphics.Encoder
+	in      io.Reader
+	out     *bufio.Writer
+	// tty is the terminal the reader is drawn in
+	tty *os.File
+
+	chapters []*source.Chapter
+	chapter  int
+	page     int
+}
+
+func run(chapter *source.Chapter, in io.Reader, out io.Writer) error {
+	encoder, err := Encoder()
+	if err != nil {
+		return err
+	}
+
+	tty, ok := out.(*os.File)
+	if !ok || !term.IsTerminal(int(tty.Fd())) {
+		return errors.New("terminal reader requires a terminal")
+	}
+
+	v := &viewer{
+		encoder:  encoder,
+		in:       in,
+		out:      bufio.NewWriter(out),
+		tty:      tty,
+		chapters: []*source.Chapter{chapter},
+	}
+
+	if chapter.Manga != nil {
+		for i, c := range chapter.Manga.Chapters {
+			if c == chapter {
+				v.chapters = chapter.Manga.Chapters
+				v.chapter = i
+				break
+			}
+		}
+	}
+
+	if f, ok := in.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		state, err := term.MakeRaw(int(f.Fd()))
+		if err != nil {
+			return err
+		}
+
+		defer func() {
+			_ = term.Restore(int(f.Fd()), state)
+		}()
+	}
+
+	// alternate screen keeps the scrollback clean, cursor is hidden while reading.
+	// Line wrapping is disabled, so that long status doesn't scroll the page
+	_, _ = v.out.WriteString("\x1b[?1049h\x1b[?25l\x1b[?7l")
+	defer func() {
+		_ = v.encoder.Clear(v.out)
+		_, _ = v.out.WriteString("\x1b[?7h\x1b[?25h\x1b[?1049l")
+		_ = v.out.Flush()
+		v.leave(v.current())
+	}()
+
+	if err = v.load(v.current()); err != nil {
+		return err
+	}
+
+	return v.loop()
+}
+
+func (v *viewer) current() *source.Chapter {
+	return v.chapters[v.chapter]
+}
+
+func (v *viewer) loop() error {
+	input := make([]byte, 16)
+
+	for {
+		if err := v.render(); err != nil {
+			return err
+		}
+
+		n, err := v.in.Read(input)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return err
+		}
+
+		pages := len(v.current().Pages)
+
+		switch parseKey(input[:n]) {
+		case actionNextPage:
+			if v.page+1 < pages {
+				v.page++
+			} else {
+				v.open(v.chapter+1, false)
+			}
+		case actionPrevPage:
+			if v.page > 0 {
+				v.page--
+			} else {
+				v.open(v.chapter-1, true)
+			}
+		case actionNextChapter:
+			v.open(v.chapter+1, false)
+		case actionPrevChapter:
+			v.open(v.chapter-1, false)
+		case actionFirstPage:
+			v.page = 0
+		case actionLastPage:
+			v.page = pages - 1
+		case actionQuit:
+			return nil
+		}
+	}
+}
+
+// open switches to the chapter, at the last page if atEnd is true.
+// The current chapter stays open if the chapter can't be loaded
+func (v *viewer) open(index int, atEnd bool) {
+	if index < 0 || index >= len(v.chapters) {
+		return
+	}
+
+	chapter := v.chapters[index]
+	if err := v.load(chapter); err != nil {
+		log.Error(err)
+		v.status(style.Fg(color.Red)(err.Error()))
+		v.waitKey()
+		return
+	}
+
+	v.leave(v.current())
+	v.chapter = index
+	v.page = 0
+
+	if atEnd {
+		v.page = len(chapter.Pages) - 1
+	}
+}
+
+// load downloads pages of the chapter
+func (v *viewer) load(chapter *source.Chapter) error {
+	v.status(fmt.Sprintf("Loading %s", chapter.Name))
+
+	if len(chapter.Pages) == 0 {
+		if _, err := chapter.Source().PagesOf(chapter); err != nil {
+			return err
+		}
+	}
+
+	if err := chapter.DownloadPages(true, v.status); err != nil {
+		return err
+	}
+
+	imaging.ProcessChapter(chapter, v.status)
+
+	if len(chapter.Pages) == 0 {
+		return fmt.Errorf("%s has no pages", chapter.Name)
+	}
+
+	if viper.GetBool(key.HistorySaveOnRead) {
+		go func() {
+			if err := history.Save(chapter, history.ActionRead); err != nil {
+				log.Warn(err)
+			} else {
+				log.Info("history saved")
+			}
+		}()
+	}
+
+	return nil
+}
+
+// leave frees the pages of the chapter.
+// Pages are requested again when the chapter is opened next time,
+// since processing could have split them
+func (v *viewer) leave(chapter *source.Chapter) {
+	chapter.CleanPages()
+	chapter.Pages = nil
+
+	if err := chapter.ClearStaged(); err != nil {
+		log.Warn(err)
+	}
+}
+
+// size returns the size of the terminal in cells
+func (v *viewer) size() (cols, rows int) {
+	if v.tty == nil {
+		return 80, 24
+	}
+
+	cols, rows, err := term.GetSize(int(v.tty.Fd()))
+	if err != nil || cols <= 0 || rows <= 1 {
+		return 80, 24
+	}
+
+	return cols, rows
+}
+
+func (v *viewer) render() error {
+	_ = v.encoder.Clear(v.out)
+	_, _ = v.out.WriteString("\x1b[2J\x1b[H")
+
+	var (
+		chapter    = v.current()
+		page       = chapter.Pages[v.page]
+		cols, rows = v.size()
+	)
+
+	if page.Contents != nil {
+		img, _, err := image.Decode(bytes.NewReader(page.Contents.Bytes()))
+		if err != nil {
+			log.Warn(err)
+		} else {
+			var (
+				cellWidth, cellHeight int
+				bounds                = img.Bounds()
+			)
+
+			if v.tty != nil {
+				cellWidth, cellHeight = cellSize(v.tty)
+			}
+
+			// the last row is for the status line
+			placement := graphics.Fit(bounds.Dx(), bounds.Dy(), graphics.Area{
+				Cols:       cols,
+				Rows:       rows - 1,
+				CellWidth:  cellWidth,
+				CellHeight: cellHeight,
+			})
+
+			// pages are centered horizontally
+			_, _ = fmt.Fprintf(v.out, "\x1b[1;%dH", (cols-placement.Cols)/2+1)
+			if err = v.encoder.Encode(v.out, img, placement); err != nil {
+				return err
+			}
+		}
+	}
+
+	name := chapter.Name
+	if chapter.Manga != nil {
+		name = chapter.Manga.Name + " : " + name
+	}
+
+	v.status(fmt.Sprintf(
+		"%s  %d/%d  %s",
+		name,
+		v.page+1,
+		len(chapter.Pages),
+		style.Faint(keysHelp),
+	))
+
+	return nil
+}
+
+// status shows the message in the last line of the terminal
+func (v *viewer) status(message string) {
+	_, rows := v.size()
+	_, _ = fmt.Fprintf(v.out, "\x1b[%d;1H\x1b[2K%s", rows, message)
+	_ = v.out.Flush()
+}
+
+// waitKey blocks until any key is pressed
+func (v *viewer) waitKey() {
+	_, _ = v.in.Read(make([]byte, 16))
+}
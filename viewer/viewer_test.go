@@ -0,0 +1,101 @@
+package viewer
+
+import (
+	"bufio"
+	"bytes"
+	"image"
+	"image/png"
+	"io"
+	"testing"
+
+	"github.com/metafates/mangal/graphics"
+	"github.com/metafates/mangal/source"
+	"github.com/samber/lo"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// recorder is the encoder that records the width of the drawn images
+type recorder struct {
+	widths []int
+}
+
+func (r *recorder) Encode(_ io.Writer, img image.Image, _ graphics.Placement) error {
+	r.widths = append(r.widths, img.Bounds().Dx())
+	return nil
+}
+
+func (*recorder) Clear(io.Writer) error {
+	return nil
+}
+
+// keyReader returns one key per read
+type keyReader struct {
+	keys []string
+}
+
+func (k *keyReader) Read(b []byte) (int, error) {
+	if len(k.keys) == 0 {
+		return 0, io.EOF
+	}
+
+	n := copy(b, k.keys[0])
+	k.keys = k.keys[1:]
+	return n, nil
+}
+
+func TestParseKey(t *testing.T) {
+	Convey("Given keys", t, func() {
+		Convey("Then arrows should turn pages", func() {
+			So(parseKey([]byte("\x1b[C")), ShouldEqual, actionNextPage)
+			So(parseKey([]byte("\x1bOD")), ShouldEqual, actionPrevPage)
+		})
+
+		Convey("Then brackets should switch chapters", func() {
+			So(parseKey([]byte("]")), ShouldEqual, actionNextChapter)
+			So(parseKey([]byte("[")), ShouldEqual, actionPrevChapter)
+		})
+
+		Convey("Then ctrl+c should quit", func() {
+			So(parseKey([]byte{3}), ShouldEqual, actionQuit)
+		})
+
+		Convey("Then unknown keys should do nothing", func() {
+			So(parseKey([]byte("x")), ShouldEqual, actionNone)
+		})
+	})
+}
+
+func TestLoop(t *testing.T) {
+	Convey("Given a chapter with three pages", t, func() {
+		chapter := &source.Chapter{Name: "chapter"}
+		for width := 1; width <= 3; width++ {
+			var buf bytes.Buffer
+			lo.Must0(png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, 4))))
+			chapter.Pages = append(chapter.Pages, &source.Page{Contents: &buf, Chapter: chapter})
+		}
+
+		var (
+			encoder = &recorder{}
+			out     bytes.Buffer
+		)
+
+		v := &viewer{
+			encoder:  encoder,
+			out:      bufio.NewWriter(&out),
+			chapters: []*source.Chapter{chapter},
+		}
+
+		Convey("When pages are turned", func() {
+			v.in = &keyReader{keys: []string{"l", "l", "l", "g", "G", "h", "[", "x", "q", "l"}}
+			So(v.loop(), ShouldBeNil)
+
+			Convey("Then pages should be drawn in order and stay in bounds", func() {
+				So(encoder.widths, ShouldResemble, []int{1, 2, 3, 3, 1, 3, 2, 2, 2})
+			})
+
+			Convey("Then the status line should show the page number", func() {
+				So(out.String(), ShouldContainSubstring, "chapter  2/3")
+			})
+		})
+	})
+}
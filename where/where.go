@@ -12,6 +12,9 @@ import (
 
 const EnvConfigPath = "MANGAL_CONFIG_PATH"
 
+// StagingDirname is the name of the staging directory inside the temp directory
+const StagingDirname = "staging"
+
 // mkdir creates a directory and all parent directories if they don't exist
 // will return the path of the directory
 func mkdir(path string) string {
@@ -61,6 +64,22 @@ func History() string {
 	return filepath.Join(Config(), "history.json")
 }
 
+// Queue path to the file
+// Will create the directory if it doesn't exist
+func Queue() string {
+	return filepath.Join(Config(), "queue.json")
+}
+
+// Volumes path to the file with chapters of the saved volumes
+func Volumes() string {
+	return filepath.Join(Config(), "volumes.json")
+}
+
+// Library path to the index of the downloaded manga
+func Library() string {
+	return filepath.Join(Config(), "library.json")
+}
+
 // Downloads path
 // Will create the directory if it doesn't exist
 func Downloads() string {
@@ -88,9 +107,26 @@ func Cache() string {
 	return mkdir(cacheDir)
 }
 
+// HTTPCache path to the directory of the cached http responses
+// Will create the directory if it doesn't exist
+func HTTPCache() string {
+	return mkdir(filepath.Join(Cache(), "http"))
+}
+
 // Temp path
 // Will create the directory if it doesn't exist
 func Temp() string {
 	tempDir := filepath.Join(os.TempDir(), constant.Mangal)
 	return mkdir(tempDir)
 }
+
+// Staging path for the downloaded pages of the unfinished chapters
+// Will create the directory if it doesn't exist
+func Staging() string {
+	return mkdir(filepath.Join(Temp(), StagingDirname))
+}
+
+// Subscriptions path to the file with manga that are synced by the sync command
+func Subscriptions() string {
+	return filepath.Join(Config(), "subscriptions.json")
+}